@@ -1,22 +1,43 @@
 package commands
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/cjnosal/manifer/v2/lib"
+	"github.com/cjnosal/manifer/v2/pkg/composer"
+	"github.com/cjnosal/manifer/v2/pkg/diff"
 	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/plan"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
 )
 
 type composeCmd struct {
 	templatePath string
+	planPath     string
 	scenarios    []string
 	showPlan     bool
 	showDiff     bool
+	semanticDiff bool
+	ignoreOrder  bool
+	diffFormat   string
+	fromStep     int
+	toStep       int
+	onlyScenario []string
+	dumpDir      string
+	blame        bool
+	compare      bool
+	strict       bool
+	reportVars   bool
+	varsSeed     string
 
 	manifer lib.Manifer
 
@@ -35,8 +56,11 @@ func NewComposeCommand(l io.Writer, w io.Writer, m lib.Manifer) *cobra.Command {
 	cobraCompose := &cobra.Command{
 		Use:   "compose",
 		Short: "compose a yml file from snippets.",
-		Long: `compose --template <template path> (--library <library path>...) (--scenario <scenario>...) [--print] [--diff] [-- passthrough flags ...] [\;] :
+		Long: `compose --template <template path> ((--library <library path>...) (--scenario <scenario>...) | --plan-file <plan path>) [--print] [--diff [--diff-format <pretty|unified|json>] [--semantic-diff] [--ignore-order]] [--from-step <n>] [--to-step <n>] [--only-scenario <scenario>...] [--dump-dir <dir>] [--blame] [--strict] [--report-vars] [--compare] [--vars-store-seed <seed>] [-- passthrough flags ...] [\;] :
   compose a yml file from snippets. Use '\;' as a separator when reusing a scenario with different variables.
+  Step selection and dumps apply to the initial composition. Blame is printed for each composition. --strict checks the final output, and that steps of each composition do not override other scenarios.
+  With --compare, print the paths changed between the initial composition and a single composition after '\;'.
+  With --vars-store-seed, generated credentials are derived from the seed and kept in memory instead of the vars store file.
 `,
 		Run:              compose.execute,
 		TraverseChildren: true,
@@ -45,8 +69,21 @@ func NewComposeCommand(l io.Writer, w io.Writer, m lib.Manifer) *cobra.Command {
 	cobraCompose.Flags().StringVarP(&compose.templatePath, "template", "t", "", "Path to initial template file")
 	cobraCompose.Flags().StringSliceVarP(&libraryPaths, "library", "l", []string{}, "Path to library file")
 	cobraCompose.Flags().StringSliceVarP(&compose.scenarios, "scenario", "s", []string{}, "Scenario name in library")
+	cobraCompose.Flags().StringVar(&compose.planPath, "plan-file", "", "Path to a saved execution plan to run instead of resolving scenarios")
 	cobraCompose.Flags().BoolVarP(&compose.showPlan, "print", "p", false, "Show snippets and arguments being applied")
 	cobraCompose.Flags().BoolVarP(&compose.showDiff, "diff", "d", false, "Show diff after each snippet is applied")
+	cobraCompose.Flags().StringVar(&compose.diffFormat, "diff-format", diff.FormatPretty, "Diff output format (pretty, unified, or json)")
+	cobraCompose.Flags().BoolVar(&compose.semanticDiff, "semantic-diff", false, "Show added, removed, and changed paths instead of a text diff")
+	cobraCompose.Flags().BoolVar(&compose.ignoreOrder, "ignore-order", false, "Match list elements by name or value in semantic diffs")
+	cobraCompose.Flags().IntVar(&compose.fromStep, "from-step", 0, "First plan step to apply (1-based)")
+	cobraCompose.Flags().IntVar(&compose.toStep, "to-step", 0, "Last plan step to apply (1-based)")
+	cobraCompose.Flags().StringSliceVar(&compose.onlyScenario, "only-scenario", []string{}, "Only apply steps contributed by this scenario")
+	cobraCompose.Flags().StringVar(&compose.dumpDir, "dump-dir", "", "Directory to save the output of each step")
+	cobraCompose.Flags().BoolVar(&compose.blame, "blame", false, "Show which step last changed each path of the output")
+	cobraCompose.Flags().BoolVar(&compose.strict, "strict", false, "Fail if the output contains unresolved variables or steps override other scenarios")
+	cobraCompose.Flags().BoolVar(&compose.reportVars, "report-vars", false, "Show unresolved variables and provided variables that were never used")
+	cobraCompose.Flags().BoolVar(&compose.compare, "compare", false, "Compare the output of two compositions separated by '\\;'")
+	cobraCompose.Flags().StringVar(&compose.varsSeed, "vars-store-seed", "", "Generate reproducible vars store values from this seed without reading or writing the vars store file")
 
 	return cobraCompose
 }
@@ -61,67 +98,327 @@ func (p *composeCmd) execute(cmd *cobra.Command, args []string) {
 
 	initialArgs, additionalCompositions := p.split(args)
 
-	libraryPaths := libraryPaths
-	outBytes, err := p.manifer.Compose(
-		p.templatePath,
-		libraryPaths,
-		p.scenarios,
-		initialArgs,
-		p.showPlan,
-		p.showDiff,
-	)
+	if !validDiffFormat(p.diffFormat) {
+		p.logger.Printf("Unknown diff format %s", p.diffFormat)
+		p.logger.Printf(cmd.Long)
+		os.Exit(1)
+	}
+
+	if p.semanticDiff && p.diffFormat == diff.FormatUnified {
+		p.logger.Printf("--semantic-diff can not be combined with --diff-format unified")
+		p.logger.Printf(cmd.Long)
+		os.Exit(1)
+	}
+
+	if p.fromStep < 0 || p.toStep < 0 {
+		p.logger.Printf("--from-step and --to-step must not be negative")
+		p.logger.Printf(cmd.Long)
+		os.Exit(1)
+	}
+
+	if p.toStep > 0 && p.fromStep > p.toStep {
+		p.logger.Printf("--from-step must not be after --to-step")
+		p.logger.Printf(cmd.Long)
+		os.Exit(1)
+	}
 
+	if p.compare {
+		if len(additionalCompositions) != 1 {
+			p.logger.Printf("--compare requires one composition after '\\;'")
+			p.logger.Printf(cmd.Long)
+			os.Exit(1)
+		}
+		if p.blame || p.dumpDir != "" || p.fromStep > 0 || p.toStep > 0 || len(p.onlyScenario) > 0 || p.strict || p.reportVars {
+			p.logger.Printf("--compare can not be combined with step selection, dumps, blame, or variable checks")
+			p.logger.Printf(cmd.Long)
+			os.Exit(1)
+		}
+		p.compareCompositions(initialArgs, additionalCompositions[0])
+		return
+	}
+
+	libraryPaths := libraryPaths
+	outBytes, err := p.composePlan(libraryPaths, initialArgs, len(additionalCompositions) == 0)
 	if err != nil {
-		p.logger.Printf("%v\n  while composing initial output", err)
+		p.logger.Printf("%v\n  while composing initial output", p.manifer.RedactError(err))
 		os.Exit(1)
 	}
 
 	// additionalCompositions will:
-	// - invoke Compose using outBytes as template,
+	// - compose a new plan using outBytes as template,
 	// - accumulate libraryPaths,
 	// - preserve plan/diff,
 	// - reset scenarios, passthrough args
 	for i, comp := range additionalCompositions {
 		template := &file.TaggedBytes{Tag: p.templatePath, Bytes: outBytes}
-		set := flag.NewFlagSet("additional composition", flag.ContinueOnError)
-		set.SetOutput(&nullWriter{}) // suppress default error output
-		var newLibraryPaths arrayFlags
-		var newScenarios arrayFlags
-		set.Var(&newLibraryPaths, "library", "Path to library file")
-		set.Var(&newLibraryPaths, "l", "Path to library file")
-		set.Var(&newScenarios, "scenario", "Scenario name in library")
-		set.Var(&newScenarios, "s", "Scenario name in library")
-		err := set.Parse(comp)
+		set, newLibraryPaths, newScenarios := p.parseComposition(comp, i+1)
+		libraryPaths = append(libraryPaths, newLibraryPaths...)
+
+		executionPlan, err := p.manifer.GetPlan(libraryPaths, newScenarios, set.Args())
 		if err != nil {
-			p.logger.Printf("%v\n  while parsing flags for composition %d\nUsage for additional compositions:\n", err, i+1)
-			set.SetOutput(p.logger.Writer())
-			set.PrintDefaults()
+			p.logger.Printf("%v\n  while trying to resolve scenarios\n  during composition %d", p.manifer.RedactError(err), i+1)
+			os.Exit(1)
+		}
+		err = p.checkConflicts(executionPlan)
+		if err != nil {
+			p.logger.Printf("%v\n  during composition %d", p.manifer.RedactError(err), i+1)
 			os.Exit(1)
 		}
-		libraryPaths = append(libraryPaths, newLibraryPaths...)
-
-		outBytes, err = p.manifer.ComposeFromBytes(
-			template,
-			libraryPaths,
-			newScenarios,
-			set.Args(),
-			p.showPlan,
-			p.showDiff,
-		)
 
+		final := i == len(additionalCompositions)-1
+		if p.blame {
+			outBytes, err = p.composeWithBlame(template, executionPlan, p.composeOptions(final))
+		} else {
+			outBytes, err = p.manifer.ComposePlan(template, executionPlan, p.composeOptions(final))
+		}
 		if err != nil {
-			p.logger.Printf("%v\n  during composition %d", err, i+1)
+			p.logger.Printf("%v\n  during composition %d", p.manifer.RedactError(err), i+1)
 			os.Exit(1)
 		}
+		if p.reportVars {
+			err = p.printVarsReport(template, executionPlan, outBytes, final)
+			if err != nil {
+				p.logger.Printf("%v\n  during composition %d", p.manifer.RedactError(err), i+1)
+				os.Exit(1)
+			}
+		}
 	}
 
 	_, err = p.writer.Write(outBytes)
 	if err != nil {
-		p.logger.Printf("%v\n  while writing composed output", err)
+		p.logger.Printf("%v\n  while writing composed output", p.manifer.RedactError(err))
 		os.Exit(1)
 	}
 }
 
+func (p *composeCmd) composePlan(libraryPaths []string, passthrough []string, final bool) ([]byte, error) {
+	executionPlan, err := p.resolvePlan(libraryPaths, passthrough)
+	if err != nil {
+		return nil, err
+	}
+	err = p.checkConflicts(executionPlan)
+	if err != nil {
+		return nil, err
+	}
+	template, err := p.loadTemplate()
+	if err != nil {
+		return nil, err
+	}
+	options := p.composeOptions(final)
+	options.FromStep = p.fromStep
+	options.ToStep = p.toStep
+	options.Scenarios = p.onlyScenario
+	options.DumpDir = p.dumpDir
+	var out []byte
+	if p.blame {
+		out, err = p.composeWithBlame(template, executionPlan, options)
+	} else {
+		out, err = p.manifer.ComposePlan(template, executionPlan, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.reportVars {
+		err = p.printVarsReport(template, executionPlan, out, final)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (p *composeCmd) composeWithBlame(template *file.TaggedBytes, executionPlan *plan.Plan, options composer.Options) ([]byte, error) {
+	file := &file.FileIO{}
+	out, entries, err := p.manifer.Blame(template, executionPlan, options)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		if entry.Snippet != "" {
+			entries[i].Snippet, err = file.ResolveRelativeFromWD(entry.Snippet)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while resolving relative snippet path %s", err, entry.Snippet)
+			}
+		}
+		if filepath.IsAbs(entry.Library) {
+			entries[i].Library, err = file.ResolveRelativeFromWD(entry.Library)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while resolving relative library path %s", err, entry.Library)
+			}
+		}
+	}
+	yaml := &yaml.Yaml{}
+	bytes, err := yaml.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while marshaling blame", err)
+	}
+	p.logger.Writer().Write([]byte("\nBlame:\n"))
+	p.logger.Writer().Write(bytes)
+	return out, nil
+}
+
+// in strict mode steps must not override paths modified by other scenarios.
+// Possible conflicts depend on variables that are only known while composing, so they are left to inspect --conflicts.
+func (p *composeCmd) checkConflicts(executionPlan *plan.Plan) error {
+	if !p.strict {
+		return nil
+	}
+	conflicts, err := p.manifer.GetConflicts(executionPlan)
+	if err != nil {
+		return fmt.Errorf("%w\n  while checking for conflicts", err)
+	}
+	lines := []string{}
+	for _, c := range conflicts {
+		if c.Possible {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  step %d %s overrides step %d %s at %s", c.Winner.Step, c.Winner.Snippet, c.Overridden.Step, c.Overridden.Snippet, strings.Join(c.Overridden.Paths, ", ")))
+	}
+	if len(lines) > 0 {
+		return fmt.Errorf("Steps override other scenarios:\n%s", strings.Join(lines, "\n"))
+	}
+	return nil
+}
+
+// prints the report to the logger, and fails in strict mode after the report is printed
+func (p *composeCmd) printVarsReport(template *file.TaggedBytes, executionPlan *plan.Plan, out []byte, final bool) error {
+	report, err := p.manifer.ReportVars(template, executionPlan, out)
+	if err != nil {
+		return err
+	}
+	yaml := &yaml.Yaml{}
+	bytes, err := yaml.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("%w\n  while marshaling variable report", err)
+	}
+	p.logger.Writer().Write([]byte("\nVariables:\n"))
+	p.logger.Writer().Write(bytes)
+	if p.strict && final && len(report.Unresolved) > 0 {
+		return fmt.Errorf("Unresolved variables in output")
+	}
+	return nil
+}
+
+func (p *composeCmd) compareCompositions(initialArgs []string, comp []string) {
+	first, err := p.resolvePlan(libraryPaths, initialArgs)
+	if err != nil {
+		p.logger.Printf("%v\n  while resolving initial composition", p.manifer.RedactError(err))
+		os.Exit(1)
+	}
+	set, newLibraryPaths, newScenarios := p.parseComposition(comp, 1)
+	second, err := p.manifer.GetPlan(append(libraryPaths, newLibraryPaths...), newScenarios, set.Args())
+	if err != nil {
+		p.logger.Printf("%v\n  while trying to resolve scenarios\n  during composition 1", p.manifer.RedactError(err))
+		os.Exit(1)
+	}
+	template, err := p.loadTemplate()
+	if err != nil {
+		p.logger.Printf("%v\n  while comparing compositions", p.manifer.RedactError(err))
+		os.Exit(1)
+	}
+
+	changes, err := p.manifer.Compare(template, first, second, p.diffOptions())
+	if err != nil {
+		p.logger.Printf("%v\n  while comparing compositions", p.manifer.RedactError(err))
+		os.Exit(1)
+	}
+
+	var outBytes []byte
+	if p.diffFormat == diff.FormatJson {
+		outBytes, err = json.Marshal(changes)
+		if err != nil {
+			p.logger.Printf("%v\n  while marshaling changes", p.manifer.RedactError(err))
+			os.Exit(1)
+		}
+	} else {
+		outBytes = []byte(diff.FormatChanges(changes))
+	}
+	_, err = p.writer.Write(outBytes)
+	if err != nil {
+		p.logger.Printf("%v\n  while writing comparison", p.manifer.RedactError(err))
+		os.Exit(1)
+	}
+}
+
+// flags for a composition after '\;' - exits with usage on error
+func (p *composeCmd) parseComposition(comp []string, index int) (*flag.FlagSet, arrayFlags, arrayFlags) {
+	set := flag.NewFlagSet("additional composition", flag.ContinueOnError)
+	set.SetOutput(&nullWriter{}) // suppress default error output
+	var newLibraryPaths arrayFlags
+	var newScenarios arrayFlags
+	set.Var(&newLibraryPaths, "library", "Path to library file")
+	set.Var(&newLibraryPaths, "l", "Path to library file")
+	set.Var(&newScenarios, "scenario", "Scenario name in library")
+	set.Var(&newScenarios, "s", "Scenario name in library")
+	err := set.Parse(comp)
+	if err != nil {
+		p.logger.Printf("%v\n  while parsing flags for composition %d\nUsage for additional compositions:\n", p.manifer.RedactError(err), index)
+		set.SetOutput(p.logger.Writer())
+		set.PrintDefaults()
+		os.Exit(1)
+	}
+	return set, newLibraryPaths, newScenarios
+}
+
+// initial plan from the plan file or selected scenarios
+func (p *composeCmd) resolvePlan(libraryPaths []string, passthrough []string) (*plan.Plan, error) {
+	var executionPlan *plan.Plan
+	var err error
+	if p.planPath != "" {
+		if len(p.scenarios) > 0 || len(passthrough) > 0 {
+			return nil, fmt.Errorf("Scenarios and passthrough flags can not be combined with a plan file")
+		}
+		executionPlan, err = p.manifer.LoadPlan(p.planPath)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while loading plan file %s", err, p.planPath)
+		}
+	} else {
+		executionPlan, err = p.manifer.GetPlan(libraryPaths, p.scenarios, passthrough)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while trying to resolve scenarios", err)
+		}
+	}
+	return executionPlan, nil
+}
+
+func (p *composeCmd) loadTemplate() (*file.TaggedBytes, error) {
+	file := &file.FileIO{}
+	template, err := file.ReadAndTag(p.templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to load template %s", err, p.templatePath)
+	}
+	return template, nil
+}
+
+// with --report-vars strict mode is checked by printVarsReport
+func (p *composeCmd) composeOptions(final bool) composer.Options {
+	options := p.diffOptions()
+	options.Strict = p.strict && final && !p.reportVars
+	return options
+}
+
+func (p *composeCmd) diffOptions() composer.Options {
+	return composer.Options{
+		ShowPlan:     p.showPlan,
+		ShowDiff:     p.showDiff,
+		SemanticDiff: p.semanticDiff,
+		DiffFormat:   p.diffFormat,
+		DiffOptions: diff.CompareOptions{
+			IgnoreOrder: p.ignoreOrder,
+		},
+		VarsStoreSeed: p.varsSeed,
+	}
+}
+
+func validDiffFormat(format string) bool {
+	for _, f := range diff.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *composeCmd) split(args []string) ([]string, [][]string) {
 	comps := [][]string{}
 
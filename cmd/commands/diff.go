@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cjnosal/manifer/v2/lib"
+	"github.com/cjnosal/manifer/v2/pkg/diff"
+)
+
+type diffCmd struct {
+	ignoreOrder bool
+	printJson   bool
+
+	logger  *log.Logger
+	writer  io.Writer
+	manifer lib.Manifer
+}
+
+var diffCommand diffCmd
+
+func NewDiffCommand(l io.Writer, w io.Writer, m lib.Manifer) *cobra.Command {
+
+	diffCommand.logger = log.New(l, "", 0)
+	diffCommand.writer = w
+	diffCommand.manifer = m
+
+	cobraDiff := &cobra.Command{
+		Use:   "diff",
+		Short: "show paths added, removed, or changed between two yml files.",
+		Long: `diff [--ignore-order] [--json] <first yml path> <second yml path>:
+  show paths added, removed, or changed between two yml files.
+`,
+		Args:             cobra.ExactArgs(2),
+		Run:              diffCommand.execute,
+		TraverseChildren: true,
+	}
+
+	cobraDiff.Flags().BoolVar(&diffCommand.ignoreOrder, "ignore-order", false, "Match list elements by name or value instead of by index")
+	cobraDiff.Flags().BoolVarP(&diffCommand.printJson, "json", "j", false, "Print output in json format")
+
+	return cobraDiff
+}
+
+func (p *diffCmd) execute(cmd *cobra.Command, args []string) {
+
+	changes, err := p.manifer.Diff(args[0], args[1], diff.CompareOptions{
+		IgnoreOrder: p.ignoreOrder,
+	})
+	if err != nil {
+		p.logger.Printf("%v\n  while comparing %s and %s", err, args[0], args[1])
+		os.Exit(1)
+	}
+
+	var outBytes []byte
+	if p.printJson {
+		outBytes, err = json.Marshal(changes)
+		if err != nil {
+			p.logger.Printf("%v\n  while marshaling changes", err)
+			os.Exit(1)
+		}
+	} else {
+		outBytes = []byte(diff.FormatChanges(changes))
+	}
+
+	_, err = p.writer.Write(outBytes)
+	if err != nil {
+		p.logger.Printf("%v\n  while writing diff output", err)
+		os.Exit(1)
+	}
+}
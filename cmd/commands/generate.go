@@ -46,7 +46,7 @@ func NewGenerateCommand(l io.Writer, w io.Writer, m lib.Manifer) *cobra.Command
 	cobraGenerate.Flags().StringVarP(&generate.lib, "out", "o", "", "Path to save generated library file")
 	cobraGenerate.Flags().StringVarP(&generate.template, "template", "t", "", "Template to generate from")
 	cobraGenerate.Flags().StringVarP(&generate.dir, "directory", "d", "", "Directory to save generated snippets (default out/snippets)")
-	cobraGenerate.Flags().StringVarP(&generate.libType, "processor", "y", "", "Yaml backend for this library (opsfile or yq)")
+	cobraGenerate.Flags().StringVarP(&generate.libType, "processor", "y", "", "Yaml backend for this library (opsfile, yq, jsonpatch, or yq4)")
 
 	return cobraGenerate
 }
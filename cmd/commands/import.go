@@ -62,7 +62,10 @@ func (p *importCmd) execute(cmd *cobra.Command, args []string) {
 	}
 
 	lib := library.Library{}
-	for _, t := range library.Types {
+	for _, t := range p.manifer.ProcessorTypes() {
+		if !p.manifer.ProcessorCapabilities(t).Import {
+			continue
+		}
 		tlib, err := p.manifer.Import(t, p.path, p.recursive, p.out)
 
 		if err != nil {
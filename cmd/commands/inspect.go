@@ -19,6 +19,9 @@ type inspectCmd struct {
 	printJson bool
 	printPlan bool
 	printTree bool
+	planPath  string
+	conflicts bool
+	explain   bool
 
 	logger  *log.Logger
 	writer  io.Writer
@@ -35,8 +38,10 @@ func NewInspectCommand(l io.Writer, w io.Writer, m lib.Manifer) *cobra.Command {
 	cobraInspect := &cobra.Command{
 		Use:   "inspect",
 		Short: "inspect scenarios as a dependency tree or execution plan.",
-		Long: `inspect (--library <library path>...) [--tree|--plan] (-s <scenario name>...) [-- passthrough flags ...]:
+		Long: `inspect (--library <library path>...) [--tree|--plan|--plan-file <plan path>|--conflicts|--explain-vars] (-s <scenario name>...) [-- passthrough flags ...]:
   inspect scenarios as a dependency tree or execution plan.
+  --conflicts reports steps from different scenarios that modify overlapping paths.
+  --explain-vars shows the effective value of each variable for each step, the scope that supplied it, and the values it shadowed.
 `,
 		Run:              inspect.execute,
 		TraverseChildren: true,
@@ -46,6 +51,9 @@ func NewInspectCommand(l io.Writer, w io.Writer, m lib.Manifer) *cobra.Command {
 	cobraInspect.Flags().BoolVarP(&inspect.printJson, "json", "j", false, "Print output in json format")
 	cobraInspect.Flags().BoolVarP(&inspect.printPlan, "plan", "p", false, "Print execution plan")
 	cobraInspect.Flags().BoolVarP(&inspect.printTree, "tree", "t", false, "Print dependency tree (default)")
+	cobraInspect.Flags().StringVar(&inspect.planPath, "plan-file", "", "Save execution plan for compose --plan-file")
+	cobraInspect.Flags().BoolVar(&inspect.conflicts, "conflicts", false, "Print steps from different scenarios that modify overlapping paths")
+	cobraInspect.Flags().BoolVar(&inspect.explain, "explain-vars", false, "Print the effective value and source of each variable for each step")
 	cobraInspect.Flags().StringSliceVarP(&inspect.scenarios, "scenario", "s", []string{}, "Scenario name in library")
 
 	return cobraInspect
@@ -65,6 +73,70 @@ func (p *inspectCmd) execute(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if p.planPath != "" {
+		executionPlan, err := p.manifer.GetPlan(libraryPaths, p.scenarios, args)
+		if err != nil {
+			p.logger.Printf("%v\n  while resolving execution plan", err)
+			os.Exit(1)
+		}
+		err = p.manifer.SavePlan(p.planPath, executionPlan)
+		if err != nil {
+			p.logger.Printf("%v\n  while saving execution plan", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if p.conflicts {
+		executionPlan, err := p.manifer.GetPlan(libraryPaths, p.scenarios, args)
+		if err != nil {
+			p.logger.Printf("%v\n  while resolving execution plan", err)
+			os.Exit(1)
+		}
+		conflicts, err := p.manifer.GetConflicts(executionPlan)
+		if err != nil {
+			p.logger.Printf("%v\n  while checking for conflicts", err)
+			os.Exit(1)
+		}
+		var outBytes []byte
+		if p.printJson {
+			outBytes = p.formatJson(conflicts)
+		} else {
+			outBytes = p.formatYaml(conflicts)
+		}
+		_, err = p.writer.Write(outBytes)
+		if err != nil {
+			p.logger.Printf("%v\n  while writing inspect output", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if p.explain {
+		executionPlan, err := p.manifer.GetPlan(libraryPaths, p.scenarios, args)
+		if err != nil {
+			p.logger.Printf("%v\n  while resolving execution plan", err)
+			os.Exit(1)
+		}
+		explained, err := p.manifer.ExplainVars(executionPlan)
+		if err != nil {
+			p.logger.Printf("%v\n  while explaining variables", err)
+			os.Exit(1)
+		}
+		var outBytes []byte
+		if p.printJson {
+			outBytes = p.formatJson(explained)
+		} else {
+			outBytes = p.formatYaml(explained)
+		}
+		_, err = p.writer.Write(outBytes)
+		if err != nil {
+			p.logger.Printf("%v\n  while writing inspect output", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	nodes := library.ScenarioNodes{}
 	for _, name := range p.scenarios {
 		node, err := p.manifer.GetScenarioTree(libraryPaths, name)
@@ -74,7 +146,7 @@ func (p *inspectCmd) execute(cmd *cobra.Command, args []string) {
 		}
 		nodes = append(nodes, node)
 	}
-	for _, t := range library.Types {
+	for _, t := range p.manifer.ProcessorTypes() {
 		passthroughNode, remainder, err := p.manifer.GetSnippetScenarioNode(t, args)
 		if err != nil {
 			p.logger.Printf("%v\n  while trying to parse passthrough args", err)
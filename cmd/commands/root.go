@@ -11,6 +11,7 @@ import (
 	"github.com/cjnosal/manifer/v2/lib"
 	"github.com/cjnosal/manifer/v2/pkg/file"
 	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/redact"
 	"github.com/cjnosal/manifer/v2/pkg/yaml"
 )
 
@@ -18,24 +19,37 @@ var rootCmd = &cobra.Command{
 	Use:              "manifer",
 	Short:            "a yaml composer",
 	TraverseChildren: true,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		if len(libraryPaths) == 0 {
-			libraryPaths = defaultLibPaths
-		}
-	},
 }
 
 var libraryPaths []string
 var defaultLibPaths []string
+var secretPatterns []string
+var envSecretPatterns []string
 
 func Init(logger io.Writer, writer io.Writer, maniferLib lib.Manifer) *cobra.Command {
 	rootCmd.PersistentFlags().StringSliceVarP(&libraryPaths, "library", "l", []string{}, "Path to library file")
+	rootCmd.PersistentFlags().StringSliceVar(&secretPatterns, "secret-pattern", []string{}, "Mask values of variables with names matching this glob pattern (in addition to "+strings.Join(redact.DefaultPatterns, ", ")+")")
+
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if len(libraryPaths) == 0 {
+			libraryPaths = defaultLibPaths
+		}
+		patterns := append([]string{}, redact.DefaultPatterns...)
+		patterns = append(patterns, envSecretPatterns...)
+		patterns = append(patterns, secretPatterns...)
+		maniferLib.SetSecretPatterns(patterns)
+	}
 
 	// register subcommands
 	rootCmd.AddCommand(NewComposeCommand(logger, writer, maniferLib))
 	rootCmd.AddCommand(NewListCommand(logger, writer, maniferLib))
 	rootCmd.AddCommand(NewSearchCommand(logger, writer, maniferLib))
+	rootCmd.AddCommand(NewWhereCommand(logger, writer, maniferLib))
 	rootCmd.AddCommand(NewInspectCommand(logger, writer, maniferLib))
+	rootCmd.AddCommand(NewVarsCommand(logger, writer, maniferLib))
+	rootCmd.AddCommand(NewDiffCommand(logger, writer, maniferLib))
+	rootCmd.AddCommand(NewTestCommand(logger, writer, maniferLib))
+	rootCmd.AddCommand(NewSnapshotCommand(logger, writer, maniferLib))
 	rootCmd.AddCommand(NewImportCommand(logger, writer, maniferLib))
 	rootCmd.AddCommand(NewGenerateCommand(logger, writer, maniferLib))
 	rootCmd.AddCommand(NewAddCommand(logger, writer, maniferLib))
@@ -43,6 +57,13 @@ func Init(logger io.Writer, writer io.Writer, maniferLib lib.Manifer) *cobra.Com
 	// viper.SetEnvPrefix("manifer")
 	viper.BindEnv("lib_path", "MANIFER_LIB_PATH")
 	viper.BindEnv("libs", "MANIFER_LIBS")
+	viper.BindEnv("secret_patterns", "MANIFER_SECRET_PATTERNS")
+
+	// additional secret patterns from env
+	envSecretPatternsString := viper.GetString("secret_patterns")
+	if len(envSecretPatternsString) > 0 {
+		envSecretPatterns = strings.Split(envSecretPatternsString, ",")
+	}
 
 	// specific libraries from env
 	envLibsString := viper.GetString("libs")
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cjnosal/manifer/v2/lib"
+	"github.com/cjnosal/manifer/v2/pkg/diff"
+	"github.com/cjnosal/manifer/v2/pkg/tester"
+)
+
+type snapshotCmd struct {
+	dir       string
+	update    bool
+	names     []string
+	printJson bool
+
+	logger  *log.Logger
+	writer  io.Writer
+	manifer lib.Manifer
+}
+
+var snapshot snapshotCmd
+
+func NewSnapshotCommand(l io.Writer, w io.Writer, m lib.Manifer) *cobra.Command {
+
+	snapshot.logger = log.New(l, "", 0)
+	snapshot.writer = w
+	snapshot.manifer = m
+
+	cobraSnapshot := &cobra.Command{
+		Use:   "snapshot",
+		Short: "compare the output of library tests to saved snapshots.",
+		Long: `snapshot (--library <library path>...) --dir <snapshot dir> [--update] [--name <test name>...] [--json]:
+  compose the template of each library test and compare the output to <snapshot dir>/<library name>/<test name>.yml.
+  Fails if any snapshot is missing or changed, unless --update rewrites them.
+`,
+		Run:              snapshot.execute,
+		TraverseChildren: true,
+	}
+
+	cobraSnapshot.Flags().StringSliceVarP(&libraryPaths, "library", "l", []string{}, "Path to library file")
+	cobraSnapshot.Flags().StringVarP(&snapshot.dir, "dir", "d", "", "Directory of snapshot files")
+	cobraSnapshot.Flags().BoolVarP(&snapshot.update, "update", "u", false, "Write missing or changed snapshots")
+	cobraSnapshot.Flags().StringSliceVarP(&snapshot.names, "name", "n", []string{}, "Only snapshot the test with this name")
+	cobraSnapshot.Flags().BoolVarP(&snapshot.printJson, "json", "j", false, "Print output in json format")
+
+	return cobraSnapshot
+}
+
+func (p *snapshotCmd) execute(cmd *cobra.Command, args []string) {
+
+	if len(libraryPaths) == 0 {
+		p.logger.Printf("Library not specified")
+		p.logger.Printf(cmd.Long)
+		os.Exit(1)
+	}
+
+	if p.dir == "" {
+		p.logger.Printf("Snapshot directory not specified")
+		p.logger.Printf(cmd.Long)
+		os.Exit(1)
+	}
+
+	results, err := p.manifer.Snapshot(libraryPaths, p.names, p.dir, p.update)
+	if err != nil {
+		p.logger.Printf("%v\n  while taking snapshots", err)
+		os.Exit(1)
+	}
+
+	var outBytes []byte
+	if p.printJson {
+		outBytes, err = json.Marshal(results)
+		if err != nil {
+			p.logger.Printf("%v\n  while marshaling snapshot results", err)
+			os.Exit(1)
+		}
+	} else {
+		outBytes = []byte(p.format(results))
+	}
+
+	_, err = p.writer.Write(outBytes)
+	if err != nil {
+		p.logger.Printf("%v\n  while writing snapshot output", err)
+		os.Exit(1)
+	}
+
+	for _, result := range results {
+		if result.Error != "" || result.Status == tester.SnapshotChanged || result.Status == tester.SnapshotMissing {
+			os.Exit(1)
+		}
+	}
+}
+
+// one line per snapshot followed by its indented changes or error, then a count of each status
+func (p *snapshotCmd) format(results []tester.SnapshotResult) string {
+	var b strings.Builder
+	counts := map[string]int{}
+	for _, result := range results {
+		status := result.Status
+		if result.Error != "" {
+			status = "error"
+		}
+		counts[status]++
+		fmt.Fprintf(&b, "%s %s (%s)\n", strings.ToUpper(status), result.Name, result.Path)
+		if result.Error != "" {
+			fmt.Fprintf(&b, "  %s\n", strings.Replace(result.Error, "\n", "\n  ", -1))
+		}
+		for _, line := range strings.Split(diff.FormatChanges(result.Changes), "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		}
+	}
+	summary := []string{}
+	for _, status := range []string{tester.SnapshotMatched, tester.SnapshotChanged, tester.SnapshotMissing, tester.SnapshotUpdated, "error"} {
+		if counts[status] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	if len(summary) == 0 {
+		summary = append(summary, "no tests")
+	}
+	fmt.Fprintf(&b, "%s\n", strings.Join(summary, ", "))
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cjnosal/manifer/v2/lib"
+	"github.com/cjnosal/manifer/v2/pkg/diff"
+	"github.com/cjnosal/manifer/v2/pkg/tester"
+)
+
+type testCmd struct {
+	names     []string
+	printJson bool
+
+	logger  *log.Logger
+	writer  io.Writer
+	manifer lib.Manifer
+}
+
+var testCommand testCmd
+
+func NewTestCommand(l io.Writer, w io.Writer, m lib.Manifer) *cobra.Command {
+
+	testCommand.logger = log.New(l, "", 0)
+	testCommand.writer = w
+	testCommand.manifer = m
+
+	cobraTest := &cobra.Command{
+		Use:   "test",
+		Short: "run the tests declared by libraries.",
+		Long: `test (--library <library path>...) [--name <test name>...] [--json]:
+  compose the template of each library test and compare the output to its expected file and assertions.
+  Fails if any test fails.
+`,
+		Run:              testCommand.execute,
+		TraverseChildren: true,
+	}
+
+	cobraTest.Flags().StringSliceVarP(&libraryPaths, "library", "l", []string{}, "Path to library file")
+	cobraTest.Flags().StringSliceVarP(&testCommand.names, "name", "n", []string{}, "Only run the test with this name")
+	cobraTest.Flags().BoolVarP(&testCommand.printJson, "json", "j", false, "Print output in json format")
+
+	return cobraTest
+}
+
+func (p *testCmd) execute(cmd *cobra.Command, args []string) {
+
+	if len(libraryPaths) == 0 {
+		p.logger.Printf("Library not specified")
+		p.logger.Printf(cmd.Long)
+		os.Exit(1)
+	}
+
+	results, err := p.manifer.RunTests(libraryPaths, p.names)
+	if err != nil {
+		p.logger.Printf("%v\n  while running tests", err)
+		os.Exit(1)
+	}
+
+	var outBytes []byte
+	if p.printJson {
+		outBytes, err = json.Marshal(results)
+		if err != nil {
+			p.logger.Printf("%v\n  while marshaling test results", err)
+			os.Exit(1)
+		}
+	} else {
+		outBytes = []byte(p.format(results))
+	}
+
+	_, err = p.writer.Write(outBytes)
+	if err != nil {
+		p.logger.Printf("%v\n  while writing test output", err)
+		os.Exit(1)
+	}
+
+	for _, result := range results {
+		if !result.Passed {
+			os.Exit(1)
+		}
+	}
+}
+
+// one line per test followed by its indented changes, failed assertions, or error
+func (p *testCmd) format(results []tester.Result) string {
+	var b strings.Builder
+	failed := 0
+	for _, result := range results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(&b, "%s %s (%s)\n", status, result.Name, result.Library)
+		if result.Error != "" {
+			fmt.Fprintf(&b, "  %s\n", strings.Replace(result.Error, "\n", "\n  ", -1))
+		}
+		for _, line := range strings.Split(diff.FormatChanges(result.Changes), "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		}
+		for _, failure := range result.Failures {
+			fmt.Fprintf(&b, "  %s\n", failure)
+		}
+	}
+	fmt.Fprintf(&b, "%d passed, %d failed\n", len(results)-failed, failed)
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cjnosal/manifer/v2/lib"
+	"github.com/cjnosal/manifer/v2/pkg/scenario"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+)
+
+type varsCmd struct {
+	scenarios []string
+	printJson bool
+
+	logger  *log.Logger
+	writer  io.Writer
+	manifer lib.Manifer
+}
+
+var vars varsCmd
+
+func NewVarsCommand(l io.Writer, w io.Writer, m lib.Manifer) *cobra.Command {
+
+	vars.logger = log.New(l, "", 0)
+	vars.writer = w
+	vars.manifer = m
+
+	cobraVars := &cobra.Command{
+		Use:   "vars",
+		Short: "list variables referenced by the snippets of scenarios.",
+		Long: `vars (--library <library path>...) (-s <scenario name>...) [-- passthrough flags ...]:
+  list variables referenced by the snippets of scenarios, where values are supplied (highest precedence first), and snippets still missing a value.
+`,
+		Run:              vars.execute,
+		TraverseChildren: true,
+	}
+
+	cobraVars.Flags().StringSliceVarP(&libraryPaths, "library", "l", []string{}, "Path to library file")
+	cobraVars.Flags().BoolVarP(&vars.printJson, "json", "j", false, "Print output in json format")
+	cobraVars.Flags().StringSliceVarP(&vars.scenarios, "scenario", "s", []string{}, "Scenario name in library")
+
+	return cobraVars
+}
+
+func (p *varsCmd) execute(cmd *cobra.Command, args []string) {
+
+	if len(libraryPaths) == 0 {
+		p.logger.Printf("Library not specified")
+		p.logger.Printf(cmd.Long)
+		os.Exit(1)
+	}
+
+	if len(p.scenarios) == 0 {
+		p.logger.Printf("A scenario must be specified")
+		p.logger.Printf(cmd.Long)
+		os.Exit(1)
+	}
+
+	entries, err := p.manifer.ListVars(libraryPaths, p.scenarios, args)
+	if err != nil {
+		p.logger.Printf("%v\n  while listing variables", err)
+		os.Exit(1)
+	}
+
+	var outBytes []byte
+	if p.printJson {
+		outBytes = p.formatJson(entries)
+	} else {
+		outBytes = p.formatYaml(entries)
+	}
+
+	_, err = p.writer.Write(outBytes)
+	if err != nil {
+		p.logger.Printf("%v\n  while writing vars output", err)
+		os.Exit(1)
+	}
+}
+
+func (p *varsCmd) formatJson(entries []scenario.VarEntry) []byte {
+	bytes, _ := json.Marshal(entries)
+	return bytes
+}
+
+func (p *varsCmd) formatYaml(entries []scenario.VarEntry) []byte {
+	yaml := yaml.Yaml{}
+	bytes, _ := yaml.Marshal(entries)
+	return bytes
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cjnosal/manifer/v2/lib"
+	"github.com/cjnosal/manifer/v2/pkg/scenario"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+)
+
+type whereCmd struct {
+	printJson bool
+
+	logger  *log.Logger
+	writer  io.Writer
+	manifer lib.Manifer
+}
+
+var where whereCmd
+
+func NewWhereCommand(l io.Writer, w io.Writer, m lib.Manifer) *cobra.Command {
+
+	where.logger = log.New(l, "", 0)
+	where.writer = w
+	where.manifer = m
+
+	cobraWhere := &cobra.Command{
+		Use:   "where",
+		Short: "find scenarios with snippets that could modify a path.",
+		Long: `where (--library <library path>...) <path>:
+  find scenarios with snippets that could modify a path (e.g. /instance_groups/name=router/jobs).
+`,
+		Args:             cobra.ExactArgs(1),
+		Run:              where.execute,
+		TraverseChildren: true,
+	}
+
+	cobraWhere.Flags().StringSliceVarP(&libraryPaths, "library", "l", []string{}, "Path to library file")
+	cobraWhere.Flags().BoolVarP(&where.printJson, "json", "j", false, "Print output in json format")
+
+	return cobraWhere
+}
+
+func (p *whereCmd) execute(cmd *cobra.Command, args []string) {
+
+	if len(libraryPaths) == 0 {
+		p.logger.Printf("Library not specified")
+		p.logger.Printf(cmd.Long)
+		os.Exit(1)
+	}
+
+	entries, err := p.manifer.Where(libraryPaths, args[0])
+
+	if err != nil {
+		p.logger.Printf("%v\n  while looking up path %s", err, args[0])
+		os.Exit(1)
+	}
+
+	var outBytes []byte
+	if p.printJson {
+		outBytes = p.formatJson(entries)
+	} else {
+		outBytes = p.formatYaml(entries)
+	}
+
+	_, err = p.writer.Write(outBytes)
+	if err != nil {
+		p.logger.Printf("%v\n  while writing where output", err)
+		os.Exit(1)
+	}
+}
+
+func (p *whereCmd) formatJson(entries []scenario.WhereEntry) []byte {
+	bytes, _ := json.Marshal(entries)
+	return bytes
+}
+
+func (p *whereCmd) formatYaml(entries []scenario.WhereEntry) []byte {
+	yaml := yaml.Yaml{}
+	bytes, _ := yaml.Marshal(entries)
+	return bytes
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
 )
 
@@ -61,6 +62,330 @@ set: by_first
 		}
 	})
 
+	t.Run("TestCompose json patch", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"--",
+			"--json-patch",
+			"../../test/data/v2/json_patch.json",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `foo: bar
+patched:
+    by: jsonpatch
+    list:
+      - a
+      - bar
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose merge patch", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-l",
+			"../../test/data/v2/merge_patch_library.yml",
+			"-t",
+			"../../test/data/v2/merge_patch_template.yml",
+			"-s",
+			"scale_web",
+			"--",
+			"-v",
+			"web_instances=3",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `instance_groups:
+- instances: 3
+  jobs:
+  - name: nginx
+    properties:
+      port: 80
+      tls: true
+  name: web
+name: deployment
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose yq operations", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"--",
+			"-s",
+			"../../test/data/v2/yq_operations.yml",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `bazz:
+  buzz:
+  - bee
+foo: yq
+unique: key
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose yq merge path", func(t *testing.T) {
+		script := []byte(`- command: merge
+  path: instance_groups[name==web].jobs[name==nginx]
+  prefix: properties
+  value:
+    tls: true
+- command: merge
+  path: instance_groups[name==worker]
+  value:
+    jobs:
+    - name: queue
+`)
+		err := ioutil.WriteFile("../../test/data/v2/generated.yml", script, 0644)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		defer os.Remove("../../test/data/v2/generated.yml")
+
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/merge_patch_template.yml",
+			"--",
+			"-s",
+			"../../test/data/v2/generated.yml",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err = cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `name: deployment
+instance_groups:
+- name: web
+  instances: 1
+  jobs:
+  - name: nginx
+    properties:
+      port: 80
+      tls: true
+- instances: 2
+  jobs:
+  - name: queue
+  name: worker
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose yq4 expression", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/merge_patch_template.yml",
+			"--",
+			"--yq-expression",
+			"../../test/data/v2/yq4_expression.yq",
+			"-v",
+			"web_instances=3",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `instance_groups:
+- instances: 3
+  jobs:
+  - name: nginx
+    properties:
+      port: 80
+  name: web
+name: deployment
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose jq program", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/merge_patch_template.yml",
+			"--",
+			"--jq",
+			"../../test/data/v2/jq_program.jq",
+			"-v",
+			"deployment_name=sorted",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `instance_groups:
+- instances: 1
+  jobs:
+  - name: nginx
+    properties:
+      port: 80
+  name: web
+- instances: 2
+  name: worker
+name: sorted
+total_instances: 3
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose go template", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/merge_patch_template.yml",
+			"--",
+			"--go-template",
+			"../../test/data/v2/go_template.tmpl",
+			"-v",
+			"groups=web,worker",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `instance_count: 2
+instance_groups:
+- instances: 1
+  jobs:
+  - name: nginx
+    properties:
+      port: 80
+  name: web
+- instances: 2
+  name: worker
+name: deployment
+tags:
+  web: deployment-web
+  worker: deployment-worker
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose exec command", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"--",
+			"--exec",
+			"../../test/data/v2/exec_processor.sh",
+		)
+		cmd.Env = append(os.Environ(), "MANIFER_ALLOW_EXEC=true")
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `request:
+    template: |
+        foo: bar
+    snippet: ""
+    options:
+        command: ../../test/data/v2/exec_processor.sh
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
 	t.Run("TestCompose show plan", func(t *testing.T) {
 		cmd := exec.Command(
 			"../../manifer",
@@ -251,44 +576,831 @@ foo: bar
 		expectedWriter.Write(ansiStop)
 		expectedWriter.Write([]byte(`set: by_first
 
-Diff:
-`))
+Diff:
+`))
+
+		expectedErr := string(expectedWriter.Bytes())
+
+		if !cmp.Equal(errWriter.String(), expectedErr) {
+			t.Errorf("Expected Stderr:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedErr, errWriter.String(), cmp.Diff(expectedErr, errWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose Additional Compositions", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"-s",
+			"placeholder",
+			"--",
+			"-v",
+			"path3=/final?",
+			"-v",
+			"value3=touch",
+			";",
+			"-l",
+			"../../test/data/v2/base_library.yml",
+			"-s",
+			"placeholder",
+			"-s",
+			"base",
+			"--",
+			"-v",
+			"path3=/what?",
+			"-v",
+			"value3=now",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `base1: a
+base2: b
+final: touch
+fixed: from_scenario
+foo: bar
+reused: by_second
+set: by_first
+what: now
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose Additional Compositions Blame", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"-s",
+			"bizz",
+			"--blame",
+			"--",
+			";",
+			"-l",
+			"../../test/data/v2/base_library.yml",
+			"-s",
+			"base",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedErr := `
+Blame:
+- path: /bazz
+  source: step
+  step: 1
+  snippet: ../../test/data/v2/opsfile.yml
+  scenarios:
+    - bizz
+  library: ../../test/data/v2/library.yml
+- path: /bizz
+  source: step
+  step: 1
+  snippet: ../../test/data/v2/opsfile.yml
+  scenarios:
+    - bizz
+  library: ../../test/data/v2/library.yml
+- path: /foo
+  source: template
+
+Blame:
+- path: /base1
+  source: step
+  step: 1
+  snippet: ../../test/data/v2/placeholder_opsfile.yml
+  scenarios:
+    - base
+  library: ../../test/data/v2/base_library.yml
+- path: /base2
+  source: step
+  step: 1
+  snippet: ../../test/data/v2/placeholder_opsfile.yml
+  scenarios:
+    - base
+  library: ../../test/data/v2/base_library.yml
+- path: /base3
+  source: step
+  step: 1
+  snippet: ../../test/data/v2/placeholder_opsfile.yml
+  scenarios:
+    - base
+  library: ../../test/data/v2/base_library.yml
+- path: /bazz
+  source: template
+- path: /bizz
+  source: template
+- path: /foo
+  source: template
+`
+
+		if !cmp.Equal(errWriter.String(), expectedErr) {
+			t.Errorf("Expected Stderr:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedErr, errWriter.String(), cmp.Diff(expectedErr, errWriter.String()))
+		}
+
+		expectedOut := `base1: a
+base2: b
+base3: c
+bazz: buzz
+bizz: bazz
+foo: bar
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose plan file", func(t *testing.T) {
+		defer os.Remove("../../test/data/v2/generated_plan.yml")
+
+		cmd := exec.Command(
+			"../../manifer",
+			"inspect",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-s",
+			"placeholder",
+			"--plan-file",
+			"../../test/data/v2/generated_plan.yml",
+			"--",
+			"-v",
+			"path3=/final?",
+			"-v",
+			"value3=touch",
+		)
+		errWriter := &test.StringWriter{}
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		saved, err := ioutil.ReadFile("../../test/data/v2/generated_plan.yml")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		expectedPlan := `version: 2
+global:
+    raw_args:
+      - -v
+      - path3=/final?
+      - -v
+      - value3=touch
+steps:
+  - snippet: placeholder_opsfile.yml
+`
+		if !strings.HasPrefix(string(saved), expectedPlan) {
+			t.Errorf("Expected plan prefix:\n'''%s'''\nActual:\n'''%s'''\n", expectedPlan, saved)
+		}
+
+		cmd = exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"--plan-file",
+			"../../test/data/v2/generated_plan.yml",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter = &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err = cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `base2: basic_from_placeholder
+final: touch
+fixed: from_scenario
+foo: bar
+reused: by_second
+set: by_first
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose partial plan", func(t *testing.T) {
+		defer os.RemoveAll("../../test/data/v2/generated_dump")
+
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"-s",
+			"placeholder",
+			"--to-step",
+			"2",
+			"--dump-dir",
+			"../../test/data/v2/generated_dump",
+			"--",
+			"-v",
+			"path3=/final?",
+			"-v",
+			"value3=touch",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expectedOut := `base2: basic_from_placeholder
+final: touch
+fixed: from_scenario
+foo: bar
+set: by_first
+`
+
+		if !cmp.Equal(outWriter.String(), expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		}
+
+		dumped, err := ioutil.ReadFile("../../test/data/v2/generated_dump/001-placeholder_opsfile.yml")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		expectedDump := `base2: basic_from_placeholder
+final: touch
+fixed: from_scenario
+foo: bar
+`
+		if !cmp.Equal(string(dumped), expectedDump) {
+			t.Errorf("Expected dump:\n'''%v'''\nActual:\n'''%v'''\n", expectedDump, string(dumped))
+		}
+	})
+
+	t.Run("TestTest", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"test",
+			"-l",
+			"../../test/data/v2/tested_library.yml",
+			"-n",
+			"bizz",
+			"-n",
+			"basic",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s\n%s", err, outWriter.String(), errWriter.String())
+		}
+
+		expected := `PASS bizz (../../test/data/v2/tested_library.yml)
+PASS basic (../../test/data/v2/tested_library.yml)
+2 passed, 0 failed
+`
+
+		if !cmp.Equal(outWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, outWriter.String(), cmp.Diff(expected, outWriter.String()))
+		}
+	})
+
+	t.Run("TestTest failure", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"test",
+			"-l",
+			"../../test/data/v2/tested_library.yml",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err == nil {
+			t.Errorf("Expected failing test to exit with an error\n%s", outWriter.String())
+		}
+
+		expected := `PASS bizz (../../test/data/v2/tested_library.yml)
+PASS basic (../../test/data/v2/tested_library.yml)
+FAIL outdated (../../test/data/v2/tested_library.yml)
+  + /bazz: buzz
+  + /bizz: bazz
+  /bizz == buzz (actual: 'bazz')
+2 passed, 1 failed
+`
+
+		if !cmp.Equal(outWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, outWriter.String(), cmp.Diff(expected, outWriter.String()))
+		}
+	})
+
+	t.Run("TestTest mask secrets", func(t *testing.T) {
+		lib := []byte(`tests:
+- name: secret
+  template: ./template_with_var.yml
+  interpolator:
+    vars:
+      bar: hunter2
+  expected: ./template.yml
+  assertions:
+  - /foo == nope
+`)
+		err := ioutil.WriteFile("../../test/data/v2/generated.yml", lib, 0644)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		defer os.Remove("../../test/data/v2/generated.yml")
+
+		cmd := exec.Command(
+			"../../manifer",
+			"test",
+			"--secret-pattern",
+			"bar",
+			"-l",
+			"../../test/data/v2/generated.yml",
+		)
+		outWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+
+		err = cmd.Run()
+		if err == nil {
+			t.Errorf("Expected failing test to exit with an error\n%s", outWriter.String())
+		}
+
+		expected := `FAIL secret (../../test/data/v2/generated.yml)
+  ~ /foo: bar -> ***
+  /foo == nope (actual: '***')
+0 passed, 1 failed
+`
+
+		if !cmp.Equal(outWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, outWriter.String(), cmp.Diff(expected, outWriter.String()))
+		}
+	})
+
+	t.Run("TestSnapshot", func(t *testing.T) {
+		defer os.RemoveAll("../../test/data/v2/generated_snapshots")
+
+		snapshot := func(update bool) (string, error) {
+			args := []string{
+				"snapshot",
+				"-l",
+				"../../test/data/v2/tested_library.yml",
+				"-d",
+				"../../test/data/v2/generated_snapshots",
+				"-n",
+				"bizz",
+			}
+			if update {
+				args = append(args, "--update")
+			}
+			cmd := exec.Command("../../manifer", args...)
+			outWriter := &test.StringWriter{}
+			cmd.Stdout = outWriter
+			err := cmd.Run()
+			return outWriter.String(), err
+		}
+
+		out, err := snapshot(true)
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, out)
+		}
+		expectedOut := `UPDATED bizz (../../test/data/v2/generated_snapshots/tested_library/bizz.yml)
+1 updated
+`
+		if !cmp.Equal(out, expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\n", expectedOut, out)
+		}
+
+		saved, err := ioutil.ReadFile("../../test/data/v2/generated_snapshots/tested_library/bizz.yml")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		expectedSnapshot := `bazz: buzz
+bizz: bazz
+foo: bar
+`
+		if !cmp.Equal(string(saved), expectedSnapshot) {
+			t.Errorf("Expected snapshot:\n'''%v'''\nActual:\n'''%v'''\n", expectedSnapshot, string(saved))
+		}
+
+		out, err = snapshot(false)
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, out)
+		}
+		expectedOut = `MATCHED bizz (../../test/data/v2/generated_snapshots/tested_library/bizz.yml)
+1 matched
+`
+		if !cmp.Equal(out, expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\n", expectedOut, out)
+		}
+
+		err = ioutil.WriteFile("../../test/data/v2/generated_snapshots/tested_library/bizz.yml", []byte("bizz: old\nfoo: bar\n"), 0644)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		out, err = snapshot(false)
+		if err == nil {
+			t.Errorf("Expected changed snapshot to exit with an error\n%s", out)
+		}
+		expectedOut = `CHANGED bizz (../../test/data/v2/generated_snapshots/tested_library/bizz.yml)
+  ~ /bizz: old -> bazz
+  + /bazz: buzz
+1 changed
+`
+		if !cmp.Equal(out, expectedOut) {
+			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n", expectedOut, out, cmp.Diff(expectedOut, out))
+		}
+	})
+
+	t.Run("TestDiff", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"diff",
+			"../../test/data/v2/template.yml",
+			"../../test/data/v2/yq_template.yml",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expected := `~ /foo: bar -> merged
++ /bazz: {"buzz":["wasp"]}
++ /net: new
+`
+
+		if !cmp.Equal(outWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, outWriter.String(), cmp.Diff(expected, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose semantic diff", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-s",
+			"bizz",
+			"--diff",
+			"--semantic-diff",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expected := `
+Diff:
++ /bazz: buzz
++ /bizz: bazz
+`
+
+		if !cmp.Equal(errWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, errWriter.String(), cmp.Diff(expected, errWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose mask secrets", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"--secret-pattern",
+			"value1",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-s",
+			"basic",
+			"-p",
+			"--diff",
+			"--semantic-diff",
+			"--",
+			"-v",
+			"value1=hunter2",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expected := `
+snippet: ../../test/data/v2/placeholder_opsfile.yml
+processor:
+    type: opsfile
+interpolator:
+    vars:
+        path1: /base1?
+        path2: /base2?
+        path3: /base3?
+        value1: '***'
+    raw_args:
+      - -v
+      - value1=***
+
+Diff:
++ /base1: ***
++ /base2: ((value2))
++ /base3: ((value3))
+
+interpolator:
+    raw_args:
+      - -v
+      - value1=***
+
+Diff:
+`
+
+		if !cmp.Equal(errWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, errWriter.String(), cmp.Diff(expected, errWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose vars store seed", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/generated_template.yml",
+			"--vars-store-seed",
+			"test",
+			"--",
+			"--vars-store",
+			"../../test/data/v2/generated_vars.yml",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expected := `password: ybejnf2vsqdazaqresjo
+variables: []
+`
+
+		if !cmp.Equal(outWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, outWriter.String(), cmp.Diff(expected, outWriter.String()))
+		}
+		if _, err := os.Stat("../../test/data/v2/generated_vars.yml"); !os.IsNotExist(err) {
+			t.Errorf("Expected vars store not to be written")
+		}
+	})
+
+	t.Run("TestCompose compare", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-s",
+			"placeholder",
+			"--compare",
+			"--",
+			"-v",
+			"path3=/final?",
+			"-v",
+			"value3=touch",
+			";",
+			"-s",
+			"placeholder",
+			"-s",
+			"bizz",
+			"--",
+			"-v",
+			"path3=/final?",
+			"-v",
+			"value3=now",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expected := `~ /final: touch -> now
++ /bazz: buzz
++ /bizz: bazz
+`
+
+		if !cmp.Equal(outWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, outWriter.String(), cmp.Diff(expected, outWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose report vars", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-s",
+			"placeholder",
+			"--report-vars",
+			"--",
+			"-v",
+			"path3=/final?",
+			"-v",
+			"value3=((later))",
+			"-v",
+			"extra=unused",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expected := `
+Variables:
+unresolved:
+  - path: /final
+    variable: later
+unused: []
+unused_globals:
+  - extra
+`
+
+		if !cmp.Equal(errWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, errWriter.String(), cmp.Diff(expected, errWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose strict", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"--strict",
+			"--",
+			"-o",
+			"../../test/data/v2/placeholder_opsfile.yml",
+			"-v",
+			"path1=/first?",
+			"-v",
+			"value1=first",
+			"-v",
+			"path2=/second?",
+			"-v",
+			"value2=second",
+			"-v",
+			"path3=/final?",
+			"-v",
+			"value3=((later))",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err == nil {
+			t.Errorf("Expected strict composition to fail\n%s", outWriter.String())
+		}
+
+		expected := "Unresolved variables in output:\n  /final: ((later))\n  while composing initial output\n"
+
+		if !cmp.Equal(errWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, errWriter.String(), cmp.Diff(expected, errWriter.String()))
+		}
+	})
+
+	t.Run("TestCompose strict conflicts", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"compose",
+			"-t",
+			"../../test/data/v2/template.yml",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-s",
+			"placeholder",
+			"--strict",
+			"--",
+			"-v",
+			"path3=/final?",
+			"-v",
+			"value3=final",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err == nil {
+			t.Errorf("Expected strict composition to fail\n%s", outWriter.String())
+		}
 
-		expectedErr := string(expectedWriter.Bytes())
+		expected := `Steps override other scenarios:
+  step 2 ../../test/data/v2/placeholder_opsfile.yml overrides step 1 ../../test/data/v2/placeholder_opsfile.yml at /fixed
+  step 3 ../../test/data/v2/placeholder_opsfile.yml overrides step 1 ../../test/data/v2/placeholder_opsfile.yml at /fixed
+  while composing initial output
+`
 
-		if !cmp.Equal(errWriter.String(), expectedErr) {
-			t.Errorf("Expected Stderr:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
-				expectedErr, errWriter.String(), cmp.Diff(expectedErr, errWriter.String()))
+		if !cmp.Equal(errWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, errWriter.String(), cmp.Diff(expected, errWriter.String()))
 		}
 	})
 
-	t.Run("TestCompose Additional Compositions", func(t *testing.T) {
+	t.Run("TestCompose unified diff", func(t *testing.T) {
 		cmd := exec.Command(
 			"../../manifer",
 			"compose",
-			"-l",
-			"../../test/data/v2/library.yml",
 			"-t",
 			"../../test/data/v2/template.yml",
-			"-s",
-			"placeholder",
-			"--",
-			"-v",
-			"path3=/final?",
-			"-v",
-			"value3=touch",
-			";",
 			"-l",
-			"../../test/data/v2/base_library.yml",
-			"-s",
-			"placeholder",
+			"../../test/data/v2/library.yml",
 			"-s",
-			"base",
-			"--",
-			"-v",
-			"path3=/what?",
-			"-v",
-			"value3=now",
+			"bizz",
+			"--diff",
+			"--diff-format",
+			"unified",
 		)
 		outWriter := &test.StringWriter{}
 		errWriter := &test.StringWriter{}
@@ -300,19 +1412,17 @@ Diff:
 			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
 		}
 
-		expectedOut := `base1: a
-base2: b
-final: touch
-fixed: from_scenario
-foo: bar
-reused: by_second
-set: by_first
-what: now
+		expected := `--- ../../test/data/v2/opsfile.yml
++++ ../../test/data/v2/opsfile.yml
+@@ -1 +1,3 @@
++bazz: buzz
++bizz: bazz
+ foo: bar
 `
 
-		if !cmp.Equal(outWriter.String(), expectedOut) {
-			t.Errorf("Expected Stdout:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
-				expectedOut, outWriter.String(), cmp.Diff(expectedOut, outWriter.String()))
+		if !cmp.Equal(errWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, errWriter.String(), cmp.Diff(expected, errWriter.String()))
 		}
 	})
 
@@ -434,6 +1544,143 @@ what: now
 		}
 	})
 
+	t.Run("TestWhere", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"where",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"/fixed",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expected := `- scenario: placeholder
+  library: ../../test/data/v2/library.yml
+  snippet: ../../test/data/v2/placeholder_opsfile.yml
+  paths:
+    - /fixed
+    - /((path3))
+- scenario: placeholder
+  library: ../../test/data/v2/library.yml
+  snippet: ../../test/data/v2/placeholder_opsfile.yml
+  paths:
+    - /fixed
+    - /((path3))
+`
+
+		if !cmp.Equal(outWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, outWriter.String(), cmp.Diff(expected, outWriter.String()))
+		}
+	})
+
+	t.Run("TestVars", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"vars",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-s",
+			"placeholder",
+			"--",
+			"-v",
+			"value3=x",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expected := `- name: path1
+  referenced_by:
+    - ../../test/data/v2/placeholder_opsfile.yml
+  sources:
+    - scope: scenario
+      via: vars
+      scenario: placeholder
+      library: ../../test/data/v2/library.yml
+    - scope: scenario
+      via: vars
+      scenario: basic
+      library: ../../test/data/v2/library.yml
+- name: path2
+  referenced_by:
+    - ../../test/data/v2/placeholder_opsfile.yml
+  sources:
+    - scope: snippet
+      via: vars
+      scenario: basic
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      library: ../../test/data/v2/library.yml
+    - scope: snippet
+      via: vars
+      scenario: placeholder
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      library: ../../test/data/v2/library.yml
+- name: path3
+  referenced_by:
+    - ../../test/data/v2/placeholder_opsfile.yml
+  sources:
+    - scope: snippet
+      via: vars
+      scenario: basic
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      library: ../../test/data/v2/library.yml
+  missing_in:
+    - ../../test/data/v2/placeholder_opsfile.yml
+- name: value1
+  referenced_by:
+    - ../../test/data/v2/placeholder_opsfile.yml
+  sources:
+    - scope: scenario
+      via: vars
+      scenario: placeholder
+      library: ../../test/data/v2/library.yml
+    - scope: scenario
+      via: vars
+      scenario: basic
+      library: ../../test/data/v2/library.yml
+- name: value2
+  referenced_by:
+    - ../../test/data/v2/placeholder_opsfile.yml
+  sources:
+    - scope: ref
+      via: vars
+      scenario: basic
+      library: ../../test/data/v2/library.yml
+    - scope: snippet
+      via: vars
+      scenario: placeholder
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      library: ../../test/data/v2/library.yml
+- name: value3
+  referenced_by:
+    - ../../test/data/v2/placeholder_opsfile.yml
+  sources:
+    - scope: cli
+      via: raw_args
+      library: <cli>
+`
+
+		if !cmp.Equal(outWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, outWriter.String(), cmp.Diff(expected, outWriter.String()))
+		}
+	})
+
 	t.Run("TestInspect", func(t *testing.T) {
 		t.Run("Yaml Tree", func(t *testing.T) {
 			cmd := exec.Command(
@@ -564,6 +1811,7 @@ what: now
       - -v=value=lastbit
 steps:
   - snippet: ../../test/data/v2/placeholder_opsfile.yml
+    library: ../../test/data/v2/base_library.yml
     params:
       - tag: snippet
         interpolator:
@@ -579,6 +1827,7 @@ steps:
     processor:
         type: opsfile
   - snippet: ../../test/data/v2/placeholder_opsfile.yml
+    library: ../../test/data/v2/library.yml
     params:
       - tag: snippet
         interpolator:
@@ -600,6 +1849,7 @@ steps:
     processor:
         type: opsfile
   - snippet: ../../test/data/v2/placeholder_opsfile.yml
+    library: ../../test/data/v2/library.yml
     params:
       - tag: snippet
         interpolator:
@@ -615,6 +1865,7 @@ steps:
     processor:
         type: opsfile
   - snippet: ../../test/data/v2/placeholder_opsfile.yml
+    library: ../../test/data/v2/library.yml
     params:
       - tag: snippet
         interpolator:
@@ -630,6 +1881,7 @@ steps:
     processor:
         type: opsfile
   - snippet: ../../test/data/v2/ops_file_with_vars.yml
+    library: <cli>
     params:
       - tag: snippet
       - tag: passthrough opsfile
@@ -645,6 +1897,238 @@ steps:
 
 	})
 
+	t.Run("TestInspect explain vars", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"inspect",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-s",
+			"basic",
+			"--explain-vars",
+			"--",
+			"-v",
+			"value1=override",
+			"-v",
+			"admin_password=hunter2",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expected := `- step: 1
+  snippet: ../../test/data/v2/placeholder_opsfile.yml
+  vars:
+    - name: admin_password
+      value: '***'
+      scope: global
+      via: raw_args
+    - name: path1
+      value: /base1?
+      scope: basic
+      via: vars
+    - name: path2
+      value: /base2?
+      scope: snippet
+      via: vars
+    - name: path3
+      value: /base3?
+      scope: snippet
+      via: vars
+    - name: value1
+      value: override
+      scope: global
+      via: raw_args
+      shadowed:
+        - value: from_basic
+          scope: basic
+          via: vars
+`
+
+		if !cmp.Equal(outWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, outWriter.String(), cmp.Diff(expected, outWriter.String()))
+		}
+	})
+
+	t.Run("TestInspect invalid options", func(t *testing.T) {
+		invalidLib := []byte(`
+type: yq
+scenarios:
+ - name: merge
+   snippets:
+    - path: yq_script.yml
+      processor:
+        options:
+          command: merge
+          overwite: true`)
+		err := ioutil.WriteFile("../../test/data/v2/generated.yml", invalidLib, 0644)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		defer os.Remove("../../test/data/v2/generated.yml")
+
+		cmd := exec.Command(
+			"../../manifer",
+			"inspect",
+			"-l",
+			"../../test/data/v2/generated.yml",
+			"-s",
+			"merge",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err = cmd.Run()
+		if err == nil {
+			t.Errorf("Expected invalid options to exit with an error\n%s", outWriter.String())
+		}
+
+		// the rest of the trace includes the absolute library path
+		expectedErr := `Unknown option overwite (expected command, path, overwrite, append, prefix)
+  while validating options for processor type yq
+  while validating snippet 1 of scenario merge in `
+		if !strings.HasPrefix(errWriter.String(), expectedErr) {
+			t.Errorf("Expected Stderr to start with:\n'''%v'''\nActual:\n'''%v'''\n", expectedErr, errWriter.String())
+		}
+	})
+
+	t.Run("TestInspect conflicts", func(t *testing.T) {
+		cmd := exec.Command(
+			"../../manifer",
+			"inspect",
+			"-l",
+			"../../test/data/v2/library.yml",
+			"-s",
+			"placeholder",
+			"-s",
+			"bizz",
+			"--conflicts",
+		)
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		expected := `- overridden:
+      step: 1
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      scenarios:
+        - basic
+        - placeholder
+      paths:
+        - /fixed
+  winner:
+      step: 2
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      scenarios:
+        - placeholder
+      paths:
+        - /fixed
+- overridden:
+      step: 1
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      scenarios:
+        - basic
+        - placeholder
+      paths:
+        - /fixed
+        - /base2
+        - /base3
+  winner:
+      step: 2
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      scenarios:
+        - placeholder
+      paths:
+        - /((path3))
+  possible: true
+- overridden:
+      step: 1
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      scenarios:
+        - basic
+        - placeholder
+      paths:
+        - /fixed
+  winner:
+      step: 3
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      scenarios:
+        - placeholder
+      paths:
+        - /fixed
+- overridden:
+      step: 1
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      scenarios:
+        - basic
+        - placeholder
+      paths:
+        - /fixed
+        - /base2
+        - /base3
+  winner:
+      step: 3
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      scenarios:
+        - placeholder
+      paths:
+        - /((path3))
+  possible: true
+- overridden:
+      step: 2
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      scenarios:
+        - placeholder
+      paths:
+        - /((path3))
+  winner:
+      step: 4
+      snippet: ../../test/data/v2/opsfile.yml
+      scenarios:
+        - bizz
+      paths:
+        - /bizz
+        - /bazz
+  possible: true
+- overridden:
+      step: 3
+      snippet: ../../test/data/v2/placeholder_opsfile.yml
+      scenarios:
+        - placeholder
+      paths:
+        - /((path3))
+  winner:
+      step: 4
+      snippet: ../../test/data/v2/opsfile.yml
+      scenarios:
+        - bizz
+      paths:
+        - /bizz
+        - /bazz
+  possible: true
+`
+
+		if !cmp.Equal(outWriter.String(), expected) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\nDiff:\n'''%s'''\n",
+				expected, outWriter.String(), cmp.Diff(expected, outWriter.String()))
+		}
+	})
+
 	t.Run("TestGenerate from template", func(t *testing.T) {
 
 		exec.Command(
@@ -697,15 +2181,13 @@ steps:
         processor:
             type: opsfile
   - name: add_snippet
-    description: replace /scenarios/((scenario_index))/snippets?/- (imported from
-        generated_ops/scenario/add_snippet.yml)
+    description: replace /scenarios/((scenario_index))/snippets?/- (imported from generated_ops/scenario/add_snippet.yml)
     snippets:
       - path: generated_ops/scenario/add_snippet.yml
         processor:
             type: opsfile
   - name: set_interpolator
-    description: replace /scenarios/((scenario_index))/snippets/((snippet_index))/interpolator?
-        (imported from generated_ops/scenario/snippet/set_interpolator.yml)
+    description: replace /scenarios/((scenario_index))/snippets/((snippet_index))/interpolator? (imported from generated_ops/scenario/snippet/set_interpolator.yml)
     snippets:
       - path: generated_ops/scenario/snippet/set_interpolator.yml
         processor:
@@ -717,8 +2199,7 @@ steps:
         processor:
             type: opsfile
   - name: set_snippet
-    description: replace /scenarios/((scenario_index))/snippets/((snippet_index))
-        (imported from generated_ops/scenario/set_snippet.yml)
+    description: replace /scenarios/((scenario_index))/snippets/((snippet_index)) (imported from generated_ops/scenario/set_snippet.yml)
     snippets:
       - path: generated_ops/scenario/set_snippet.yml
         processor:
@@ -730,8 +2211,7 @@ steps:
         processor:
             type: opsfile
   - name: set_vars
-    description: replace /scenarios/((scenario_index))/snippets/((snippet_index))/interpolator/vars?
-        (imported from generated_ops/scenario/snippet/interpolator/set_vars.yml)
+    description: replace /scenarios/((scenario_index))/snippets/((snippet_index))/interpolator/vars? (imported from generated_ops/scenario/snippet/interpolator/set_vars.yml)
     snippets:
       - path: generated_ops/scenario/snippet/interpolator/set_vars.yml
         processor:
@@ -744,6 +2224,104 @@ steps:
 		}
 	})
 
+	t.Run("TestGenerate jsonpatch", func(t *testing.T) {
+		defer os.RemoveAll("../../test/data/v2/generated_json_patches")
+
+		cmd := exec.Command(
+			"../../manifer",
+			"generate",
+			"-y",
+			"jsonpatch",
+			"-t",
+			"../../test/data/v2/base_library.yml",
+			"-d",
+			"../../test/data/v2/generated_json_patches/patches",
+			"-o",
+			"../../test/data/v2/generated_json_patches/library.yml",
+		)
+
+		outWriter := &test.StringWriter{}
+		errWriter := &test.StringWriter{}
+		cmd.Stdout = outWriter
+		cmd.Stderr = errWriter
+
+		err := cmd.Run()
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n%s", err, errWriter.String())
+		}
+
+		patch, err := ioutil.ReadFile("../../test/data/v2/generated_json_patches/patches/scenario/set_snippet.yml")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedPatch := `- op: replace
+  path: /scenarios/((scenario_index))/snippets/((snippet_index))
+  value:
+      path: ((snippet_path))
+`
+
+		if !cmp.Equal(string(patch), expectedPatch) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedPatch, string(patch), cmp.Diff(expectedPatch, string(patch)))
+		}
+
+		library, err := ioutil.ReadFile("../../test/data/v2/generated_json_patches/library.yml")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedOut := `scenarios:
+  - name: add_scenario
+    description: add /scenarios/- (imported from patches/add_scenario.yml)
+    snippets:
+      - path: patches/add_scenario.yml
+        processor:
+            type: jsonpatch
+  - name: add_snippet
+    description: add /scenarios/((scenario_index))/snippets/- (imported from patches/scenario/add_snippet.yml)
+    snippets:
+      - path: patches/scenario/add_snippet.yml
+        processor:
+            type: jsonpatch
+  - name: set_interpolator
+    description: add /scenarios/((scenario_index))/snippets/((snippet_index))/interpolator (imported from patches/scenario/snippet/set_interpolator.yml)
+    snippets:
+      - path: patches/scenario/snippet/set_interpolator.yml
+        processor:
+            type: jsonpatch
+  - name: set_scenario
+    description: replace /scenarios/((scenario_index)) (imported from patches/set_scenario.yml)
+    snippets:
+      - path: patches/set_scenario.yml
+        processor:
+            type: jsonpatch
+  - name: set_snippet
+    description: replace /scenarios/((scenario_index))/snippets/((snippet_index)) (imported from patches/scenario/set_snippet.yml)
+    snippets:
+      - path: patches/scenario/set_snippet.yml
+        processor:
+            type: jsonpatch
+  - name: set_type
+    description: add /type (imported from patches/set_type.yml)
+    snippets:
+      - path: patches/set_type.yml
+        processor:
+            type: jsonpatch
+  - name: set_vars
+    description: add /scenarios/((scenario_index))/snippets/((snippet_index))/interpolator/vars (imported from patches/scenario/snippet/interpolator/set_vars.yml)
+    snippets:
+      - path: patches/scenario/snippet/interpolator/set_vars.yml
+        processor:
+            type: jsonpatch
+`
+
+		if !cmp.Equal(string(library), expectedOut) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n",
+				expectedOut, string(library), cmp.Diff(expectedOut, string(library)))
+		}
+	})
+
 	t.Run("TestImport file", func(t *testing.T) {
 
 		exec.Command(
@@ -865,12 +2443,36 @@ steps:
       - path: base_library.yml
         processor:
             type: yq
+  - name: generated_template
+    description: write password (imported from generated_template.yml)
+    snippets:
+      - path: generated_template.yml
+        processor:
+            type: yq
   - name: library
     description: write type (imported from library.yml)
     snippets:
       - path: library.yml
         processor:
             type: yq
+  - name: merge_patch
+    description: write instance_groups (imported from merge_patch.yml)
+    snippets:
+      - path: merge_patch.yml
+        processor:
+            type: yq
+  - name: merge_patch_library
+    description: write type (imported from merge_patch_library.yml)
+    snippets:
+      - path: merge_patch_library.yml
+        processor:
+            type: yq
+  - name: merge_patch_template
+    description: write name (imported from merge_patch_template.yml)
+    snippets:
+      - path: merge_patch_template.yml
+        processor:
+            type: yq
   - name: ref_library
     description: write type (imported from ref_library.yml)
     snippets:
@@ -889,6 +2491,18 @@ steps:
       - path: template_with_var.yml
         processor:
             type: yq
+  - name: tested_bizz
+    description: write bazz (imported from tested_bizz.yml)
+    snippets:
+      - path: tested_bizz.yml
+        processor:
+            type: yq
+  - name: tested_library
+    description: write libraries (imported from tested_library.yml)
+    snippets:
+      - path: tested_library.yml
+        processor:
+            type: yq
   - name: vars
     description: write foo (imported from vars.yml)
     snippets:
@@ -901,6 +2515,12 @@ steps:
       - path: yq_library.yml
         processor:
             type: yq
+  - name: yq_operations
+    description: write bazz.buzz[+] (imported from yq_operations.yml)
+    snippets:
+      - path: yq_operations.yml
+        processor:
+            type: yq
   - name: yq_script
     description: write foo (imported from yq_script.yml)
     snippets:
@@ -913,6 +2533,12 @@ steps:
       - path: yq_template.yml
         processor:
             type: yq
+  - name: json_patch
+    description: test /foo (imported from json_patch.json)
+    snippets:
+      - path: json_patch.json
+        processor:
+            type: jsonpatch
 `
 
 		if !cmp.Equal(outWriter.String(), expectedOut) {
@@ -15,35 +15,40 @@ require (
 	github.com/cppforlife/go-patch v0.2.0
 	github.com/cppforlife/go-semi-semantic v0.0.0-20160921010311-576b6af77ae4 // indirect
 	github.com/dustin/go-humanize v1.0.0 // indirect
-	github.com/golang/mock v1.2.0 // pin v1.2.0 for https://github.com/golang/mock/issues/325
+	github.com/golang/mock v1.3.1 // replaced with v1.2.0 for https://github.com/golang/mock/issues/325
 	github.com/golang/protobuf v1.3.2 // indirect
 	github.com/google/go-cmp v0.3.1
 	github.com/hashicorp/go-multierror v1.0.0 // indirect
+	github.com/itchyny/gojq v0.6.0
 	github.com/jessevdk/go-flags v1.4.0
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/kr/pty v1.1.8 // indirect
 	github.com/magiconair/properties v1.8.1 // indirect
-	github.com/mattn/go-colorable v0.1.4 // indirect
-	github.com/mattn/go-isatty v0.0.11 // indirect
 	github.com/mattn/go-runewidth v0.0.7 // indirect
 	github.com/mikefarah/yaml/v2 v2.4.0
 	github.com/mikefarah/yq/v2 v2.4.2-0.20191206025856-5fc13bdccd28
+	github.com/mikefarah/yq/v4 v4.6.1
 	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d // indirect
 	github.com/onsi/ginkgo v1.9.0 // indirect
 	github.com/onsi/gomega v1.7.0 // indirect
 	github.com/pivotal-cf/paraphernalia v0.0.0-20180203224945-a64ae2051c20 // indirect
 	github.com/sergi/go-diff v1.0.0
 	github.com/spf13/cast v1.3.1 // indirect
-	github.com/spf13/cobra v0.0.5
+	github.com/spf13/cobra v1.1.1
 	github.com/spf13/pflag v1.0.5 // indirect
-	github.com/spf13/viper v1.3.2
+	github.com/spf13/viper v1.7.0
 	github.com/square/certstrap v1.1.1 // indirect
-	github.com/stretchr/testify v1.4.0 // indirect
 	github.com/tedsuo/ifrit v0.0.0-20180802180643-bea94bb476cc // indirect
 	github.com/vito/go-interact v1.0.0 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	golang.org/x/text v0.3.2 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 	gopkg.in/op/go-logging.v1 v1.0.0-20160211212156-b2cb9fa56473
-	gopkg.in/yaml.v2 v2.2.7 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20190905181640-827449938966
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
+)
+
+// yq v4 requires newer versions: keep the mockgen pin, and the oldest yaml.v3 yq v4 builds with so output keeps its indentation
+replace (
+	github.com/golang/mock => github.com/golang/mock v1.2.0
+	gopkg.in/yaml.v3 => gopkg.in/yaml.v3 v3.0.0-20200603094226-e3079894b1e8
 )
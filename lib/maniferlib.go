@@ -6,6 +6,7 @@ import (
 	"io"
 	"path/filepath"
 
+	"github.com/cjnosal/manifer/v2/pkg/blame"
 	"github.com/cjnosal/manifer/v2/pkg/composer"
 	"github.com/cjnosal/manifer/v2/pkg/diff"
 	"github.com/cjnosal/manifer/v2/pkg/file"
@@ -15,7 +16,9 @@ import (
 	"github.com/cjnosal/manifer/v2/pkg/library"
 	"github.com/cjnosal/manifer/v2/pkg/plan"
 	"github.com/cjnosal/manifer/v2/pkg/processor/factory"
+	"github.com/cjnosal/manifer/v2/pkg/redact"
 	"github.com/cjnosal/manifer/v2/pkg/scenario"
+	"github.com/cjnosal/manifer/v2/pkg/tester"
 	"github.com/cjnosal/manifer/v2/pkg/yaml"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
@@ -27,18 +30,32 @@ func NewManifer(logger io.Writer) Manifer {
 	processorFactory := factory.NewProcessorFactory(yaml, fileIO)
 	importer := importer.NewImporter(fileIO, processorFactory)
 	loader := &library.Loader{
-		File: fileIO,
-		Yaml: yaml,
+		File:    fileIO,
+		Yaml:    yaml,
+		Options: processorFactory,
 	}
 	lister := &scenario.Lister{
 		Loader: loader,
 	}
+	finder := &scenario.Finder{
+		Loader:           loader,
+		ProcessorFactory: processorFactory,
+	}
+	interpolator := bosh.NewBoshInterpolator()
+	redactor := redact.NewSecretRedactor(interpolator)
+	varsScanner := &scenario.VarsScanner{
+		File:         fileIO,
+		Interpolator: interpolator,
+	}
+	treeDiff := &diff.TreeDiff{
+		File: fileIO,
+		Yaml: yaml,
+	}
 	patch := diffmatchpatch.New()
 	diff := &diff.FileDiff{
 		File:  fileIO,
 		Patch: patch,
 	}
-	interpolator := bosh.NewBoshInterpolator()
 	resolver := &composer.Resolver{
 		Loader:           loader,
 		ProcessorFactory: processorFactory,
@@ -51,22 +68,63 @@ func NewManifer(logger io.Writer) Manifer {
 		Output:           logger,
 		File:             fileIO,
 		Yaml:             yaml,
+		Redactor:         redactor,
+	}
+	varsReporter := &plan.StaticVarsReporter{
+		File:             fileIO,
+		Yaml:             yaml,
+		Interpolator:     interpolator,
+		ProcessorFactory: processorFactory,
 	}
 	composer := &composer.ComposerImpl{
 		Resolver: resolver,
 		File:     fileIO,
 		Executor: executor,
+		TextDiff: diff,
+		TreeDiff: treeDiff,
+		Vars:     varsReporter,
+		Redactor: redactor,
+		Output:   logger,
+	}
+	conflictDetector := &plan.StaticConflictDetector{
+		ProcessorFactory: processorFactory,
+	}
+	varsExplainer := &plan.ParamsExplainer{
+		Interpolator: interpolator,
+		Redactor:     redactor,
+	}
+	planIO := &plan.PlanIO{
+		File: fileIO,
+		Yaml: yaml,
+	}
+	runner := &tester.Runner{
+		Loader:   loader,
+		Resolver: resolver,
+		Composer: composer,
+		Diff:     treeDiff,
+		File:     fileIO,
+		Yaml:     yaml,
 	}
 
 	return &libImpl{
 		composer:     composer,
+		resolver:     resolver,
+		planIO:       planIO,
+		treeDiff:     treeDiff,
+		conflicts:    conflictDetector,
+		vars:         varsReporter,
+		explainer:    varsExplainer,
 		lister:       lister,
+		finder:       finder,
+		varsLister:   varsScanner,
 		loader:       loader,
 		file:         fileIO,
 		yaml:         yaml,
 		procFact:     processorFactory,
 		importer:     importer,
 		interpolator: interpolator,
+		redactor:     redactor,
+		tester:       runner,
 	}
 }
 
@@ -87,12 +145,70 @@ type Manifer interface {
 		showPlan bool,
 		showDiff bool) ([]byte, error)
 
+	ComposePlan(
+		template *file.TaggedBytes,
+		executionPlan *plan.Plan,
+		options composer.Options) ([]byte, error)
+
+	Blame(
+		template *file.TaggedBytes,
+		executionPlan *plan.Plan,
+		options composer.Options) ([]byte, []blame.Entry, error)
+
+	Compare(
+		template *file.TaggedBytes,
+		first *plan.Plan,
+		second *plan.Plan,
+		options composer.Options) ([]diff.Change, error)
+
+	ReportVars(
+		template *file.TaggedBytes,
+		executionPlan *plan.Plan,
+		output []byte) (*plan.VarsReport, error)
+
+	GetPlan(libraryPaths []string, scenarioNames []string, passthrough []string) (*plan.Plan, error)
+
+	LoadPlan(planPath string) (*plan.Plan, error)
+
+	SavePlan(planPath string, executionPlan *plan.Plan) error
+
+	GetConflicts(executionPlan *plan.Plan) ([]plan.Conflict, error)
+
+	ExplainVars(executionPlan *plan.Plan) ([]plan.StepVars, error)
+
+	// glob patterns of variable names whose values are masked in printed plans, diffs, and errors (see redact.DefaultPatterns)
+	SetSecretPatterns(patterns []string)
+
+	// mask tracked secret values in the messages of err and errors wrapping it
+	RedactError(err error) error
+
+	Diff(path1 string, path2 string, options diff.CompareOptions) ([]diff.Change, error)
+
+	// run the tests declared by the libraries, or only the tests with the given names
+	RunTests(libraryPaths []string, names []string) ([]tester.Result, error)
+
+	// compare the output of library tests to snapshots in dir, or rewrite the snapshots that differ when update is set
+	Snapshot(libraryPaths []string, names []string, dir string, update bool) ([]tester.SnapshotResult, error)
+
 	ListScenarios(libraryPaths []string, all bool) ([]scenario.ScenarioEntry, error)
 
 	GetScenarioTree(libraryPaths []string, name string) (*library.ScenarioNode, error)
 
+	Where(libraryPaths []string, path string) ([]scenario.WhereEntry, error)
+
+	ListVars(libraryPaths []string, scenarioNames []string, passthrough []string) ([]scenario.VarEntry, error)
+
 	GetSnippetScenarioNode(libType library.Type, passthroughArgs []string) (*library.ScenarioNode, []string, error)
 
+	// add a processor type for libraries and passthrough flags, newGenerator may be nil if the type can't generate snippets
+	RegisterProcessor(libType library.Type, newProcessor factory.ProcessorConstructor, newGenerator factory.GeneratorConstructor, capabilities factory.Capabilities) error
+
+	// registered processor types in the order their passthrough flags are parsed
+	ProcessorTypes() []library.Type
+
+	// whether import looks for snippets of the type, and whether they are rendered instead of interpolated
+	ProcessorCapabilities(libType library.Type) factory.Capabilities
+
 	GetVarScenarioNode(passthroughArgs []string) (*library.ScenarioNode, []string, error)
 
 	Generate(libType library.Type, templatePath string, libPath string, snippetDir string) (*library.Library, error)
@@ -104,13 +220,23 @@ type Manifer interface {
 
 type libImpl struct {
 	composer     composer.Composer
+	resolver     composer.ScenarioResolver
+	planIO       plan.PlanAccess
+	treeDiff     diff.StructuralDiff
+	conflicts    plan.ConflictDetector
+	vars         plan.VarsReporter
+	explainer    plan.VarsExplainer
 	lister       scenario.ScenarioLister
+	finder       scenario.PathFinder
+	varsLister   scenario.VarsLister
 	loader       *library.Loader
 	file         *file.FileIO
 	yaml         yaml.YamlAccess
 	importer     importer.Importer
 	interpolator interpolator.Interpolator
 	procFact     factory.ProcessorFactory
+	redactor     *redact.SecretRedactor
+	tester       tester.LibraryTester
 }
 
 func (l *libImpl) Compose(
@@ -138,6 +264,168 @@ func (l *libImpl) ComposeFromBytes(
 	return l.composer.Compose(template, libraryPaths, scenarioNames, passthrough, showPlan, showDiff)
 }
 
+func (l *libImpl) ComposePlan(
+	template *file.TaggedBytes,
+	executionPlan *plan.Plan,
+	options composer.Options) ([]byte, error) {
+	return l.composer.ComposePlan(template, executionPlan, options)
+}
+
+func (l *libImpl) Blame(
+	template *file.TaggedBytes,
+	executionPlan *plan.Plan,
+	options composer.Options) ([]byte, []blame.Entry, error) {
+	tracker := &blame.Tracker{
+		Yaml: l.yaml,
+	}
+	options.Observers = append(options.Observers, tracker)
+	out, err := l.composer.ComposePlan(template, executionPlan, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, tracker.Entries(), nil
+}
+
+func (l *libImpl) Compare(
+	template *file.TaggedBytes,
+	first *plan.Plan,
+	second *plan.Plan,
+	options composer.Options) ([]diff.Change, error) {
+	firstOut, err := l.composer.ComposePlan(template, first, options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while composing first plan", err)
+	}
+	secondOut, err := l.composer.ComposePlan(template, second, options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while composing second plan", err)
+	}
+	changes, err := l.treeDiff.Compare(firstOut, secondOut, options.DiffOptions)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while comparing outputs", err)
+	}
+	l.redactChanges(changes)
+	return changes, nil
+}
+
+func (l *libImpl) redactChanges(changes []diff.Change) {
+	for i := range changes {
+		changes[i].Old = l.redactor.Value(changes[i].Old)
+		changes[i].New = l.redactor.Value(changes[i].New)
+	}
+}
+
+func (l *libImpl) ReportVars(
+	template *file.TaggedBytes,
+	executionPlan *plan.Plan,
+	output []byte) (*plan.VarsReport, error) {
+	report, err := l.vars.Report(template, executionPlan, output)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while reporting variables", err)
+	}
+	for i, unused := range report.Unused {
+		if unused.Snippet == "" {
+			continue
+		}
+		report.Unused[i].Snippet, err = l.file.ResolveRelativeFromWD(unused.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while finding relative path to %s", err, unused.Snippet)
+		}
+	}
+	return report, nil
+}
+
+func (l *libImpl) GetPlan(libraryPaths []string, scenarioNames []string, passthrough []string) (*plan.Plan, error) {
+	return l.resolver.Resolve(libraryPaths, scenarioNames, passthrough)
+}
+
+func (l *libImpl) LoadPlan(planPath string) (*plan.Plan, error) {
+	return l.planIO.Read(planPath)
+}
+
+func (l *libImpl) SavePlan(planPath string, executionPlan *plan.Plan) error {
+	return l.planIO.Write(planPath, executionPlan)
+}
+
+func (l *libImpl) GetConflicts(executionPlan *plan.Plan) ([]plan.Conflict, error) {
+	conflicts, err := l.conflicts.Conflicts(executionPlan)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while detecting conflicts", err)
+	}
+	for i := range conflicts {
+		for _, step := range []*plan.ConflictStep{&conflicts[i].Overridden, &conflicts[i].Winner} {
+			step.Snippet, err = l.file.ResolveRelativeFromWD(step.Snippet)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while finding relative path to %s", err, step.Snippet)
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+func (l *libImpl) SetSecretPatterns(patterns []string) {
+	l.redactor.Patterns = patterns
+}
+
+func (l *libImpl) RedactError(err error) error {
+	return l.redactor.Error(err)
+}
+
+func (l *libImpl) ExplainVars(executionPlan *plan.Plan) ([]plan.StepVars, error) {
+	explained, err := l.explainer.Explain(executionPlan)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while explaining variables", err)
+	}
+	for i := range explained {
+		if explained[i].Snippet == "" {
+			continue
+		}
+		explained[i].Snippet, err = l.file.ResolveRelativeFromWD(explained[i].Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while finding relative path to %s", err, explained[i].Snippet)
+		}
+	}
+	return explained, nil
+}
+
+func (l *libImpl) Diff(path1 string, path2 string, options diff.CompareOptions) ([]diff.Change, error) {
+	return l.treeDiff.FindChanges(path1, path2, options)
+}
+
+func (l *libImpl) RunTests(libraryPaths []string, names []string) ([]tester.Result, error) {
+	results, err := l.tester.Run(libraryPaths, names)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while running library tests", err)
+	}
+	for i := range results {
+		results[i].Library, err = l.file.ResolveRelativeFromWD(results[i].Library)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while finding relative path to %s", err, results[i].Library)
+		}
+		results[i].Error = l.redactor.Redact(results[i].Error)
+		l.redactChanges(results[i].Changes)
+		for j := range results[i].Failures {
+			results[i].Failures[j] = l.redactor.Redact(results[i].Failures[j])
+		}
+	}
+	return results, nil
+}
+
+func (l *libImpl) Snapshot(libraryPaths []string, names []string, dir string, update bool) ([]tester.SnapshotResult, error) {
+	results, err := l.tester.Snapshot(libraryPaths, names, dir, update)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while taking snapshots", err)
+	}
+	for i := range results {
+		results[i].Library, err = l.file.ResolveRelativeFromWD(results[i].Library)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while finding relative path to %s", err, results[i].Library)
+		}
+		results[i].Error = l.redactor.Redact(results[i].Error)
+		l.redactChanges(results[i].Changes)
+	}
+	return results, nil
+}
+
 func (l *libImpl) ListScenarios(libraryPaths []string, all bool) ([]scenario.ScenarioEntry, error) {
 	return l.lister.ListScenarios(libraryPaths, all)
 }
@@ -158,6 +446,65 @@ func (l *libImpl) GetScenarioTree(libraryPaths []string, name string) (*library.
 	return node, nil
 }
 
+func (l *libImpl) Where(libraryPaths []string, path string) ([]scenario.WhereEntry, error) {
+	entries, err := l.finder.Where(libraryPaths, path)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while searching libraries for %s", err, path)
+	}
+	for i, entry := range entries {
+		entries[i].Snippet, err = l.file.ResolveRelativeFromWD(entry.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while finding relative path to %s", err, entry.Snippet)
+		}
+		entries[i].Library, err = l.file.ResolveRelativeFromWD(entry.Library)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while finding relative path to %s", err, entry.Library)
+		}
+	}
+	return entries, nil
+}
+
+func (l *libImpl) ListVars(libraryPaths []string, scenarioNames []string, passthrough []string) ([]scenario.VarEntry, error) {
+	nodes, err := l.resolver.ResolveNodes(libraryPaths, scenarioNames, passthrough)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to resolve scenarios", err)
+	}
+	entries, err := l.varsLister.ListVars(nodes)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while listing variables", err)
+	}
+	relative := func(path *string) error {
+		if !filepath.IsAbs(*path) {
+			return nil
+		}
+		rel, err := l.file.ResolveRelativeFromWD(*path)
+		if err != nil {
+			return fmt.Errorf("%w\n  while finding relative path to %s", err, *path)
+		}
+		*path = rel
+		return nil
+	}
+	for i := range entries {
+		paths := []*string{}
+		for j := range entries[i].ReferencedBy {
+			paths = append(paths, &entries[i].ReferencedBy[j])
+		}
+		for j := range entries[i].MissingIn {
+			paths = append(paths, &entries[i].MissingIn[j])
+		}
+		for j := range entries[i].Sources {
+			paths = append(paths, &entries[i].Sources[j].Snippet, &entries[i].Sources[j].Library)
+		}
+		for _, path := range paths {
+			err = relative(path)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return entries, nil
+}
+
 func (l *libImpl) GetSnippetScenarioNode(libType library.Type, passthroughArgs []string) (*library.ScenarioNode, []string, error) {
 	processor, err := l.procFact.Create(libType)
 	if err != nil {
@@ -170,6 +517,18 @@ func (l *libImpl) GetSnippetScenarioNode(libType library.Type, passthroughArgs [
 	return node, remainder, nil
 }
 
+func (l *libImpl) RegisterProcessor(libType library.Type, newProcessor factory.ProcessorConstructor, newGenerator factory.GeneratorConstructor, capabilities factory.Capabilities) error {
+	return l.procFact.Register(libType, newProcessor, newGenerator, capabilities)
+}
+
+func (l *libImpl) ProcessorTypes() []library.Type {
+	return l.procFact.Types()
+}
+
+func (l *libImpl) ProcessorCapabilities(libType library.Type) factory.Capabilities {
+	return l.procFact.Capabilities(libType)
+}
+
 func (l *libImpl) GetVarScenarioNode(passthroughArgs []string) (*library.ScenarioNode, []string, error) {
 	return l.interpolator.ParsePassthroughVars(passthroughArgs)
 }
@@ -243,7 +602,7 @@ func (l *libImpl) AddScenario(libraryPath string, name string, description strin
 	}
 
 	snippets := []library.Snippet{}
-	for _, t := range library.Types {
+	for _, t := range l.procFact.Types() {
 		node, remainder, err := l.GetSnippetScenarioNode(t, passthrough)
 		if err != nil {
 			return nil, fmt.Errorf("%w\n  while getting passthrough snippets", err)
@@ -0,0 +1,90 @@
+package blame
+
+import (
+	"fmt"
+
+	"github.com/cjnosal/manifer/v2/pkg/plan"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	y "gopkg.in/yaml.v3"
+)
+
+const (
+	SourceTemplate = "template"
+	SourceStep     = "step"
+	SourceGlobals  = "globals"
+)
+
+type Entry struct {
+	Path      string   `yaml:"path"`
+	Source    string   `yaml:"source"`
+	Step      int      `yaml:"step,omitempty"`
+	Snippet   string   `yaml:"snippet,omitempty"`
+	Scenarios []string `yaml:"scenarios,omitempty"` // innermost first
+	Library   string   `yaml:"library,omitempty"`
+}
+
+// Observes each composition step and attributes every leaf path to the step that last set or changed it
+type Tracker struct {
+	Yaml yaml.YamlAccess
+
+	values  map[string]string
+	entries map[string]Entry
+	order   []string
+}
+
+func (t *Tracker) Observe(index int, step *plan.Step, out []byte) error {
+	node := &y.Node{}
+	err := t.Yaml.Unmarshal(out, node)
+	if err != nil {
+		return fmt.Errorf("%w\n  while parsing output of step %d", err, index)
+	}
+
+	source := Entry{
+		Source: SourceTemplate,
+	}
+	if step != nil {
+		source = Entry{
+			Source:    SourceStep,
+			Step:      index,
+			Snippet:   step.Snippet,
+			Scenarios: step.ScenarioChain(),
+			Library:   step.Library,
+		}
+	} else if index > 0 {
+		source = Entry{
+			Source: SourceGlobals,
+			Step:   index,
+		}
+	}
+
+	values := map[string]string{}
+	entries := map[string]Entry{}
+	order := []string{}
+	for _, leaf := range yaml.Leaves(node) {
+		value := fmt.Sprintf("%s %s", leaf.Node.Tag, leaf.Value)
+		values[leaf.Path] = value
+		order = append(order, leaf.Path)
+
+		previous, found := t.entries[leaf.Path]
+		if found && t.values[leaf.Path] == value {
+			entries[leaf.Path] = previous
+		} else {
+			entry := source
+			entry.Path = leaf.Path
+			entries[leaf.Path] = entry
+		}
+	}
+	t.values = values
+	t.entries = entries
+	t.order = order
+	return nil
+}
+
+// provenance of each leaf in the last observed document, in document order
+func (t *Tracker) Entries() []Entry {
+	entries := []Entry{}
+	for _, path := range t.order {
+		entries = append(entries, t.entries[path])
+	}
+	return entries
+}
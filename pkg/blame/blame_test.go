@@ -0,0 +1,78 @@
+package blame
+
+import (
+	"testing"
+
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/plan"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTracker(t *testing.T) {
+
+	t.Run("attribute changed paths", func(t *testing.T) {
+		subject := &Tracker{
+			Yaml: &yaml.Yaml{},
+		}
+		step := &plan.Step{
+			Snippet: "/lib/snippet.yml",
+			Library: "/lib/library.yml",
+			Params: []plan.TaggedParams{
+				{Tag: "snippet"},
+				{Tag: "inner"},
+				{Tag: "outer"},
+			},
+			Processor: library.Processor{Type: library.OpsFile},
+		}
+
+		observations := []struct {
+			index int
+			step  *plan.Step
+			out   string
+		}{
+			{0, nil, "kept: a\nchanged: b\nglobal: ((g))\n"},
+			{1, step, "kept: a\nchanged: c\nadded: d\nglobal: ((g))\n"},
+			{2, nil, "kept: a\nchanged: c\nadded: d\nglobal: e\n"},
+		}
+		for _, o := range observations {
+			err := subject.Observe(o.index, o.step, []byte(o.out))
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+		}
+
+		stepEntry := func(path string) Entry {
+			return Entry{
+				Path:      path,
+				Source:    SourceStep,
+				Step:      1,
+				Snippet:   "/lib/snippet.yml",
+				Scenarios: []string{"inner", "outer"},
+				Library:   "/lib/library.yml",
+			}
+		}
+		expected := []Entry{
+			{Path: "/kept", Source: SourceTemplate},
+			stepEntry("/changed"),
+			stepEntry("/added"),
+			{Path: "/global", Source: SourceGlobals, Step: 2},
+		}
+
+		entries := subject.Entries()
+		if !cmp.Equal(expected, entries) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n", expected, entries, cmp.Diff(expected, entries))
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		subject := &Tracker{
+			Yaml: &yaml.Yaml{},
+		}
+
+		err := subject.Observe(1, nil, []byte("foo: [bar"))
+		if err == nil {
+			t.Errorf("Expected parse error")
+		}
+	})
+}
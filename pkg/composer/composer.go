@@ -1,10 +1,17 @@
 package composer
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/diff"
 	"github.com/cjnosal/manifer/v2/pkg/file"
 	"github.com/cjnosal/manifer/v2/pkg/library"
 	"github.com/cjnosal/manifer/v2/pkg/plan"
+	"github.com/cjnosal/manifer/v2/pkg/redact"
 )
 
 type Composer interface {
@@ -15,12 +22,91 @@ type Composer interface {
 		passthrough []string,
 		showPlan bool,
 		showDiff bool) ([]byte, error)
+
+	ComposePlan(
+		template *file.TaggedBytes,
+		plan *plan.Plan,
+		options Options) ([]byte, error)
+}
+
+type Options struct {
+	ShowPlan bool
+	ShowDiff bool
+
+	// with ShowDiff, print added/removed/changed paths instead of a text diff
+	SemanticDiff bool
+	DiffOptions  diff.CompareOptions
+
+	// with ShowDiff, one of diff.Formats (default pretty)
+	DiffFormat string
+
+	// 1-based inclusive range of plan steps to execute (0 for unbounded).
+	// Globals are not applied after the last step when ToStep is set.
+	FromStep int
+	ToStep   int
+
+	// only execute steps contributed by these scenarios or their dependencies
+	Scenarios []string
+
+	// write the output of each step to this directory
+	DumpDir string
+
+	// notified of the template and the output of each step
+	Observers []StepObserver
+
+	// fail if the output contains unresolved ((placeholders))
+	Strict bool
+
+	// generate vars store values from this seed in memory instead of reading or writing a vars store file
+	VarsStoreSeed string
+}
+
+type StepObserver interface {
+	// index is 0 for the template and len(plan.Steps)+1 for globals (step is nil for both)
+	Observe(index int, step *plan.Step, out []byte) error
+}
+
+// an empty range is more likely a mistake than intended
+func (o Options) validateRange(steps int) error {
+	if o.FromStep < 0 || o.ToStep < 0 {
+		return fmt.Errorf("Step range must not be negative (from %d to %d)", o.FromStep, o.ToStep)
+	}
+	if o.ToStep > 0 && o.FromStep > o.ToStep {
+		return fmt.Errorf("First step %d is after last step %d", o.FromStep, o.ToStep)
+	}
+	if o.FromStep > steps {
+		return fmt.Errorf("First step %d is after the end of the plan (%d steps)", o.FromStep, steps)
+	}
+	return nil
+}
+
+func (o Options) includes(index int, step *plan.Step) bool {
+	if o.FromStep > 0 && index < o.FromStep {
+		return false
+	}
+	if o.ToStep > 0 && index > o.ToStep {
+		return false
+	}
+	if len(o.Scenarios) == 0 {
+		return true
+	}
+	for _, s := range o.Scenarios {
+		if step.HasScenario(s) {
+			return true
+		}
+	}
+	return false
 }
 
 type ComposerImpl struct {
 	Executor plan.Executor
 	Resolver ScenarioResolver
 	File     file.FileAccess
+	TextDiff diff.Diff
+	TreeDiff diff.StructuralDiff
+	Vars     plan.VarsReporter
+	Redactor redact.Redactor
+	Output   io.Writer
 }
 
 func (c *ComposerImpl) Compose(
@@ -36,12 +122,66 @@ func (c *ComposerImpl) Compose(
 		return nil, fmt.Errorf("%w\n  while trying to resolve scenarios", err)
 	}
 
+	return c.ComposePlan(template, plan, Options{ShowPlan: showPlan, ShowDiff: showDiff})
+}
+
+func (c *ComposerImpl) ComposePlan(
+	template *file.TaggedBytes,
+	plan *plan.Plan,
+	options Options) ([]byte, error) {
+
+	err := options.validateRange(len(plan.Steps))
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while selecting steps", err)
+	}
+
 	in := template
-	var out []byte
+	out := template.Bytes
+
+	global := plan.Global
+	if options.VarsStoreSeed != "" {
+		global.VarsStoreSeed = options.VarsStoreSeed
+	}
+
+	showDiff := false
+	var diffObserver StepObserver
+	if options.ShowDiff {
+		switch {
+		case options.DiffFormat == diff.FormatJson || options.SemanticDiff:
+			diffObserver = &semanticDiffObserver{
+				diff:     c.TreeDiff,
+				file:     c.File,
+				redactor: c.Redactor,
+				output:   c.Output,
+				options:  options.DiffOptions,
+				json:     options.DiffFormat == diff.FormatJson,
+			}
+		case options.DiffFormat == diff.FormatUnified:
+			diffObserver = &unifiedDiffObserver{
+				diff:     c.TextDiff,
+				file:     c.File,
+				redactor: c.Redactor,
+				output:   c.Output,
+			}
+		default:
+			showDiff = true
+		}
+	}
+	if diffObserver != nil {
+		options.Observers = append([]StepObserver{diffObserver}, options.Observers...)
+	}
+
+	err = c.observe(options, 0, nil, out)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while observing template", err)
+	}
 
-	if len(plan.Steps) > 0 || !plan.Global.IsZero() {
+	if len(plan.Steps) > 0 || !global.IsZero() {
 
-		for _, step := range plan.Steps {
+		for i, step := range plan.Steps {
+			if !options.includes(i+1, step) {
+				continue
+			}
 			var taggedSnippet *file.TaggedBytes
 			if step.Snippet != "" {
 				taggedSnippet, err = c.File.ReadAndTag(step.Snippet)
@@ -49,23 +189,167 @@ func (c *ComposerImpl) Compose(
 					return nil, fmt.Errorf("%w\n  while trying to load snippet %s", err, step.Snippet)
 				}
 			}
-			out, err = c.Executor.Execute(showPlan, showDiff, in, taggedSnippet, &step.Processor, step.FlattenParams(), plan.Global)
+			out, err = c.Executor.Execute(options.ShowPlan, showDiff, in, taggedSnippet, &step.Processor, step.FlattenParams(), global)
 			if err != nil {
 				return nil, fmt.Errorf("%w\n  while trying to apply snippet %s", err, step.Snippet)
 			}
 
+			err = c.observe(options, i+1, step, out)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while observing output of step %d", err, i+1)
+			}
+
 			in = &file.TaggedBytes{Tag: in.Tag, Bytes: out}
 		}
 
-		if !plan.Global.IsZero() {
-			out, err = c.Executor.Execute(showPlan, showDiff, in, nil, nil, library.InterpolatorParams{}, plan.Global)
+		// the output of a step range is the document as it stood after the last selected step
+		if !global.IsZero() && options.ToStep == 0 {
+			out, err = c.Executor.Execute(options.ShowPlan, showDiff, in, nil, nil, library.InterpolatorParams{}, global)
 			if err != nil {
-				return nil, fmt.Errorf("%w\n  while trying to apply globals %+v", err, plan.Global)
+				return nil, fmt.Errorf("%w\n  while trying to apply globals %+v", err, c.Redactor.Params(global))
+			}
+
+			err = c.observe(options, len(plan.Steps)+1, nil, out)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while observing output of globals", err)
 			}
 		}
-	} else {
-		out = in.Bytes
+	}
+
+	if options.Strict {
+		unresolved, err := c.Vars.Unresolved(out)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while checking for unresolved variables", err)
+		}
+		if len(unresolved) > 0 {
+			lines := []string{}
+			for _, u := range unresolved {
+				lines = append(lines, fmt.Sprintf("  %s: ((%s))", u.Path, u.Variable))
+			}
+			return nil, fmt.Errorf("Unresolved variables in output:\n%s", strings.Join(lines, "\n"))
+		}
 	}
 
 	return out, nil
 }
+
+func (c *ComposerImpl) observe(options Options, index int, step *plan.Step, out []byte) error {
+	if options.DumpDir != "" {
+		err := c.dump(options.DumpDir, index, stepName(index, step), out)
+		if err != nil {
+			return fmt.Errorf("%w\n  while trying to dump output", err)
+		}
+	}
+	for _, o := range options.Observers {
+		err := o.Observe(index, step, out)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ComposerImpl) dump(dir string, index int, name string, content []byte) error {
+	err := c.File.MkDir(dir)
+	if err != nil {
+		return fmt.Errorf("%w\n  while creating directory %s", err, dir)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%03d-%s.yml", index, name))
+	err = c.File.Write(path, content, 0644)
+	if err != nil {
+		return fmt.Errorf("%w\n  while writing %s", err, path)
+	}
+	return nil
+}
+
+// relative snippet path, or step name for steps without a snippet
+func stepLabel(f file.FileAccess, index int, step *plan.Step) (string, error) {
+	if step == nil || step.Snippet == "" {
+		return stepName(index, step), nil
+	}
+	rel, err := f.ResolveRelativeFromWD(step.Snippet)
+	if err != nil {
+		return "", fmt.Errorf("%w\n  while resolving relative snippet path %s", err, step.Snippet)
+	}
+	return rel, nil
+}
+
+// snippet file name, or processor type for steps without a snippet
+func stepName(index int, step *plan.Step) string {
+	if step == nil {
+		if index == 0 {
+			return "template"
+		}
+		return "globals"
+	}
+	if step.Snippet == "" {
+		return string(step.Processor.Type)
+	}
+	base := filepath.Base(step.Snippet)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// prints the structural changes made by each step
+type semanticDiffObserver struct {
+	diff     diff.StructuralDiff
+	file     file.FileAccess
+	redactor redact.Redactor
+	output   io.Writer
+	options  diff.CompareOptions
+	json     bool
+	previous []byte
+}
+
+// one json object per line
+type stepChanges struct {
+	Step    int
+	Label   string
+	Changes []diff.Change
+}
+
+func (s *semanticDiffObserver) Observe(index int, step *plan.Step, out []byte) error {
+	if index > 0 {
+		changes, err := s.diff.Compare(s.previous, out, s.options)
+		if err != nil {
+			return fmt.Errorf("%w\n  while comparing output", err)
+		}
+		if s.json {
+			label, err := stepLabel(s.file, index, step)
+			if err != nil {
+				return err
+			}
+			bytes, err := json.Marshal(stepChanges{Step: index, Label: label, Changes: changes})
+			if err != nil {
+				return fmt.Errorf("%w\n  while marshaling changes", err)
+			}
+			s.output.Write([]byte(s.redactor.Redact(string(bytes)) + "\n"))
+		} else {
+			s.output.Write([]byte("\nDiff:\n"))
+			s.output.Write([]byte(s.redactor.Redact(diff.FormatChanges(changes))))
+		}
+	}
+	s.previous = out
+	return nil
+}
+
+// prints a patch for each step labelled with the snippet path
+type unifiedDiffObserver struct {
+	diff     diff.Diff
+	file     file.FileAccess
+	redactor redact.Redactor
+	output   io.Writer
+	previous []byte
+}
+
+func (u *unifiedDiffObserver) Observe(index int, step *plan.Step, out []byte) error {
+	if index > 0 {
+		label, err := stepLabel(u.file, index, step)
+		if err != nil {
+			return err
+		}
+		patch := u.diff.UnifiedDiff(string(u.previous), string(out), label, label)
+		u.output.Write([]byte(u.redactor.Redact(patch)))
+	}
+	u.previous = out
+	return nil
+}
@@ -7,9 +7,11 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
 
+	"github.com/cjnosal/manifer/v2/pkg/diff"
 	"github.com/cjnosal/manifer/v2/pkg/file"
 	"github.com/cjnosal/manifer/v2/pkg/library"
 	"github.com/cjnosal/manifer/v2/pkg/plan"
+	"github.com/cjnosal/manifer/v2/pkg/redact"
 	"github.com/cjnosal/manifer/v2/test"
 )
 
@@ -305,6 +307,7 @@ func TestCompose(t *testing.T) {
 			Resolver: mockResolver,
 			File:     mockFile,
 			Executor: mockExecutor,
+			Redactor: redact.NewSecretRedactor(nil),
 		}
 		libraries := []string{
 			"/tmp/library/lib.yml",
@@ -322,7 +325,7 @@ func TestCompose(t *testing.T) {
 		taggedSnippet := &file.TaggedBytes{Tag: planWithoutGlobals.Steps[0].Snippet, Bytes: []byte("op")}
 		snippetProcessor := &library.Processor{Type: library.OpsFile, Options: map[string]interface{}{}}
 		intError := errors.New("test")
-		expectedError := errors.New("test\n  while trying to apply globals {Vars:map[cli:carg global:garg] VarFiles:map[] VarsFiles:[] VarsEnv:[] VarsStore: RawArgs:[-vfoo=bar]}")
+		expectedError := errors.New("test\n  while trying to apply globals {Vars:map[cli:carg global:garg] VarFiles:map[] VarsFiles:[] VarsEnv:[] VarsStore: RawArgs:[-vfoo=bar] VarsStoreSeed:}")
 
 		mockResolver.EXPECT().Resolve(libraries, scenarioNames, passthrough).Times(1).Return(planWithGlobals, nil)
 		mockFile.EXPECT().ReadAndTag(taggedSnippet.Tag).Times(1).Return(taggedSnippet, nil)
@@ -336,4 +339,491 @@ func TestCompose(t *testing.T) {
 		}
 	})
 
+	t.Run("interpolate template error with secret globals", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+			Redactor: redact.NewSecretRedactor(nil),
+		}
+		global := library.InterpolatorParams{
+			Vars:      map[string]interface{}{"db_password": "hunter2secret"},
+			VarsFiles: []string{"/missing.yml"},
+		}
+		executionPlan := &plan.Plan{Global: global}
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+
+		mockExecutor.EXPECT().Execute(false, false, taggedTemplate, nil, nil, library.InterpolatorParams{}, global).Times(1).Return(nil, errors.New("test"))
+
+		_, err := subject.ComposePlan(taggedTemplate, executionPlan, Options{})
+
+		expectedError := errors.New("test\n  while trying to apply globals {Vars:map[db_password:***] VarFiles:map[] VarsFiles:[/missing.yml] VarsEnv:[] VarsStore: RawArgs:[] VarsStoreSeed:}")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+
+}
+
+func TestComposePlan(t *testing.T) {
+
+	step := func(snippet string, scenarios ...string) *plan.Step {
+		params := []plan.TaggedParams{{Tag: "snippet"}}
+		for _, s := range scenarios {
+			params = append(params, plan.TaggedParams{Tag: s})
+		}
+		return &plan.Step{
+			Snippet:   snippet,
+			Params:    params,
+			Processor: library.Processor{Type: library.OpsFile},
+		}
+	}
+
+	executionPlan := &plan.Plan{
+		Steps: []*plan.Step{
+			step("/lib/first.yml", "a", "meta"),
+			step("/lib/second.yml", "b", "meta"),
+			step("/lib/third.yml", "a", "meta"),
+			step("/lib/fourth.yml", "c"),
+		},
+	}
+
+	t.Run("step range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+		}
+
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+		second := &file.TaggedBytes{Tag: "/lib/second.yml", Bytes: []byte("2")}
+		third := &file.TaggedBytes{Tag: "/lib/third.yml", Bytes: []byte("3")}
+
+		mockFile.EXPECT().ReadAndTag("/lib/second.yml").Times(1).Return(second, nil)
+		mockFile.EXPECT().ReadAndTag("/lib/third.yml").Times(1).Return(third, nil)
+		gomock.InOrder(
+			mockExecutor.EXPECT().Execute(false, false, taggedTemplate, second, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]byte("out2"), nil),
+			mockExecutor.EXPECT().Execute(false, false, &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("out2")}, third, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]byte("out3"), nil),
+		)
+
+		out, err := subject.ComposePlan(taggedTemplate, executionPlan, Options{FromStep: 2, ToStep: 3})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		} else if string(out) != "out3" {
+			t.Errorf("Expected:\n'''out3'''\nActual:\n'''%s'''\n", out)
+		}
+	})
+
+	t.Run("step range skips globals", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+		}
+
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+		first := &file.TaggedBytes{Tag: "/lib/first.yml", Bytes: []byte("1")}
+		global := library.InterpolatorParams{Vars: map[string]interface{}{"global": "garg"}}
+
+		mockFile.EXPECT().ReadAndTag("/lib/first.yml").Times(1).Return(first, nil)
+		mockExecutor.EXPECT().Execute(false, false, taggedTemplate, first, gomock.Any(), gomock.Any(), global).Times(1).Return([]byte("out1"), nil)
+
+		out, err := subject.ComposePlan(taggedTemplate, &plan.Plan{Global: global, Steps: executionPlan.Steps}, Options{ToStep: 1})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		} else if string(out) != "out1" {
+			t.Errorf("Expected:\n'''out1'''\nActual:\n'''%s'''\n", out)
+		}
+	})
+
+	rangeCases := []struct {
+		name    string
+		options Options
+
+		expectedError error
+	}{
+		{
+			name:          "negative step",
+			options:       Options{FromStep: -1},
+			expectedError: errors.New("Step range must not be negative (from -1 to 0)\n  while selecting steps"),
+		},
+		{
+			name:          "reversed range",
+			options:       Options{FromStep: 3, ToStep: 2},
+			expectedError: errors.New("First step 3 is after last step 2\n  while selecting steps"),
+		},
+		{
+			name:          "after the plan",
+			options:       Options{FromStep: 5},
+			expectedError: errors.New("First step 5 is after the end of the plan (4 steps)\n  while selecting steps"),
+		},
+	}
+
+	for _, c := range rangeCases {
+		t.Run(c.name, func(t *testing.T) {
+			subject := ComposerImpl{}
+
+			_, err := subject.ComposePlan(&file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}, executionPlan, c.options)
+
+			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
+			}
+		})
+	}
+
+	t.Run("only scenario", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+		}
+
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+		first := &file.TaggedBytes{Tag: "/lib/first.yml", Bytes: []byte("1")}
+		third := &file.TaggedBytes{Tag: "/lib/third.yml", Bytes: []byte("3")}
+
+		mockFile.EXPECT().ReadAndTag("/lib/first.yml").Times(1).Return(first, nil)
+		mockFile.EXPECT().ReadAndTag("/lib/third.yml").Times(1).Return(third, nil)
+		gomock.InOrder(
+			mockExecutor.EXPECT().Execute(false, false, taggedTemplate, first, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]byte("out1"), nil),
+			mockExecutor.EXPECT().Execute(false, false, &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("out1")}, third, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]byte("out3"), nil),
+		)
+
+		out, err := subject.ComposePlan(taggedTemplate, executionPlan, Options{Scenarios: []string{"a"}})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		} else if string(out) != "out3" {
+			t.Errorf("Expected:\n'''out3'''\nActual:\n'''%s'''\n", out)
+		}
+	})
+
+	t.Run("no steps selected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+		}
+
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+
+		out, err := subject.ComposePlan(taggedTemplate, executionPlan, Options{Scenarios: []string{"missing"}})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		} else if string(out) != "in" {
+			t.Errorf("Expected:\n'''in'''\nActual:\n'''%s'''\n", out)
+		}
+	})
+
+	t.Run("dump dir", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+		}
+
+		dumpPlan := &plan.Plan{
+			Global: library.InterpolatorParams{
+				Vars: map[string]interface{}{"global": "garg"},
+			},
+			Steps: []*plan.Step{
+				step("/lib/first.yml", "a"),
+				{
+					Processor: library.Processor{Type: library.Yq, Options: map[string]interface{}{"command": "prefix"}},
+				},
+			},
+		}
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+		first := &file.TaggedBytes{Tag: "/lib/first.yml", Bytes: []byte("1")}
+
+		mockFile.EXPECT().ReadAndTag("/lib/first.yml").Times(1).Return(first, nil)
+		mockFile.EXPECT().MkDir("/dump").Times(4).Return(nil)
+		gomock.InOrder(
+			mockFile.EXPECT().Write("/dump/000-template.yml", []byte("in"), gomock.Any()).Times(1).Return(nil),
+			mockExecutor.EXPECT().Execute(false, false, taggedTemplate, first, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]byte("out1"), nil),
+			mockFile.EXPECT().Write("/dump/001-first.yml", []byte("out1"), gomock.Any()).Times(1).Return(nil),
+			mockExecutor.EXPECT().Execute(false, false, gomock.Any(), nil, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]byte("out2"), nil),
+			mockFile.EXPECT().Write("/dump/002-yq.yml", []byte("out2"), gomock.Any()).Times(1).Return(nil),
+			mockExecutor.EXPECT().Execute(false, false, gomock.Any(), nil, nil, library.InterpolatorParams{}, dumpPlan.Global).Times(1).Return([]byte("final"), nil),
+			mockFile.EXPECT().Write("/dump/003-globals.yml", []byte("final"), gomock.Any()).Times(1).Return(nil),
+		)
+
+		out, err := subject.ComposePlan(taggedTemplate, dumpPlan, Options{DumpDir: "/dump"})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		} else if string(out) != "final" {
+			t.Errorf("Expected:\n'''final'''\nActual:\n'''%s'''\n", out)
+		}
+	})
+
+	t.Run("dump error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+		}
+
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+
+		mockFile.EXPECT().MkDir("/dump").Times(1).Return(errors.New("test"))
+
+		_, err := subject.ComposePlan(taggedTemplate, executionPlan, Options{DumpDir: "/dump"})
+
+		expectedError := errors.New("test\n  while creating directory /dump\n  while trying to dump output\n  while observing template")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockVars := plan.NewMockVarsReporter(ctrl)
+		subject := ComposerImpl{
+			Vars: mockVars,
+		}
+
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+
+		mockVars.EXPECT().Unresolved([]byte("in")).Times(1).Return([]plan.UnresolvedVar{
+			{Path: "/foo", Variable: "bar"},
+			{Path: "/bizz", Variable: "bazz"},
+		}, nil)
+
+		_, err := subject.ComposePlan(taggedTemplate, &plan.Plan{}, Options{Strict: true})
+
+		expectedError := errors.New("Unresolved variables in output:\n  /foo: ((bar))\n  /bizz: ((bazz))")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+
+	t.Run("strict without placeholders", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockVars := plan.NewMockVarsReporter(ctrl)
+		subject := ComposerImpl{
+			Vars: mockVars,
+		}
+
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+
+		mockVars.EXPECT().Unresolved([]byte("in")).Times(1).Return([]plan.UnresolvedVar{}, nil)
+
+		out, err := subject.ComposePlan(taggedTemplate, &plan.Plan{}, Options{Strict: true})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		} else if string(out) != "in" {
+			t.Errorf("Expected:\n'''in'''\nActual:\n'''%s'''\n", out)
+		}
+	})
+
+	t.Run("vars store seed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+		}
+
+		seedPlan := &plan.Plan{
+			Steps: []*plan.Step{
+				{
+					Snippet:   "/snippet",
+					Processor: library.Processor{Type: library.OpsFile, Options: map[string]interface{}{}},
+				},
+			},
+		}
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+		taggedSnippet := &file.TaggedBytes{Tag: seedPlan.Steps[0].Snippet, Bytes: []byte("op")}
+		snippetProcessor := &library.Processor{Type: library.OpsFile, Options: map[string]interface{}{}}
+		seeded := library.InterpolatorParams{VarsStoreSeed: "seed"}
+		stepOut := &file.TaggedBytes{Tag: taggedTemplate.Tag, Bytes: []byte("step")}
+
+		mockFile.EXPECT().ReadAndTag(taggedSnippet.Tag).Times(1).Return(taggedSnippet, nil)
+		mockExecutor.EXPECT().Execute(false, false, taggedTemplate, taggedSnippet, snippetProcessor, seedPlan.Steps[0].FlattenParams(), seeded).Times(1).Return([]byte("step"), nil)
+		mockExecutor.EXPECT().Execute(false, false, stepOut, nil, nil, library.InterpolatorParams{}, seeded).Times(1).Return([]byte("out"), nil)
+
+		out, err := subject.ComposePlan(taggedTemplate, seedPlan, Options{VarsStoreSeed: "seed"})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		} else if string(out) != "out" {
+			t.Errorf("Expected:\n'''out'''\nActual:\n'''%s'''\n", out)
+		}
+		if seedPlan.Global.VarsStoreSeed != "" {
+			t.Errorf("Expected plan to be unchanged")
+		}
+	})
+
+	t.Run("semantic diff", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockDiff := diff.NewMockStructuralDiff(ctrl)
+		mockRedactor := redact.NewMockRedactor(ctrl)
+		output := &test.StringWriter{}
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+			TreeDiff: mockDiff,
+			Redactor: mockRedactor,
+			Output:   output,
+		}
+
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+		fourth := &file.TaggedBytes{Tag: "/lib/fourth.yml", Bytes: []byte("4")}
+		diffOptions := diff.CompareOptions{IgnoreOrder: true}
+
+		mockFile.EXPECT().ReadAndTag("/lib/fourth.yml").Times(1).Return(fourth, nil)
+		gomock.InOrder(
+			mockExecutor.EXPECT().Execute(false, false, taggedTemplate, fourth, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]byte("out4"), nil),
+			mockDiff.EXPECT().Compare([]byte("in"), []byte("out4"), diffOptions).Times(1).Return([]diff.Change{
+				{Path: "/foo", Type: diff.Changed, Old: "bar", New: "baz"},
+			}, nil),
+			mockRedactor.EXPECT().Redact("~ /foo: bar -> baz\n").Times(1).Return("~ /foo: bar -> ***\n"),
+		)
+
+		_, err := subject.ComposePlan(taggedTemplate, executionPlan, Options{
+			ShowDiff:     true,
+			SemanticDiff: true,
+			DiffOptions:  diffOptions,
+			FromStep:     4,
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedOutput := "\nDiff:\n~ /foo: bar -> ***\n"
+		if output.String() != expectedOutput {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedOutput, output.String())
+		}
+	})
+
+	t.Run("unified diff", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockDiff := diff.NewMockDiff(ctrl)
+		mockRedactor := redact.NewMockRedactor(ctrl)
+		output := &test.StringWriter{}
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+			TextDiff: mockDiff,
+			Redactor: mockRedactor,
+			Output:   output,
+		}
+
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+		fourth := &file.TaggedBytes{Tag: "/lib/fourth.yml", Bytes: []byte("4")}
+
+		mockFile.EXPECT().ReadAndTag("/lib/fourth.yml").Times(1).Return(fourth, nil)
+		gomock.InOrder(
+			mockExecutor.EXPECT().Execute(false, false, taggedTemplate, fourth, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]byte("out4"), nil),
+			mockFile.EXPECT().ResolveRelativeFromWD("/lib/fourth.yml").Times(1).Return("lib/fourth.yml", nil),
+			mockDiff.EXPECT().UnifiedDiff("in", "out4", "lib/fourth.yml", "lib/fourth.yml").Times(1).Return("patch"),
+			mockRedactor.EXPECT().Redact("patch").Times(1).DoAndReturn(func(s string) string { return s }),
+		)
+
+		_, err := subject.ComposePlan(taggedTemplate, executionPlan, Options{
+			ShowDiff:   true,
+			DiffFormat: diff.FormatUnified,
+			FromStep:   4,
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		if output.String() != "patch" {
+			t.Errorf("Expected:\n'''patch'''\nActual:\n'''%s'''\n", output.String())
+		}
+	})
+
+	t.Run("json diff", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockExecutor := plan.NewMockExecutor(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockDiff := diff.NewMockStructuralDiff(ctrl)
+		mockRedactor := redact.NewMockRedactor(ctrl)
+		output := &test.StringWriter{}
+		subject := ComposerImpl{
+			File:     mockFile,
+			Executor: mockExecutor,
+			TreeDiff: mockDiff,
+			Redactor: mockRedactor,
+			Output:   output,
+		}
+
+		taggedTemplate := &file.TaggedBytes{Tag: "/tmp/base.yml", Bytes: []byte("in")}
+		fourth := &file.TaggedBytes{Tag: "/lib/fourth.yml", Bytes: []byte("4")}
+
+		mockFile.EXPECT().ReadAndTag("/lib/fourth.yml").Times(1).Return(fourth, nil)
+		gomock.InOrder(
+			mockExecutor.EXPECT().Execute(false, false, taggedTemplate, fourth, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]byte("out4"), nil),
+			mockDiff.EXPECT().Compare([]byte("in"), []byte("out4"), diff.CompareOptions{}).Times(1).Return([]diff.Change{
+				{Path: "/foo", Type: diff.Added, New: "bar"},
+			}, nil),
+			mockFile.EXPECT().ResolveRelativeFromWD("/lib/fourth.yml").Times(1).Return("lib/fourth.yml", nil),
+			mockRedactor.EXPECT().Redact(`{"Step":4,"Label":"lib/fourth.yml","Changes":[{"Path":"/foo","Type":"added","Old":null,"New":"bar"}]}`).Times(1).DoAndReturn(func(s string) string { return s }),
+		)
+
+		_, err := subject.ComposePlan(taggedTemplate, executionPlan, Options{
+			ShowDiff:   true,
+			DiffFormat: diff.FormatJson,
+			FromStep:   4,
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedOutput := `{"Step":4,"Label":"lib/fourth.yml","Changes":[{"Path":"/foo","Type":"added","Old":null,"New":"bar"}]}` + "\n"
+		if output.String() != expectedOutput {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedOutput, output.String())
+		}
+	})
 }
@@ -11,6 +11,9 @@ import (
 
 type ScenarioResolver interface {
 	Resolve(libPaths []string, scenarioNames []string, passthrough []string) (*plan.Plan, error)
+
+	// scenario trees followed by nodes for passthrough snippets and vars
+	ResolveNodes(libPaths []string, scenarioNames []string, passthrough []string) (library.ScenarioNodes, error)
 }
 
 type Resolver struct {
@@ -20,6 +23,29 @@ type Resolver struct {
 }
 
 func (r *Resolver) Resolve(libPaths []string, scenarioNames []string, passthrough []string) (*plan.Plan, error) {
+	nodes, err := r.ResolveNodes(libPaths, scenarioNames, passthrough)
+	if err != nil {
+		return nil, err
+	}
+	executionPlan := &plan.Plan{
+		Global: library.InterpolatorParams{
+			Vars:      map[string]interface{}{},
+			VarFiles:  map[string]string{},
+			VarsFiles: []string{},
+			VarsEnv:   []string{},
+			VarsStore: "",
+			RawArgs:   []string{},
+		},
+		Steps: []*plan.Step{},
+	}
+	for _, node := range nodes {
+		executionPlan = plan.Append(executionPlan, plan.FromScenarioTree(node))
+	}
+
+	return executionPlan, nil
+}
+
+func (r *Resolver) ResolveNodes(libPaths []string, scenarioNames []string, passthrough []string) (library.ScenarioNodes, error) {
 	libraries, err := r.Loader.Load(libPaths)
 	if err != nil {
 		return nil, fmt.Errorf("%w\n  while trying to load libraries", err)
@@ -34,7 +60,7 @@ func (r *Resolver) Resolve(libPaths []string, scenarioNames []string, passthroug
 		nodes = append(nodes, node)
 	}
 
-	for _, t := range library.Types {
+	for _, t := range r.ProcessorFactory.Types() {
 		processor, err := r.ProcessorFactory.Create(t)
 		if err != nil {
 			return nil, fmt.Errorf("%w\n  while initializing processor of type  %s", err, t)
@@ -58,20 +84,6 @@ func (r *Resolver) Resolve(libPaths []string, scenarioNames []string, passthroug
 	if len(remainder) > 0 {
 		return nil, fmt.Errorf("Invalid passthrough arguments %v", remainder)
 	}
-	executionPlan := &plan.Plan{
-		Global: library.InterpolatorParams{
-			Vars:      map[string]interface{}{},
-			VarFiles:  map[string]string{},
-			VarsFiles: []string{},
-			VarsEnv:   []string{},
-			VarsStore: "",
-			RawArgs:   []string{},
-		},
-		Steps: []*plan.Step{},
-	}
-	for _, node := range nodes {
-		executionPlan = plan.Append(executionPlan, plan.FromScenarioTree(node))
-	}
 
-	return executionPlan, nil
+	return nodes, nil
 }
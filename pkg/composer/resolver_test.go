@@ -161,6 +161,7 @@ func TestResolve(t *testing.T) {
 				Steps: []*plan.Step{
 					{
 						Snippet: "opsfile",
+						Library: "<cli>",
 						Params: []plan.TaggedParams{
 							{
 								Tag:          "snippet",
@@ -177,6 +178,7 @@ func TestResolve(t *testing.T) {
 					},
 					{
 						Snippet: "yqscript",
+						Library: "<cli>",
 						Params: []plan.TaggedParams{
 							{
 								Tag:          "snippet",
@@ -364,19 +366,38 @@ func TestResolve(t *testing.T) {
 			mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
 			mockOpsProcessor := processor.NewMockProcessor(ctrl)
 			mockYqProcessor := processor.NewMockProcessor(ctrl)
+			mockJsonPatchProcessor := processor.NewMockProcessor(ctrl)
+			mockMergePatchProcessor := processor.NewMockProcessor(ctrl)
+			mockYq4Processor := processor.NewMockProcessor(ctrl)
+			mockJqProcessor := processor.NewMockProcessor(ctrl)
+			mockGoTemplateProcessor := processor.NewMockProcessor(ctrl)
+			mockExecProcessor := processor.NewMockProcessor(ctrl)
 			mockInterpolator := interpolator.NewMockInterpolator(ctrl)
 
 			mockLoader.EXPECT().Load(c.libraryPaths).Times(1).Return(c.expectedLibraries, c.yamlError)
 			if c.yamlError == nil {
+				mockProcessorFactory.EXPECT().Types().Times(1).Return([]library.Type{library.OpsFile, library.Yq, library.JsonPatch, library.MergePatch, library.Yq4, library.Jq, library.GoTemplate, library.Exec})
 				mockProcessorFactory.EXPECT().Create(library.OpsFile).Times(1).Return(mockOpsProcessor, nil)
 				mockOpsProcessor.EXPECT().ParsePassthroughFlags(c.passthrough).Times(1).Return(c.expectedOpsFilePassthroughNode, []string{"opsremainder"}, c.parseError)
 				if c.parseError == nil {
 					mockProcessorFactory.EXPECT().Create(library.Yq).Times(1).Return(mockYqProcessor, nil)
 					mockYqProcessor.EXPECT().ParsePassthroughFlags([]string{"opsremainder"}).Times(1).Return(c.expectedYqPassthroughNode, []string{"yqremainder"}, nil)
+					mockProcessorFactory.EXPECT().Create(library.JsonPatch).Times(1).Return(mockJsonPatchProcessor, nil)
+					mockJsonPatchProcessor.EXPECT().ParsePassthroughFlags([]string{"yqremainder"}).Times(1).Return(nil, []string{"jsonpatchremainder"}, nil)
+					mockProcessorFactory.EXPECT().Create(library.MergePatch).Times(1).Return(mockMergePatchProcessor, nil)
+					mockMergePatchProcessor.EXPECT().ParsePassthroughFlags([]string{"jsonpatchremainder"}).Times(1).Return(nil, []string{"mergepatchremainder"}, nil)
+					mockProcessorFactory.EXPECT().Create(library.Yq4).Times(1).Return(mockYq4Processor, nil)
+					mockYq4Processor.EXPECT().ParsePassthroughFlags([]string{"mergepatchremainder"}).Times(1).Return(nil, []string{"yq4remainder"}, nil)
+					mockProcessorFactory.EXPECT().Create(library.Jq).Times(1).Return(mockJqProcessor, nil)
+					mockJqProcessor.EXPECT().ParsePassthroughFlags([]string{"yq4remainder"}).Times(1).Return(nil, []string{"jqremainder"}, nil)
+					mockProcessorFactory.EXPECT().Create(library.GoTemplate).Times(1).Return(mockGoTemplateProcessor, nil)
+					mockGoTemplateProcessor.EXPECT().ParsePassthroughFlags([]string{"jqremainder"}).Times(1).Return(nil, []string{"gotemplateremainder"}, nil)
+					mockProcessorFactory.EXPECT().Create(library.Exec).Times(1).Return(mockExecProcessor, nil)
+					mockExecProcessor.EXPECT().ParsePassthroughFlags([]string{"gotemplateremainder"}).Times(1).Return(nil, []string{"execremainder"}, nil)
 				}
 			}
 			if c.yamlError == nil && c.parseError == nil {
-				mockInterpolator.EXPECT().ParsePassthroughVars([]string{"yqremainder"}).Times(1).Return(c.expectedVarNode, []string{}, c.parseVarError)
+				mockInterpolator.EXPECT().ParsePassthroughVars([]string{"execremainder"}).Times(1).Return(c.expectedVarNode, []string{}, c.parseVarError)
 			}
 
 			subject := Resolver{
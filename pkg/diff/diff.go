@@ -6,14 +6,26 @@ import (
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
+// output formats for step diffs
+const (
+	FormatPretty  = "pretty"
+	FormatUnified = "unified"
+	FormatJson    = "json"
+)
+
+var Formats = []string{FormatPretty, FormatUnified, FormatJson} // treat as const
+
 type Diff interface {
 	FindDiff(path1 string, path2 string) (string, error)
 	StringDiff(str1 string, str2 string) string
+	UnifiedDiff(str1 string, str2 string, label1 string, label2 string) string
 }
 
 type diffMatchPatch interface {
 	DiffMain(text1 string, text2 string, checkLines bool) []diffmatchpatch.Diff
 	DiffPrettyText([]diffmatchpatch.Diff) string
+	DiffLinesToChars(text1 string, text2 string) (string, string, []string)
+	DiffCharsToLines(diffs []diffmatchpatch.Diff, lineArray []string) []diffmatchpatch.Diff
 }
 
 type FileDiff struct {
@@ -0,0 +1,246 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	y "gopkg.in/yaml.v3"
+)
+
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+type StructuralDiff interface {
+	FindChanges(path1 string, path2 string, options CompareOptions) ([]Change, error)
+	Compare(a []byte, b []byte, options CompareOptions) ([]Change, error)
+}
+
+type CompareOptions struct {
+	// match sequence elements by name or value instead of by index
+	IgnoreOrder bool
+}
+
+type Change struct {
+	Path string      `yaml:"path"`
+	Type string      `yaml:"type"`
+	Old  interface{} `yaml:"old,omitempty"`
+	New  interface{} `yaml:"new,omitempty"`
+}
+
+// compares parsed yaml documents by path, ignoring formatting and map key order
+type TreeDiff struct {
+	File file.FileAccess
+	Yaml yaml.YamlAccess
+}
+
+func (t *TreeDiff) FindChanges(path1 string, path2 string, options CompareOptions) ([]Change, error) {
+	b1, err := t.File.Read(path1)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while reading first file %s", err, path1)
+	}
+	b2, err := t.File.Read(path2)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while reading second file %s", err, path2)
+	}
+	return t.Compare(b1, b2, options)
+}
+
+func (t *TreeDiff) Compare(a []byte, b []byte, options CompareOptions) ([]Change, error) {
+	nodeA := &y.Node{}
+	err := t.Yaml.Unmarshal(a, nodeA)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while parsing first document", err)
+	}
+	nodeB := &y.Node{}
+	err = t.Yaml.Unmarshal(b, nodeB)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while parsing second document", err)
+	}
+	changes := []Change{}
+	compareNodes(resolve(nodeA), resolve(nodeB), "", options, &changes)
+	return changes, nil
+}
+
+func compareNodes(a *y.Node, b *y.Node, path string, options CompareOptions, changes *[]Change) {
+	if a == nil && b == nil {
+		return
+	}
+	if a == nil {
+		*changes = append(*changes, Change{Path: rootPath(path), Type: Added, New: decode(b)})
+		return
+	}
+	if b == nil {
+		*changes = append(*changes, Change{Path: rootPath(path), Type: Removed, Old: decode(a)})
+		return
+	}
+	if a.Kind != b.Kind {
+		*changes = append(*changes, Change{Path: rootPath(path), Type: Changed, Old: decode(a), New: decode(b)})
+		return
+	}
+	switch a.Kind {
+	case y.ScalarNode:
+		if a.Tag != b.Tag || a.Value != b.Value {
+			*changes = append(*changes, Change{Path: rootPath(path), Type: Changed, Old: decode(a), New: decode(b)})
+		}
+	case y.MappingNode:
+		compareMappings(a, b, path, options, changes)
+	case y.SequenceNode:
+		if options.IgnoreOrder {
+			compareUnorderedSequences(a, b, path, options, changes)
+		} else {
+			compareSequences(a, b, path, options, changes)
+		}
+	}
+}
+
+func compareMappings(a *y.Node, b *y.Node, path string, options CompareOptions, changes *[]Change) {
+	bValues := map[string]*y.Node{}
+	for i := 0; i+1 < len(b.Content); i += 2 {
+		bValues[b.Content[i].Value] = resolve(b.Content[i+1])
+	}
+	aKeys := map[string]bool{}
+	for i := 0; i+1 < len(a.Content); i += 2 {
+		key := a.Content[i].Value
+		aKeys[key] = true
+		compareNodes(resolve(a.Content[i+1]), bValues[key], childPath(path, yaml.EscapeToken(key)), options, changes)
+	}
+	for i := 0; i+1 < len(b.Content); i += 2 {
+		key := b.Content[i].Value
+		if !aKeys[key] {
+			compareNodes(nil, bValues[key], childPath(path, yaml.EscapeToken(key)), options, changes)
+		}
+	}
+}
+
+// elements are compared by index, identified by name when both elements share it
+func compareSequences(a *y.Node, b *y.Node, path string, options CompareOptions, changes *[]Change) {
+	for i := 0; i < len(a.Content) || i < len(b.Content); i++ {
+		var elementA, elementB *y.Node
+		if i < len(a.Content) {
+			elementA = resolve(a.Content[i])
+		}
+		if i < len(b.Content) {
+			elementB = resolve(b.Content[i])
+		}
+		token := fmt.Sprintf("%d", i)
+		if elementA != nil && elementB != nil {
+			tokenA := yaml.ElementToken(a, i)
+			if tokenA == yaml.ElementToken(b, i) {
+				token = tokenA
+			}
+		} else if elementA != nil {
+			token = yaml.ElementToken(a, i)
+		} else {
+			token = yaml.ElementToken(b, i)
+		}
+		compareNodes(elementA, elementB, childPath(path, token), options, changes)
+	}
+}
+
+// named elements are matched by name, other elements by value
+func compareUnorderedSequences(a *y.Node, b *y.Node, path string, options CompareOptions, changes *[]Change) {
+	matched := make([]bool, len(b.Content))
+	for i, c := range a.Content {
+		elementA := resolve(c)
+		tokenA := yaml.ElementToken(a, i)
+		named := strings.HasPrefix(tokenA, "name=")
+		match := -1
+		for j, d := range b.Content {
+			if matched[j] {
+				continue
+			}
+			elementB := resolve(d)
+			if named && tokenA == yaml.ElementToken(b, j) || !named && equal(elementA, elementB, options) {
+				match = j
+				break
+			}
+		}
+		if match < 0 {
+			compareNodes(elementA, nil, childPath(path, tokenA), options, changes)
+		} else {
+			matched[match] = true
+			compareNodes(elementA, resolve(b.Content[match]), childPath(path, tokenA), options, changes)
+		}
+	}
+	for j, d := range b.Content {
+		if !matched[j] {
+			elementB := resolve(d)
+			compareNodes(nil, elementB, childPath(path, yaml.ElementToken(b, j)), options, changes)
+		}
+	}
+}
+
+func equal(a *y.Node, b *y.Node, options CompareOptions) bool {
+	changes := []Change{}
+	compareNodes(a, b, "", options, &changes)
+	return len(changes) == 0
+}
+
+func resolve(n *y.Node) *y.Node {
+	for n != nil && (n.Kind == y.DocumentNode || n.Kind == y.AliasNode) {
+		if n.Kind == y.AliasNode {
+			n = n.Alias
+		} else if len(n.Content) > 0 {
+			n = n.Content[0]
+		} else {
+			return nil
+		}
+	}
+	return n
+}
+
+// decoded nodes use interface{} map keys which can not be marshaled to json
+func decode(n *y.Node) interface{} {
+	var value interface{}
+	err := n.Decode(&value)
+	if err != nil {
+		return n.Value
+	}
+	return yaml.StringKeys(value)
+}
+
+func childPath(path string, token string) string {
+	return path + "/" + token
+}
+
+func rootPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// one line per change: '+ path: new', '- path: old', or '~ path: old -> new'
+func FormatChanges(changes []Change) string {
+	var b strings.Builder
+	for _, c := range changes {
+		switch c.Type {
+		case Added:
+			fmt.Fprintf(&b, "+ %s: %s\n", c.Path, formatValue(c.New))
+		case Removed:
+			fmt.Fprintf(&b, "- %s: %s\n", c.Path, formatValue(c.Old))
+		default:
+			fmt.Fprintf(&b, "~ %s: %s -> %s\n", c.Path, formatValue(c.Old), formatValue(c.New))
+		}
+	}
+	return b.String()
+}
+
+func formatValue(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		bytes, err := json.Marshal(value)
+		if err == nil {
+			return string(bytes)
+		}
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%v", value)
+}
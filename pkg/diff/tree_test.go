@@ -0,0 +1,145 @@
+package diff
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompare(t *testing.T) {
+
+	a := `name: dep
+instance_groups:
+- name: web
+  instances: 1
+- name: db
+  instances: 1
+tags: [x, y]
+removed: {foo: bar}
+`
+	b := `instance_groups:
+- name: db
+  instances: 2
+- name: web
+  instances: 1
+name: dep
+tags: [y, x]
+added: true
+`
+
+	t.Run("ordered", func(t *testing.T) {
+		subject := &TreeDiff{
+			Yaml: &yaml.Yaml{},
+		}
+
+		changes, err := subject.Compare([]byte(a), []byte(b), CompareOptions{})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expected := []Change{
+			{Path: "/instance_groups/0/name", Type: Changed, Old: "web", New: "db"},
+			{Path: "/instance_groups/0/instances", Type: Changed, Old: 1, New: 2},
+			{Path: "/instance_groups/1/name", Type: Changed, Old: "db", New: "web"},
+			{Path: "/tags/0", Type: Changed, Old: "x", New: "y"},
+			{Path: "/tags/1", Type: Changed, Old: "y", New: "x"},
+			{Path: "/removed", Type: Removed, Old: map[string]interface{}{"foo": "bar"}},
+			{Path: "/added", Type: Added, New: true},
+		}
+		if !cmp.Equal(expected, changes) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n", expected, changes, cmp.Diff(expected, changes))
+		}
+	})
+
+	t.Run("ignore order", func(t *testing.T) {
+		subject := &TreeDiff{
+			Yaml: &yaml.Yaml{},
+		}
+
+		changes, err := subject.Compare([]byte(a), []byte(b), CompareOptions{IgnoreOrder: true})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expected := []Change{
+			{Path: "/instance_groups/name=db/instances", Type: Changed, Old: 1, New: 2},
+			{Path: "/removed", Type: Removed, Old: map[string]interface{}{"foo": "bar"}},
+			{Path: "/added", Type: Added, New: true},
+		}
+		if !cmp.Equal(expected, changes) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n", expected, changes, cmp.Diff(expected, changes))
+		}
+	})
+
+	t.Run("kind and type changes", func(t *testing.T) {
+		subject := &TreeDiff{
+			Yaml: &yaml.Yaml{},
+		}
+
+		changes, err := subject.Compare([]byte("foo: [1]\nbar: '1'"), []byte("foo: 1\nbar: 1"), CompareOptions{})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expected := []Change{
+			{Path: "/foo", Type: Changed, Old: []interface{}{1}, New: 1},
+			{Path: "/bar", Type: Changed, Old: "1", New: 1},
+		}
+		if !cmp.Equal(expected, changes) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n", expected, changes, cmp.Diff(expected, changes))
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		subject := &TreeDiff{
+			Yaml: &yaml.Yaml{},
+		}
+
+		_, err := subject.Compare([]byte("foo: bar"), []byte("foo: [bar"), CompareOptions{})
+
+		if err == nil {
+			t.Errorf("Expected parse error")
+		}
+	})
+}
+
+func TestFindChanges(t *testing.T) {
+
+	t.Run("read error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFile := file.NewMockFileAccess(ctrl)
+		defer ctrl.Finish()
+
+		subject := &TreeDiff{
+			File: mockFile,
+			Yaml: &yaml.Yaml{},
+		}
+
+		mockFile.EXPECT().Read("a.yml").Times(1).Return(nil, errors.New("test"))
+
+		_, err := subject.FindChanges("a.yml", "b.yml", CompareOptions{})
+
+		expectedError := errors.New("test\n  while reading first file a.yml")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+}
+
+func TestFormatChanges(t *testing.T) {
+	changes := []Change{
+		{Path: "/a", Type: Added, New: map[string]interface{}{"b": 1}},
+		{Path: "/c", Type: Removed, Old: "d"},
+		{Path: "/e", Type: Changed, Old: nil, New: []interface{}{"f"}},
+	}
+	expected := "+ /a: {\"b\":1}\n- /c: d\n~ /e: null -> [\"f\"]\n"
+	actual := FormatChanges(changes)
+	if actual != expected {
+		t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expected, actual)
+	}
+}
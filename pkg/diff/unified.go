@@ -0,0 +1,128 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// lines of context around each hunk, as in diff -u
+const unifiedContext = 3
+
+type diffLine struct {
+	op   byte // ' ', '-', or '+'
+	text string
+}
+
+// diff -u style hunks that can be applied with patch
+func (f *FileDiff) UnifiedDiff(str1 string, str2 string, label1 string, label2 string) string {
+	if str1 == str2 {
+		return ""
+	}
+	chars1, chars2, lineArray := f.Patch.DiffLinesToChars(str1, str2)
+	diffs := f.Patch.DiffCharsToLines(f.Patch.DiffMain(chars1, chars2, false), lineArray)
+
+	lines := []diffLine{}
+	for _, d := range diffs {
+		op := byte(' ')
+		if d.Type == diffmatchpatch.DiffDelete {
+			op = '-'
+		} else if d.Type == diffmatchpatch.DiffInsert {
+			op = '+'
+		}
+		for _, text := range splitLines(d.Text) {
+			lines = append(lines, diffLine{op: op, text: text})
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", label1, label2)
+	for start := 0; start < len(lines); {
+		first := nextChange(lines, start)
+		if first < 0 {
+			break
+		}
+		// extend the hunk while changes are separated by at most two contexts, like diff -u
+		last := first
+		for next := nextChange(lines, last+1); next >= 0 && next-last <= 2*unifiedContext+1; next = nextChange(lines, last+1) {
+			last = next
+		}
+		from := maxInt(first-unifiedContext, 0)
+		to := minInt(last+unifiedContext+1, len(lines))
+		writeHunk(&b, lines, from, to)
+		start = to
+	}
+	return b.String()
+}
+
+func writeHunk(b *strings.Builder, lines []diffLine, from int, to int) {
+	oldStart, newStart := 1, 1
+	for _, l := range lines[:from] {
+		if l.op != '+' {
+			oldStart++
+		}
+		if l.op != '-' {
+			newStart++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, l := range lines[from:to] {
+		if l.op != '+' {
+			oldCount++
+		}
+		if l.op != '-' {
+			newCount++
+		}
+	}
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+	for _, l := range lines[from:to] {
+		b.WriteByte(l.op)
+		b.WriteString(l.text)
+		if !strings.HasSuffix(l.text, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func nextChange(lines []diffLine, from int) int {
+	for i := from; i < len(lines); i++ {
+		if lines[i].op != ' ' {
+			return i
+		}
+	}
+	return -1
+}
+
+// split text into lines, keeping line endings
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+
+	t.Run("separate hunks", func(t *testing.T) {
+		subject := &FileDiff{
+			Patch: diffmatchpatch.New(),
+		}
+		before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+		after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+		diff := subject.UnifiedDiff(before, after, "snippet.yml", "snippet.yml")
+
+		expected := `--- snippet.yml
++++ snippet.yml
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -9,4 +9,3 @@
+ i
+ j
+ k
+-l
+`
+		if expected != diff {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expected, diff)
+		}
+	})
+
+	t.Run("hunks joined across two contexts", func(t *testing.T) {
+		subject := &FileDiff{
+			Patch: diffmatchpatch.New(),
+		}
+		before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+		after := "a\nB\nc\nd\ne\nf\ng\nh\nI\nj\nk\n"
+
+		diff := subject.UnifiedDiff(before, after, "snippet.yml", "snippet.yml")
+
+		expected := `--- snippet.yml
++++ snippet.yml
+@@ -1,11 +1,11 @@
+ a
+-b
++B
+ c
+ d
+ e
+ f
+ g
+ h
+-i
++I
+ j
+ k
+`
+		if expected != diff {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expected, diff)
+		}
+	})
+
+	t.Run("missing trailing newline", func(t *testing.T) {
+		subject := &FileDiff{
+			Patch: diffmatchpatch.New(),
+		}
+
+		diff := subject.UnifiedDiff("", "foo: bar", "a", "b")
+
+		expected := "--- a\n+++ b\n@@ -0,0 +1 @@\n+foo: bar\n\\ No newline at end of file\n"
+		if expected != diff {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expected, diff)
+		}
+	})
+
+	t.Run("identical strings", func(t *testing.T) {
+		subject := &FileDiff{}
+
+		diff := subject.UnifiedDiff("content", "content", "a", "b")
+
+		if diff != "" {
+			t.Errorf("Expected empty diff but was '''%v'''", diff)
+		}
+	})
+}
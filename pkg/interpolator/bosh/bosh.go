@@ -2,6 +2,9 @@ package bosh
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/cjnosal/manifer/v2/pkg/file"
 	"github.com/cjnosal/manifer/v2/pkg/interpolator"
@@ -11,17 +14,51 @@ import (
 	"github.com/jessevdk/go-flags"
 )
 
+// matches bosh-cli's template interpolation
+var placeholderRegex = regexp.MustCompile(`\(\((!?[-/\.\w\pL]+)\)\)`)
+
 func NewBoshInterpolator() interpolator.Interpolator {
-	return &boshInterpolator{}
+	return &boshInterpolator{
+		stores: map[string]*memoryStore{},
+	}
 }
 
-type boshInterpolator struct{}
+type boshInterpolator struct {
+	stores map[string]*memoryStore // keyed by seed
+}
 
 func (i *boshInterpolator) Interpolate(templateBytes *file.TaggedBytes, params library.InterpolatorParams) ([]byte, error) {
 	if params.IsZero() {
 		return templateBytes.Bytes, nil
 	}
 
+	libVarFlags, err := i.varFlags(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var boshVars boshtpl.Variables
+	if params.VarsStoreSeed != "" {
+		libVarFlags.VarsFSStore = boshopts.VarsFSStore{}
+		store := i.memoryStore(params.VarsStoreSeed)
+		boshVars = boshtpl.NewMultiVars([]boshtpl.Variables{libVarFlags.AsVariables(), store})
+		store.generator.vars = boshVars
+	} else {
+		boshVars = libVarFlags.AsVariables()
+	}
+
+	template := boshtpl.NewTemplate(templateBytes.Bytes)
+
+	outBytes, err := template.Evaluate(boshVars, nil, boshtpl.EvaluateOpts{})
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to evaluate template %s", err, templateBytes.Tag)
+	}
+
+	return outBytes, nil
+}
+
+// params other than raw args
+func (i *boshInterpolator) libVarFlags(params library.InterpolatorParams) (boshopts.VarFlags, error) {
 	libKVs := []boshtpl.VarKV{}
 	for k, v := range params.Vars {
 		libKVs = append(libKVs, boshtpl.VarKV{
@@ -29,12 +66,13 @@ func (i *boshInterpolator) Interpolate(templateBytes *file.TaggedBytes, params l
 			Value: v,
 		})
 	}
+	sort.Slice(libKVs, func(a, b int) bool { return libKVs[a].Name < libKVs[b].Name })
 	libVarFiles := []boshtpl.VarFileArg{}
 	for v, p := range params.VarFiles {
 		f := &boshtpl.VarFileArg{}
 		err := f.UnmarshalFlag(fmt.Sprintf("%s=%s", v, p))
 		if err != nil {
-			return nil, fmt.Errorf("%w\n  unmarshaling var file %s", err, p)
+			return boshopts.VarFlags{}, fmt.Errorf("%w\n  unmarshaling var file %s", err, p)
 		}
 		libVarFiles = append(libVarFiles, *f)
 	}
@@ -43,7 +81,7 @@ func (i *boshInterpolator) Interpolate(templateBytes *file.TaggedBytes, params l
 		f := &boshtpl.VarsFileArg{}
 		err := f.UnmarshalFlag(p)
 		if err != nil {
-			return nil, fmt.Errorf("%w\n  unmarshaling vars file %s", err, p)
+			return boshopts.VarFlags{}, fmt.Errorf("%w\n  unmarshaling vars file %s", err, p)
 		}
 		libVarsFiles = append(libVarsFiles, *f)
 	}
@@ -52,7 +90,7 @@ func (i *boshInterpolator) Interpolate(templateBytes *file.TaggedBytes, params l
 		e := &boshtpl.VarsEnvArg{}
 		err := e.UnmarshalFlag(p)
 		if err != nil {
-			return nil, fmt.Errorf("%w\n  unmarshaling vars env %s", err, p)
+			return boshopts.VarFlags{}, fmt.Errorf("%w\n  unmarshaling vars env %s", err, p)
 		}
 		libVarsEnv = append(libVarsEnv, *e)
 	}
@@ -60,7 +98,7 @@ func (i *boshInterpolator) Interpolate(templateBytes *file.TaggedBytes, params l
 	if params.VarsStore != "" {
 		err := libStore.UnmarshalFlag(params.VarsStore)
 		if err != nil {
-			return nil, fmt.Errorf("%w\n  unmarshaling vars store %s", err, params.VarsStore)
+			return boshopts.VarFlags{}, fmt.Errorf("%w\n  unmarshaling vars store %s", err, params.VarsStore)
 		}
 	}
 
@@ -71,10 +109,26 @@ func (i *boshInterpolator) Interpolate(templateBytes *file.TaggedBytes, params l
 		VarsEnvs:    libVarsEnv,
 		VarsFSStore: *libStore,
 	}
+	return libVarFlags, nil
+}
+
+func (i *boshInterpolator) rawVarFlags(rawArgs []string) (boshopts.VarFlags, error) {
 	passthroughVarFlags := boshopts.VarFlags{}
-	_, err := flags.NewParser(&passthroughVarFlags, flags.None).ParseArgs(params.RawArgs)
+	_, err := flags.NewParser(&passthroughVarFlags, flags.None).ParseArgs(rawArgs)
 	if err != nil {
-		return nil, fmt.Errorf("%w\n  while trying to parse vars", err)
+		return boshopts.VarFlags{}, fmt.Errorf("%w\n  while trying to parse vars", err)
+	}
+	return passthroughVarFlags, nil
+}
+
+func (i *boshInterpolator) varFlags(params library.InterpolatorParams) (boshopts.VarFlags, error) {
+	libVarFlags, err := i.libVarFlags(params)
+	if err != nil {
+		return boshopts.VarFlags{}, err
+	}
+	passthroughVarFlags, err := i.rawVarFlags(params.RawArgs)
+	if err != nil {
+		return boshopts.VarFlags{}, err
 	}
 
 	libVarFlags.VarKVs = append(libVarFlags.VarKVs, passthroughVarFlags.VarKVs...)
@@ -85,16 +139,127 @@ func (i *boshInterpolator) Interpolate(templateBytes *file.TaggedBytes, params l
 		libVarFlags.VarsFSStore = passthroughVarFlags.VarsFSStore
 	}
 
-	boshVars := libVarFlags.AsVariables()
+	return libVarFlags, nil
+}
 
-	template := boshtpl.NewTemplate(templateBytes.Bytes)
+func (i *boshInterpolator) Referenced(templateBytes *file.TaggedBytes) []string {
+	names := []string{}
+	for _, match := range placeholderRegex.FindAllSubmatch(templateBytes.Bytes, -1) {
+		name := strings.TrimPrefix(string(match[1]), "!")
+		if !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
 
-	outBytes, err := template.Evaluate(boshVars, nil, boshtpl.EvaluateOpts{})
+func (i *boshInterpolator) Provided(params library.InterpolatorParams) ([]string, error) {
+	values, err := i.Values(params)
 	if err != nil {
-		return nil, fmt.Errorf("%w\n  while trying to evaluate template %s", err, templateBytes.Tag)
+		return nil, err
+	}
+	names := []string{}
+	for _, v := range values {
+		if !contains(names, v.Name) {
+			names = append(names, v.Name)
+		}
 	}
+	sort.Strings(names)
+	return names, nil
+}
 
-	return outBytes, nil
+func (i *boshInterpolator) Values(params library.InterpolatorParams) ([]interpolator.VarValue, error) {
+	values := []interpolator.VarValue{}
+	if params.IsZero() {
+		return values, nil
+	}
+	libVarFlags, err := i.libVarFlags(params)
+	if err != nil {
+		return nil, err
+	}
+	passthroughVarFlags, err := i.rawVarFlags(params.RawArgs)
+	if err != nil {
+		return nil, err
+	}
+	values = append(values, flagValues(passthroughVarFlags, true)...)
+	values = append(values, flagValues(libVarFlags, false)...)
+	return values, nil
+}
+
+func (i *boshInterpolator) Stored(params library.InterpolatorParams) ([]interpolator.VarValue, error) {
+	values := []interpolator.VarValue{}
+	if params.IsZero() {
+		return values, nil
+	}
+	if params.VarsStoreSeed != "" {
+		store := i.memoryStore(params.VarsStoreSeed)
+		defs, _ := store.List()
+		for _, def := range defs {
+			values = append(values, interpolator.VarValue{Name: def.Name, Value: store.values[def.Name], Via: interpolator.ViaVarsStore})
+		}
+		return values, nil
+	}
+	varFlags, err := i.varFlags(params)
+	if err != nil {
+		return nil, err
+	}
+	if !varFlags.VarsFSStore.IsSet() {
+		return values, nil
+	}
+	defs, err := varFlags.VarsFSStore.List()
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while listing vars store", err)
+	}
+	sort.Slice(defs, func(a, b int) bool { return defs[a].Name < defs[b].Name })
+	for _, def := range defs {
+		value, found, err := varFlags.VarsFSStore.Get(def)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while reading %s from vars store", err, def.Name)
+		}
+		if found {
+			values = append(values, interpolator.VarValue{Name: def.Name, Value: value, Via: interpolator.ViaVarsStore})
+		}
+	}
+	return values, nil
+}
+
+// values generated for a seed are shared by every template interpolated with it
+func (i *boshInterpolator) memoryStore(seed string) *memoryStore {
+	store, ok := i.stores[seed]
+	if !ok {
+		store = newMemoryStore(seed)
+		i.stores[seed] = store
+	}
+	return store
+}
+
+// later flags of the same kind take precedence
+func flagValues(f boshopts.VarFlags, raw bool) []interpolator.VarValue {
+	values := []interpolator.VarValue{}
+	add := func(vars boshtpl.StaticVariables, via string) {
+		names := []string{}
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			values = append(values, interpolator.VarValue{Name: name, Value: vars[name], Via: via, Raw: raw})
+		}
+	}
+	for i := len(f.VarKVs) - 1; i >= 0; i-- {
+		add(boshtpl.StaticVariables{f.VarKVs[i].Name: f.VarKVs[i].Value}, interpolator.ViaVars)
+	}
+	for i := len(f.VarFiles) - 1; i >= 0; i-- {
+		add(f.VarFiles[i].Vars, interpolator.ViaVarFiles)
+	}
+	for i := len(f.VarsFiles) - 1; i >= 0; i-- {
+		add(f.VarsFiles[i].Vars, interpolator.ViaVarsFiles)
+	}
+	for i := len(f.VarsEnvs) - 1; i >= 0; i-- {
+		add(f.VarsEnvs[i].Vars, interpolator.ViaVarsEnv)
+	}
+	return values
 }
 
 func (i *boshInterpolator) ParsePassthroughVars(args []string) (*library.ScenarioNode, []string, error) {
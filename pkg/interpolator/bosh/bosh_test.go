@@ -1,13 +1,20 @@
 package bosh
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/interpolator"
 	"github.com/cjnosal/manifer/v2/pkg/library"
 	"github.com/cjnosal/manifer/v2/test"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
 )
 
 func TestInterpolate(t *testing.T) {
@@ -148,3 +155,239 @@ func TestParsePassthroughVars(t *testing.T) {
 		}
 	})
 }
+
+func TestReferenced(t *testing.T) {
+	t.Run("placeholders", func(t *testing.T) {
+		template := &file.TaggedBytes{Bytes: []byte("foo: ((bar))\nbizz: ((!bazz)) and ((bar))\n((key)): ((creds.password))\n")}
+		names := NewBoshInterpolator().Referenced(template)
+		expectedNames := []string{"bar", "bazz", "creds.password", "key"}
+		if !cmp.Equal(names, expectedNames) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNames, names)
+		}
+	})
+}
+
+func TestProvided(t *testing.T) {
+	t.Run("no params", func(t *testing.T) {
+		names, err := NewBoshInterpolator().Provided(library.InterpolatorParams{})
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedNames := []string{}
+		if err == nil && !cmp.Equal(names, expectedNames) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNames, names)
+		}
+	})
+
+	t.Run("vars, files, and args", func(t *testing.T) {
+		names, err := NewBoshInterpolator().Provided(library.InterpolatorParams{
+			Vars:      map[string]interface{}{"bar": "bizz"},
+			VarFiles:  map[string]string{"cert": "../../../test/data/v2/template.yml"},
+			VarsFiles: []string{"../../../test/data/v2/vars.yml"},
+			RawArgs:   []string{"-vfizz=buzz"},
+		})
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedNames := []string{"bar", "cert", "fizz", "foo", "value2"}
+		if err == nil && !cmp.Equal(names, expectedNames) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNames, names)
+		}
+	})
+
+	t.Run("invalid arg", func(t *testing.T) {
+		_, err := NewBoshInterpolator().Provided(library.InterpolatorParams{RawArgs: []string{"--invalid"}})
+		expectedError := errors.New("unknown flag `invalid'\n  while trying to parse vars")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
+
+func TestValues(t *testing.T) {
+	t.Run("highest precedence first", func(t *testing.T) {
+		values, err := NewBoshInterpolator().Values(library.InterpolatorParams{
+			Vars:      map[string]interface{}{"foo": "fromvars", "bar": "bizz"},
+			VarsFiles: []string{"../../../test/data/v2/vars.yml"},
+			RawArgs:   []string{"-vfoo=first", "-vfoo=second"},
+		})
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedValues := []interpolator.VarValue{
+			{Name: "foo", Value: "second", Via: interpolator.ViaVars, Raw: true},
+			{Name: "foo", Value: "first", Via: interpolator.ViaVars, Raw: true},
+			{Name: "foo", Value: "fromvars", Via: interpolator.ViaVars},
+			{Name: "bar", Value: "bizz", Via: interpolator.ViaVars},
+			{Name: "bar", Value: "fromvarsfile", Via: interpolator.ViaVarsFiles},
+			{Name: "foo", Value: "yay", Via: interpolator.ViaVarsFiles},
+			{Name: "value2", Value: "catchall", Via: interpolator.ViaVarsFiles},
+		}
+		if err == nil && !cmp.Equal(values, expectedValues) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedValues, values)
+		}
+	})
+}
+
+func TestStored(t *testing.T) {
+	t.Run("no vars store", func(t *testing.T) {
+		values, err := NewBoshInterpolator().Stored(library.InterpolatorParams{
+			Vars: map[string]interface{}{"foo": "bar"},
+		})
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		if err == nil && len(values) != 0 {
+			t.Errorf("Expected no values, got %v", values)
+		}
+	})
+
+	t.Run("vars store", func(t *testing.T) {
+		values, err := NewBoshInterpolator().Stored(library.InterpolatorParams{
+			VarsStore: "../../../test/data/v2/vars.yml",
+		})
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedValues := []interpolator.VarValue{
+			{Name: "bar", Value: "fromvarsfile", Via: interpolator.ViaVarsStore},
+			{Name: "foo", Value: "yay", Via: interpolator.ViaVarsStore},
+			{Name: "value2", Value: "catchall", Via: interpolator.ViaVarsStore},
+		}
+		if err == nil && !cmp.Equal(values, expectedValues) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedValues, values)
+		}
+	})
+}
+
+func TestVarsStoreSeed(t *testing.T) {
+	template := &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(`password: ((admin_password))
+cert: ((server_cert.certificate))
+ca: ((server_cert.ca))
+variables:
+- name: admin_password
+  type: password
+- name: root_ca
+  type: certificate
+  options:
+    is_ca: true
+    common_name: root
+- name: server_cert
+  type: certificate
+  options:
+    ca: root_ca
+    common_name: server
+`)}
+
+	interpolate := func(t *testing.T, subject interpolator.Interpolator, seed string) map[string]interface{} {
+		bytes, err := subject.Interpolate(template, library.InterpolatorParams{
+			VarsStore:     "does/not/exist.yml",
+			VarsStoreSeed: seed,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		values := map[string]interface{}{}
+		err = yaml.Unmarshal(bytes, &values)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		return values
+	}
+
+	t.Run("same seed", func(t *testing.T) {
+		first := interpolate(t, NewBoshInterpolator(), "seed1")
+		second := interpolate(t, NewBoshInterpolator(), "seed1")
+
+		if !cmp.Equal(first, second) {
+			t.Errorf("Expected identical values:\n%s", cmp.Diff(first, second))
+		}
+		password := first["password"].(string)
+		if len(password) != 20 {
+			t.Errorf("Expected 20 character password, got %s", password)
+		}
+		if _, err := os.Stat("does"); !os.IsNotExist(err) {
+			t.Errorf("Expected vars store not to be written")
+		}
+	})
+
+	t.Run("different seed", func(t *testing.T) {
+		first := interpolate(t, NewBoshInterpolator(), "seed1")
+		second := interpolate(t, NewBoshInterpolator(), "seed2")
+
+		if first["password"] == second["password"] || first["cert"] == second["cert"] {
+			t.Errorf("Expected different values for different seeds")
+		}
+	})
+
+	t.Run("signed by ca", func(t *testing.T) {
+		values := interpolate(t, NewBoshInterpolator(), "seed1")
+
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM([]byte(values["ca"].(string))) {
+			t.Fatalf("Expected PEM encoded CA")
+		}
+		block, _ := pem.Decode([]byte(values["cert"].(string)))
+		if block == nil {
+			t.Fatalf("Expected PEM encoded certificate")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		_, err = cert.Verify(x509.VerifyOptions{Roots: roots, CurrentTime: seededEpoch.Add(time.Hour)})
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		if cert.Subject.CommonName != "server" {
+			t.Errorf("Expected common name server, got %s", cert.Subject.CommonName)
+		}
+	})
+
+	t.Run("valid keys", func(t *testing.T) {
+		generator := &seededGenerator{seed: "seed1"}
+		for _, keyType := range []string{"rsa", "ssh", "certificate"} {
+			options := map[interface{}]interface{}{"is_ca": true, "common_name": "key"}
+			value, err := generator.Generate(boshtpl.VariableDefinition{Name: "key", Type: keyType, Options: options})
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			block, _ := pem.Decode([]byte(value.(map[interface{}]interface{})["private_key"].(string)))
+			if block == nil {
+				t.Fatalf("Expected PEM encoded %s private key", keyType)
+			}
+			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			err = key.Validate()
+			if err != nil {
+				t.Errorf("Expected valid %s key: %v", keyType, err)
+			}
+		}
+	})
+
+	t.Run("stored", func(t *testing.T) {
+		subject := NewBoshInterpolator()
+		values := interpolate(t, subject, "seed1")
+
+		stored, err := subject.Stored(library.InterpolatorParams{VarsStoreSeed: "seed1"})
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		names := []string{}
+		for _, v := range stored {
+			names = append(names, v.Name)
+			if v.Via != interpolator.ViaVarsStore {
+				t.Errorf("Expected %s via vars_store, got %s", v.Name, v.Via)
+			}
+		}
+		expectedNames := []string{"admin_password", "root_ca", "server_cert"}
+		if !cmp.Equal(names, expectedNames) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNames, names)
+		}
+		if stored[0].Value != values["password"] {
+			t.Errorf("Expected stored password %v, got %v", values["password"], stored[0].Value)
+		}
+	})
+}
@@ -0,0 +1,302 @@
+package bosh
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	boshopts "github.com/cloudfoundry/bosh-cli/cmd/opts"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// generated certificates are valid from this date so their contents are reproducible
+var seededEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var passwordRunes = []rune("abcdefghijklmnopqrstuvwxyz0123456789")
+
+// Generates the same values as bosh's config-server types for a seed and variable name.
+// Signing CAs are loaded from vars.
+type seededGenerator struct {
+	seed string
+	vars boshtpl.Variables
+}
+
+type generatorParams struct {
+	Length           int      `yaml:"length"`
+	CommonName       string   `yaml:"common_name"`
+	Organization     string   `yaml:"organization"`
+	AlternativeNames []string `yaml:"alternative_names"`
+	IsCA             bool     `yaml:"is_ca"`
+	CAName           string   `yaml:"ca"`
+	ExtKeyUsage      []string `yaml:"extended_key_usage"`
+	Duration         int64    `yaml:"duration"`
+}
+
+func (g *seededGenerator) Generate(def boshtpl.VariableDefinition) (interface{}, error) {
+	params := generatorParams{}
+	if def.Options != nil {
+		bytes, err := yaml.Marshal(def.Options)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while marshaling options of %s", err, def.Name)
+		}
+		err = yaml.Unmarshal(bytes, &params)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while parsing options of %s", err, def.Name)
+		}
+	}
+	random := newSeededReader(g.seed, def.Name)
+
+	switch def.Type {
+	case "password":
+		return generatePassword(random, params.Length)
+	case "rsa":
+		return generateRSA(random)
+	case "ssh":
+		return generateSSH(random)
+	case "certificate":
+		return g.generateCertificate(random, params)
+	default:
+		return nil, fmt.Errorf("Unsupported value type: %s", def.Type)
+	}
+}
+
+func generatePassword(random io.Reader, length int) (interface{}, error) {
+	if length < 0 {
+		return nil, fmt.Errorf("Failed to generate password, 'length' param cannot be negative")
+	}
+	if length == 0 {
+		length = 20
+	}
+	bytes := make([]byte, length)
+	_, err := io.ReadFull(random, bytes)
+	if err != nil {
+		return nil, err
+	}
+	password := make([]rune, length)
+	for i, b := range bytes {
+		password[i] = passwordRunes[int(b)%len(passwordRunes)]
+	}
+	return string(password), nil
+}
+
+func generateRSA(random io.Reader) (interface{}, error) {
+	key, err := seededRSAKey(random, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while generating RSA key pair", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[interface{}]interface{}{
+		"private_key": encodePEM(x509.MarshalPKCS1PrivateKey(key), "RSA PRIVATE KEY"),
+		"public_key":  encodePEM(publicKey, "PUBLIC KEY"),
+	}, nil
+}
+
+func generateSSH(random io.Reader) (interface{}, error) {
+	key, err := seededRSAKey(random, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while generating RSA key pair", err)
+	}
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := []string{}
+	for _, b := range md5.Sum(publicKey.Marshal()) {
+		fingerprint = append(fingerprint, fmt.Sprintf("%02x", b))
+	}
+	return map[interface{}]interface{}{
+		"private_key":            encodePEM(x509.MarshalPKCS1PrivateKey(key), "RSA PRIVATE KEY"),
+		"public_key":             string(ssh.MarshalAuthorizedKey(publicKey)),
+		"public_key_fingerprint": strings.Join(fingerprint, ":"),
+	}, nil
+}
+
+func (g *seededGenerator) generateCertificate(random io.Reader, params generatorParams) (interface{}, error) {
+	key, err := seededRSAKey(random, 3072)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while generating certificate key", err)
+	}
+
+	serial := make([]byte, 16)
+	_, err = io.ReadFull(random, serial)
+	if err != nil {
+		return nil, err
+	}
+	duration := params.Duration
+	if duration <= 0 {
+		duration = 365
+	}
+	organization := params.Organization
+	if organization == "" {
+		organization = "Cloud Foundry"
+	}
+	keyHash := sha1.Sum(key.N.Bytes())
+	template := &x509.Certificate{
+		SerialNumber: new(big.Int).SetBytes(serial),
+		Subject: pkix.Name{
+			Country:      []string{"USA"},
+			Organization: []string{organization},
+			CommonName:   params.CommonName,
+		},
+		NotBefore:             seededEpoch,
+		NotAfter:              seededEpoch.Add(time.Duration(duration*24) * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  params.IsCA,
+		SubjectKeyId:          keyHash[:],
+	}
+
+	signingCert := template
+	signingKey := key
+	if params.CAName != "" {
+		signingCert, signingKey, err = boshopts.NewVarsCertLoader(g.vars).LoadCerts(params.CAName)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while loading CA %s", err, params.CAName)
+		}
+	} else if !params.IsCA {
+		return nil, fmt.Errorf("Missing required CA name")
+	}
+	template.AuthorityKeyId = signingCert.SubjectKeyId
+
+	if params.IsCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	} else {
+		template.KeyUsage = x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature
+		for _, usage := range params.ExtKeyUsage {
+			switch usage {
+			case "client_auth":
+				template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+			case "server_auth":
+				template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+			default:
+				return nil, fmt.Errorf("Unsupported extended key usage value: %s", usage)
+			}
+		}
+		if len(template.ExtKeyUsage) == 0 {
+			template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		}
+		for _, name := range params.AlternativeNames {
+			if ip := net.ParseIP(name); ip != nil {
+				template.IPAddresses = append(template.IPAddresses, ip)
+			} else {
+				template.DNSNames = append(template.DNSNames, name)
+			}
+		}
+	}
+
+	certificate, err := x509.CreateCertificate(random, template, signingCert, &key.PublicKey, signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while signing certificate", err)
+	}
+	ca := certificate
+	if params.CAName != "" {
+		ca = signingCert.Raw
+	}
+	return map[interface{}]interface{}{
+		"ca":          encodePEM(ca, "CERTIFICATE"),
+		"certificate": encodePEM(certificate, "CERTIFICATE"),
+		"private_key": encodePEM(x509.MarshalPKCS1PrivateKey(key), "RSA PRIVATE KEY"),
+	}, nil
+}
+
+// rsa.GenerateKey can not be used with the seeded reader: it randomly consumes an extra byte
+// (randutil.MaybeReadByte) so keys differ between runs, and since go 1.26 it ignores the reader.
+// Primes are searched for directly instead, and the key is checked with Validate before use.
+// Certificates are signed with PKCS #1 v1.5, which x509.CreateCertificate does deterministically.
+func seededRSAKey(random io.Reader, bits int) (*rsa.PrivateKey, error) {
+	e := big.NewInt(65537)
+	one := big.NewInt(1)
+	for {
+		p, err := seededPrime(random, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := seededPrime(random, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: new(big.Int).Mul(p, q), E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		err = key.Validate()
+		if err != nil {
+			return nil, err
+		}
+		key.Precompute()
+		return key, nil
+	}
+}
+
+// the top two bits are set so the product of two primes has the full key length
+func seededPrime(random io.Reader, bits int) (*big.Int, error) {
+	bytes := make([]byte, bits/8)
+	_, err := io.ReadFull(random, bytes)
+	if err != nil {
+		return nil, err
+	}
+	bytes[0] |= 0xc0
+	bytes[len(bytes)-1] |= 1
+	candidate := new(big.Int).SetBytes(bytes)
+	two := big.NewInt(2)
+	for !candidate.ProbablyPrime(20) {
+		candidate.Add(candidate, two)
+	}
+	return candidate, nil
+}
+
+func encodePEM(bytes []byte, blockType string) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: bytes}))
+}
+
+// HMAC-SHA256 of the seed and variable name in counter mode
+type seededReader struct {
+	key     []byte
+	name    string
+	counter uint64
+	buffer  []byte
+}
+
+func newSeededReader(seed string, name string) io.Reader {
+	return &seededReader{key: []byte(seed), name: name}
+}
+
+func (r *seededReader) Read(p []byte) (int, error) {
+	for len(r.buffer) < len(p) {
+		h := hmac.New(sha256.New, r.key)
+		h.Write([]byte(r.name))
+		counter := make([]byte, 8)
+		binary.BigEndian.PutUint64(counter, r.counter)
+		h.Write(counter)
+		r.buffer = append(r.buffer, h.Sum(nil)...)
+		r.counter++
+	}
+	n := copy(p, r.buffer)
+	r.buffer = r.buffer[n:]
+	return n, nil
+}
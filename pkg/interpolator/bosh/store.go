@@ -0,0 +1,49 @@
+package bosh
+
+import (
+	"fmt"
+	"sort"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+// Replaces a vars store file with values generated from a seed.
+// Nothing is read from or written to the vars store path.
+type memoryStore struct {
+	generator *seededGenerator
+	values    boshtpl.StaticVariables
+}
+
+var _ boshtpl.Variables = &memoryStore{}
+
+func newMemoryStore(seed string) *memoryStore {
+	return &memoryStore{
+		generator: &seededGenerator{seed: seed},
+		values:    boshtpl.StaticVariables{},
+	}
+}
+
+func (s *memoryStore) Get(def boshtpl.VariableDefinition) (interface{}, bool, error) {
+	value, found := s.values[def.Name]
+	if found {
+		return value, true, nil
+	}
+	if len(def.Type) == 0 {
+		return nil, false, nil
+	}
+	value, err := s.generator.Generate(def)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w\n  while generating variable '%s'", err, def.Name)
+	}
+	s.values[def.Name] = value
+	return value, true, nil
+}
+
+func (s *memoryStore) List() ([]boshtpl.VariableDefinition, error) {
+	defs, err := s.values.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(defs, func(a, b int) bool { return defs[a].Name < defs[b].Name })
+	return defs, nil
+}
@@ -8,4 +8,16 @@ import (
 type Interpolator interface {
 	Interpolate(templateBytes *file.TaggedBytes, params library.InterpolatorParams) ([]byte, error)
 	ParsePassthroughVars(args []string) (*library.ScenarioNode, []string, error)
+
+	// names of ((placeholders)) in the template
+	Referenced(templateBytes *file.TaggedBytes) []string
+
+	// names of variables supplied by the params (values generated by a vars store are not included)
+	Provided(params library.InterpolatorParams) ([]string, error)
+
+	// values supplied by the params, highest precedence first (values generated by a vars store are not included)
+	Values(params library.InterpolatorParams) ([]VarValue, error)
+
+	// values saved in the vars store of the params
+	Stored(params library.InterpolatorParams) ([]VarValue, error)
 }
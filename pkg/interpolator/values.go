@@ -0,0 +1,54 @@
+package interpolator
+
+// kinds of interpolator params
+const (
+	ViaVars      = "vars"
+	ViaVarFiles  = "var_files"
+	ViaVarsFiles = "vars_files"
+	ViaVarsEnv   = "vars_env"
+	ViaRawArgs   = "raw_args"
+	ViaVarsStore = "vars_store"
+)
+
+// highest precedence first
+var viaPrecedence = []string{ViaVars, ViaVarFiles, ViaVarsFiles, ViaVarsEnv}
+
+type VarValue struct {
+	Name  string
+	Value interface{}
+	Via   string // vars, var_files, vars_files, vars_env, or vars_store
+	Raw   bool   // parsed from raw_args
+}
+
+// ViaRawArgs for values parsed from raw args
+func (v VarValue) Source() string {
+	if v.Raw {
+		return ViaRawArgs
+	}
+	return v.Via
+}
+
+type RankedValue struct {
+	Scope int // index of the scope supplying the value
+	VarValue
+}
+
+// Orders values supplied by merged params from highest to lowest precedence.
+// Scopes are ordered as their params would be merged, highest precedence first, and each
+// scope's values as returned by Interpolator.Values.
+// Raw args of every scope are applied after the other params of the same kind.
+func Rank(scopes [][]VarValue) []RankedValue {
+	ranked := []RankedValue{}
+	for _, via := range viaPrecedence {
+		for _, raw := range []bool{true, false} {
+			for i, values := range scopes {
+				for _, v := range values {
+					if v.Via == via && v.Raw == raw {
+						ranked = append(ranked, RankedValue{Scope: i, VarValue: v})
+					}
+				}
+			}
+		}
+	}
+	return ranked
+}
@@ -2,19 +2,23 @@ package library
 
 type Type string
 
+// built in types, others can be registered with the processor factory
 const (
-	OpsFile Type = "opsfile"
-	Yq      Type = "yq"
-)
-
-var (
-	Types []Type = []Type{OpsFile, Yq} // treat as const
+	OpsFile    Type = "opsfile"
+	Yq         Type = "yq"
+	JsonPatch  Type = "jsonpatch"
+	MergePatch Type = "mergepatch"
+	Yq4        Type = "yq4"
+	Jq         Type = "jq"
+	GoTemplate Type = "gotemplate"
+	Exec       Type = "exec"
 )
 
 type Library struct {
 	Libraries []LibraryRef `yaml:"libraries,omitempty"`
 	Type      Type         `yaml:"type,omitempty"`
 	Scenarios []Scenario   `yaml:"scenarios,omitempty"`
+	Tests     []Test       `yaml:"tests,omitempty"`
 }
 
 type LibraryRef struct {
@@ -42,6 +46,21 @@ type Snippet struct {
 	Processor    Processor          `yaml:"processor,omitempty"`
 }
 
+// composes a template with scenarios of the library and checks the output
+type Test struct {
+	Name         string
+	Description  string             `yaml:"description,omitempty"`
+	Template     string             `yaml:"template"`
+	Scenarios    []string           `yaml:"scenarios,omitempty"`
+	Interpolator InterpolatorParams `yaml:"interpolator,omitempty"` // global vars
+
+	// path to a yml file the output must match
+	Expected string `yaml:"expected,omitempty"`
+
+	// '<path> <operator> <value>' where operator is one of ==, !=, <, <=, >, >=
+	Assertions []string `yaml:"assertions,omitempty"`
+}
+
 type Processor struct {
 	Type    Type                   `yaml:"type,omitempty"`
 	Options map[string]interface{} `yaml:"options,omitempty"`
@@ -58,11 +77,15 @@ type InterpolatorParams struct {
 	VarsEnv   []string               `yaml:"vars_env,omitempty"`
 	VarsStore string                 `yaml:"vars_store,omitempty"`
 	RawArgs   []string               `yaml:"raw_args,omitempty"`
+
+	// generate vars store values from this seed and keep them in memory instead of the vars_store file
+	VarsStoreSeed string `yaml:"vars_store_seed,omitempty"`
 }
 
 func (i InterpolatorParams) IsZero() bool {
 	return len(i.Vars) == 0 && len(i.VarFiles) == 0 && len(i.VarsFiles) == 0 &&
-		len(i.VarsEnv) == 0 && len(i.VarsStore) == 0 && len(i.RawArgs) == 0
+		len(i.VarsEnv) == 0 && len(i.VarsStore) == 0 && len(i.RawArgs) == 0 &&
+		len(i.VarsStoreSeed) == 0
 }
 
 func (ip InterpolatorParams) Merge(other InterpolatorParams) InterpolatorParams {
@@ -78,5 +101,8 @@ func (ip InterpolatorParams) Merge(other InterpolatorParams) InterpolatorParams
 		ip.VarsStore = other.VarsStore
 	}
 	ip.RawArgs = append(ip.RawArgs, other.RawArgs...)
+	if other.VarsStoreSeed != "" {
+		ip.VarsStoreSeed = other.VarsStoreSeed
+	}
 	return ip
 }
@@ -16,6 +16,13 @@ type ScenarioNodes []*ScenarioNode
 type Loader struct {
 	Yaml yaml.YamlAccess
 	File file.FileAccess
+
+	// checks snippet processor options while loading, if set
+	Options OptionsValidator
+}
+
+type OptionsValidator interface {
+	ValidateOptions(t Type, options map[string]interface{}) error
 }
 
 type LoadedLibrary struct {
@@ -175,6 +182,14 @@ func (l *Loader) loadLib(path string, loaded *LoadedLibrary, top bool) error {
 
 	for i, scenario := range lib.Scenarios {
 		for j, snippet := range scenario.Snippets {
+			err = l.validateOptions(lib, snippet)
+			if err != nil {
+				return fmt.Errorf("%w\n  while validating snippet %d of scenario %s in %s", err, j+1, scenario.Name, path)
+			}
+			err = l.resolveCommand(lib, snippet, path)
+			if err != nil {
+				return fmt.Errorf("%w\n  while resolving command of snippet %d of scenario %s in %s", err, j+1, scenario.Name, path)
+			}
 			if snippet.Path != "" {
 				absSnippetPath, err := l.File.ResolveRelativeTo(snippet.Path, path)
 				if err != nil {
@@ -185,6 +200,21 @@ func (l *Loader) loadLib(path string, loaded *LoadedLibrary, top bool) error {
 		}
 	}
 
+	for i, test := range lib.Tests {
+		absTemplatePath, err := l.File.ResolveRelativeTo(test.Template, path)
+		if err != nil {
+			return fmt.Errorf("%w\n  while resolving template path %s from %s", err, test.Template, path)
+		}
+		lib.Tests[i].Template = absTemplatePath
+		if test.Expected != "" {
+			absExpectedPath, err := l.File.ResolveRelativeTo(test.Expected, path)
+			if err != nil {
+				return fmt.Errorf("%w\n  while resolving expected output path %s from %s", err, test.Expected, path)
+			}
+			lib.Tests[i].Expected = absExpectedPath
+		}
+	}
+
 	for i, libref := range lib.Libraries {
 		absLibPath, err := l.File.ResolveRelativeTo(libref.Path, path)
 		if err != nil {
@@ -205,6 +235,34 @@ func (l *Loader) loadLib(path string, loaded *LoadedLibrary, top bool) error {
 	return nil
 }
 
+func (l *Loader) validateOptions(lib *Library, snippet Snippet) error {
+	t := snippet.Processor.Type
+	if t == "" {
+		t = lib.Type
+	}
+	if l.Options == nil || t == "" {
+		return nil
+	}
+	return l.Options.ValidateOptions(t, snippet.Processor.Options)
+}
+
+// exec commands with a separator are relative to the library, others are looked up in PATH
+func (l *Loader) resolveCommand(lib *Library, snippet Snippet, path string) error {
+	if snippet.Processor.Type != Exec && (snippet.Processor.Type != "" || lib.Type != Exec) {
+		return nil
+	}
+	command, ok := snippet.Processor.Options["command"].(string)
+	if !ok || !strings.ContainsRune(command, '/') {
+		return nil
+	}
+	absCommand, err := l.File.ResolveRelativeTo(command, path)
+	if err != nil {
+		return err
+	}
+	snippet.Processor.Options["command"] = absCommand
+	return nil
+}
+
 func SplitName(scenarioName string) []string {
 	return strings.Split(scenarioName, ".")
 }
@@ -78,6 +78,77 @@ func TestLoad(t *testing.T) {
 		}
 	})
 
+	t.Run("library tests", func(t *testing.T) {
+		lib1 := Library{
+			Type: OpsFile,
+			Tests: []Test{
+				{
+					Name:       "t",
+					Template:   "./template.yml",
+					Expected:   "./expected.yml",
+					Assertions: []string{"/foo == bar"},
+				},
+				{
+					Name:     "no expected output",
+					Template: "../template.yml",
+				},
+			},
+		}
+		loadedlib1 := &Library{
+			Type: OpsFile,
+			Tests: []Test{
+				{
+					Name:       "t",
+					Template:   "/wd/lib/template.yml",
+					Expected:   "/wd/lib/expected.yml",
+					Assertions: []string{"/foo == bar"},
+				},
+				{
+					Name:     "no expected output",
+					Template: "/wd/template.yml",
+				},
+			},
+		}
+		expectedLoadedLibs := LoadedLibrary{
+			TopLibraries: []*Library{
+				loadedlib1,
+			},
+			Libraries: map[string]*Library{
+				"/wd/lib/library.yml": loadedlib1,
+			},
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		subject := &Loader{
+			File: mockFile,
+			Yaml: mockYaml,
+		}
+
+		mockFile.EXPECT().GetWorkingDirectory().Times(1).Return("/wd", nil)
+		mockFile.EXPECT().ResolveRelativeTo("./lib/library.yml", "/wd").Times(1).Return("/wd/lib/library.yml", nil)
+		mockFile.EXPECT().Read("/wd/lib/library.yml").Times(1).Return([]byte("bytes"), nil)
+		mockYaml.EXPECT().Unmarshal([]byte("bytes"), &Library{}).Times(1).Return(nil).Do(func(bytes []byte, lib *Library) {
+			*lib = lib1
+		})
+		mockFile.EXPECT().ResolveRelativeTo("./template.yml", "/wd/lib/library.yml").Times(1).Return("/wd/lib/template.yml", nil)
+		mockFile.EXPECT().ResolveRelativeTo("./expected.yml", "/wd/lib/library.yml").Times(1).Return("/wd/lib/expected.yml", nil)
+		mockFile.EXPECT().ResolveRelativeTo("../template.yml", "/wd/lib/library.yml").Times(1).Return("/wd/template.yml", nil)
+
+		loadedLibs, err := subject.Load([]string{"./lib/library.yml"})
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n", err)
+		}
+
+		if !cmp.Equal(expectedLoadedLibs, *loadedLibs) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedLoadedLibs, *loadedLibs)
+		}
+	})
+
 	t.Run("two library", func(t *testing.T) {
 		lib1 := Library{
 			Type: OpsFile,
@@ -387,6 +458,158 @@ func TestLoad(t *testing.T) {
 		}
 	})
 
+	t.Run("validate options", func(t *testing.T) {
+		lib1 := Library{
+			Type: OpsFile,
+			Scenarios: []Scenario{
+				{
+					Name: "s",
+					Snippets: []Snippet{
+						{
+							Processor: Processor{
+								Options: map[string]interface{}{"path": "/foo"},
+							},
+						},
+						{
+							Path: "./snippet.yml",
+							Processor: Processor{
+								Type:    Yq,
+								Options: map[string]interface{}{"command": "merge"},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		mockOptions := NewMockOptionsValidator(ctrl)
+		subject := &Loader{
+			File:    mockFile,
+			Yaml:    mockYaml,
+			Options: mockOptions,
+		}
+
+		mockFile.EXPECT().GetWorkingDirectory().Times(1).Return("/wd", nil)
+		mockFile.EXPECT().ResolveRelativeTo("./lib/library.yml", "/wd").Times(1).Return("/wd/lib/library.yml", nil)
+		mockFile.EXPECT().Read("/wd/lib/library.yml").Times(1).Return([]byte("bytes"), nil)
+		mockYaml.EXPECT().Unmarshal([]byte("bytes"), &Library{}).Times(1).Return(nil).Do(func(bytes []byte, lib *Library) {
+			*lib = lib1
+		})
+		mockOptions.EXPECT().ValidateOptions(OpsFile, map[string]interface{}{"path": "/foo"}).Times(1).Return(nil)
+		mockOptions.EXPECT().ValidateOptions(Yq, map[string]interface{}{"command": "merge"}).Times(1).Return(nil)
+		mockFile.EXPECT().ResolveRelativeTo("./snippet.yml", "/wd/lib/library.yml").Times(1).Return("/wd/lib/snippet.yml", nil)
+
+		_, err := subject.Load([]string{"./lib/library.yml"})
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n", err)
+		}
+	})
+
+	t.Run("exec commands", func(t *testing.T) {
+		lib1 := Library{
+			Type: Exec,
+			Scenarios: []Scenario{
+				{
+					Name: "s",
+					Snippets: []Snippet{
+						{
+							Processor: Processor{
+								Options: map[string]interface{}{"command": "./scripts/transform.sh"},
+							},
+						},
+						{
+							Processor: Processor{
+								Options: map[string]interface{}{"command": "jq"},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		subject := &Loader{
+			File: mockFile,
+			Yaml: mockYaml,
+		}
+
+		mockFile.EXPECT().GetWorkingDirectory().Times(1).Return("/wd", nil)
+		mockFile.EXPECT().ResolveRelativeTo("./lib/library.yml", "/wd").Times(1).Return("/wd/lib/library.yml", nil)
+		mockFile.EXPECT().Read("/wd/lib/library.yml").Times(1).Return([]byte("bytes"), nil)
+		mockYaml.EXPECT().Unmarshal([]byte("bytes"), &Library{}).Times(1).Return(nil).Do(func(bytes []byte, lib *Library) {
+			*lib = lib1
+		})
+		mockFile.EXPECT().ResolveRelativeTo("./scripts/transform.sh", "/wd/lib/library.yml").Times(1).Return("/wd/lib/scripts/transform.sh", nil)
+
+		loaded, err := subject.Load([]string{"./lib/library.yml"})
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n", err)
+		}
+		snippets := loaded.TopLibraries[0].Scenarios[0].Snippets
+		if snippets[0].Processor.Options["command"] != "/wd/lib/scripts/transform.sh" || snippets[1].Processor.Options["command"] != "jq" {
+			t.Errorf("Unexpected commands %v %v", snippets[0].Processor.Options["command"], snippets[1].Processor.Options["command"])
+		}
+	})
+
+	t.Run("validate options error", func(t *testing.T) {
+		lib1 := Library{
+			Type: OpsFile,
+			Scenarios: []Scenario{
+				{
+					Name: "s",
+					Snippets: []Snippet{
+						{
+							Path: "./snippet.yml",
+							Processor: Processor{
+								Options: map[string]interface{}{"paht": "/foo"},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		mockOptions := NewMockOptionsValidator(ctrl)
+		subject := &Loader{
+			File:    mockFile,
+			Yaml:    mockYaml,
+			Options: mockOptions,
+		}
+
+		mockFile.EXPECT().GetWorkingDirectory().Times(1).Return("/wd", nil)
+		mockFile.EXPECT().ResolveRelativeTo("./lib/library.yml", "/wd").Times(1).Return("/wd/lib/library.yml", nil)
+		mockFile.EXPECT().Read("/wd/lib/library.yml").Times(1).Return([]byte("bytes"), nil)
+		mockYaml.EXPECT().Unmarshal([]byte("bytes"), &Library{}).Times(1).Return(nil).Do(func(bytes []byte, lib *Library) {
+			*lib = lib1
+		})
+		mockOptions.EXPECT().ValidateOptions(OpsFile, map[string]interface{}{"paht": "/foo"}).Times(1).Return(errors.New("test"))
+
+		_, err := subject.Load([]string{"./lib/library.yml"})
+
+		expectedError := errors.New(`test
+  while validating snippet 1 of scenario s in /wd/lib/library.yml
+  while loading library from path ./lib/library.yml`)
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+
 	t.Run("unmarshal library error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
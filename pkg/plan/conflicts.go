@@ -0,0 +1,147 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/processor/factory"
+)
+
+type ConflictDetector interface {
+	Conflicts(plan *Plan) ([]Conflict, error)
+}
+
+// compares the paths each snippet may modify without executing the plan
+type StaticConflictDetector struct {
+	ProcessorFactory factory.ProcessorFactory
+}
+
+// two steps from different scenarios modifying overlapping paths - the later step wins
+type Conflict struct {
+	Overridden ConflictStep `yaml:"overridden"`
+	Winner     ConflictStep `yaml:"winner"`
+	Possible   bool         `yaml:"possible,omitempty"` // the paths only overlap if unresolved variables match, listed apart from definite overlaps
+}
+
+type ConflictStep struct {
+	Step      int      `yaml:"step"` // 1-based plan index
+	Snippet   string   `yaml:"snippet"`
+	Scenarios []string `yaml:"scenarios,omitempty"` // innermost first
+	Paths     []string `yaml:"paths"`               // overlapping paths modified by this step
+}
+
+func (d *StaticConflictDetector) Conflicts(plan *Plan) ([]Conflict, error) {
+	stepPaths := make([][]string, len(plan.Steps))
+	for i, step := range plan.Steps {
+		paths, err := d.paths(step, plan)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while analyzing step %d", err, i+1)
+		}
+		stepPaths[i] = paths
+	}
+
+	conflicts := []Conflict{}
+	for i, earlier := range plan.Steps {
+		for j := i + 1; j < len(plan.Steps); j++ {
+			later := plan.Steps[j]
+			if sameScenario(earlier, later) {
+				continue
+			}
+			definite := overlap{}
+			possible := overlap{}
+			for _, a := range stepPaths[i] {
+				for _, b := range stepPaths[j] {
+					if !processor.PointersOverlap(a, b) {
+						continue
+					}
+					if unresolved(a) || unresolved(b) {
+						possible.add(a, b)
+					} else {
+						definite.add(a, b)
+					}
+				}
+			}
+			if len(definite.overridden) > 0 {
+				conflicts = append(conflicts, conflict(i+1, earlier, j+1, later, definite, false))
+			}
+			if len(possible.overridden) > 0 {
+				conflicts = append(conflicts, conflict(i+1, earlier, j+1, later, possible, true))
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// paths modified by a step's snippet, with variables from the step and globals substituted.
+// Unresolved variables are left in place and match any token, as in where.
+func (d *StaticConflictDetector) paths(step *Step, plan *Plan) ([]string, error) {
+	if step.Snippet == "" {
+		return []string{}, nil
+	}
+	p, err := d.ProcessorFactory.Create(step.Processor.Type)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while initializing processor of type %s", err, step.Processor.Type)
+	}
+	hint, err := p.ValidateSnippet(step.Snippet)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while parsing snippet %s", err, step.Snippet)
+	}
+	vars := step.FlattenParams().Vars
+	for k, v := range plan.Global.Vars {
+		vars[k] = v
+	}
+	paths := []string{}
+	for _, path := range hint.Paths {
+		paths = append(paths, processor.SubstituteVars(path, vars))
+	}
+	return paths, nil
+}
+
+func unresolved(path string) bool {
+	return strings.Contains(path, "((")
+}
+
+// scenario names are only unique within a library
+func sameScenario(a *Step, b *Step) bool {
+	aChain := a.ScenarioChain()
+	bChain := b.ScenarioChain()
+	return len(aChain) > 0 && len(bChain) > 0 && aChain[0] == bChain[0] && a.Library == b.Library
+}
+
+// overlapping paths of an earlier and a later step
+type overlap struct {
+	overridden []string
+	winner     []string
+}
+
+func (o *overlap) add(overridden string, winner string) {
+	o.overridden = insert(o.overridden, overridden)
+	o.winner = insert(o.winner, winner)
+}
+
+func conflict(i int, earlier *Step, j int, later *Step, o overlap, possible bool) Conflict {
+	return Conflict{
+		Overridden: conflictStep(i, earlier, o.overridden),
+		Winner:     conflictStep(j, later, o.winner),
+		Possible:   possible,
+	}
+}
+
+func conflictStep(index int, step *Step, paths []string) ConflictStep {
+	return ConflictStep{
+		Step:      index,
+		Snippet:   step.Snippet,
+		Scenarios: step.ScenarioChain(),
+		Paths:     paths,
+	}
+}
+
+func insert(paths []string, path string) []string {
+	for _, p := range paths {
+		if p == path {
+			return paths
+		}
+	}
+	return append(paths, path)
+}
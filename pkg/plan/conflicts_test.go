@@ -0,0 +1,195 @@
+package plan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/processor/factory"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConflicts(t *testing.T) {
+
+	step := func(snippet string, vars map[string]interface{}, scenarios ...string) *Step {
+		params := []TaggedParams{
+			{
+				Tag:          "snippet",
+				Interpolator: library.InterpolatorParams{Vars: vars},
+			},
+		}
+		for _, s := range scenarios {
+			params = append(params, TaggedParams{Tag: s})
+		}
+		return &Step{
+			Snippet:   snippet,
+			Params:    params,
+			Processor: library.Processor{Type: library.OpsFile},
+		}
+	}
+
+	t.Run("overlapping paths in different scenarios", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
+		mockProcessor := processor.NewMockProcessor(ctrl)
+		subject := &StaticConflictDetector{
+			ProcessorFactory: mockProcessorFactory,
+		}
+
+		executionPlan := &Plan{
+			Global: library.InterpolatorParams{
+				Vars: map[string]interface{}{"job": "router"},
+			},
+			Steps: []*Step{
+				step("/a.yml", map[string]interface{}{"key": "foo"}, "first", "main"),
+				step("/a.yml", map[string]interface{}{"key": "bar"}, "first", "main"),
+				step("/b.yml", nil, "second", "main"),
+				step("/c.yml", nil, "third"),
+			},
+		}
+
+		mockProcessorFactory.EXPECT().Create(library.OpsFile).Times(4).Return(mockProcessor, nil)
+		mockProcessor.EXPECT().ValidateSnippet("/a.yml").Times(2).Return(processor.SnippetHint{
+			Valid: true,
+			Paths: []string{"/properties/((key))"},
+		}, nil)
+		mockProcessor.EXPECT().ValidateSnippet("/b.yml").Times(1).Return(processor.SnippetHint{
+			Valid: true,
+			Paths: []string{"/properties/foo/nested", "/jobs/name=((job))"},
+		}, nil)
+		mockProcessor.EXPECT().ValidateSnippet("/c.yml").Times(1).Return(processor.SnippetHint{
+			Valid: true,
+			Paths: []string{"/jobs/name=router/-", "/((unknown))"},
+		}, nil)
+
+		conflicts, err := subject.Conflicts(executionPlan)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expected := []Conflict{
+			{
+				Overridden: ConflictStep{Step: 1, Snippet: "/a.yml", Scenarios: []string{"first", "main"}, Paths: []string{"/properties/foo"}},
+				Winner:     ConflictStep{Step: 3, Snippet: "/b.yml", Scenarios: []string{"second", "main"}, Paths: []string{"/properties/foo/nested"}},
+			},
+			{
+				Overridden: ConflictStep{Step: 1, Snippet: "/a.yml", Scenarios: []string{"first", "main"}, Paths: []string{"/properties/foo"}},
+				Winner:     ConflictStep{Step: 4, Snippet: "/c.yml", Scenarios: []string{"third"}, Paths: []string{"/((unknown))"}},
+				Possible:   true,
+			},
+			{
+				Overridden: ConflictStep{Step: 2, Snippet: "/a.yml", Scenarios: []string{"first", "main"}, Paths: []string{"/properties/bar"}},
+				Winner:     ConflictStep{Step: 4, Snippet: "/c.yml", Scenarios: []string{"third"}, Paths: []string{"/((unknown))"}},
+				Possible:   true,
+			},
+			{
+				Overridden: ConflictStep{Step: 3, Snippet: "/b.yml", Scenarios: []string{"second", "main"}, Paths: []string{"/jobs/name=router"}},
+				Winner:     ConflictStep{Step: 4, Snippet: "/c.yml", Scenarios: []string{"third"}, Paths: []string{"/jobs/name=router/-"}},
+			},
+			{
+				Overridden: ConflictStep{Step: 3, Snippet: "/b.yml", Scenarios: []string{"second", "main"}, Paths: []string{"/properties/foo/nested", "/jobs/name=router"}},
+				Winner:     ConflictStep{Step: 4, Snippet: "/c.yml", Scenarios: []string{"third"}, Paths: []string{"/((unknown))"}},
+				Possible:   true,
+			},
+		}
+
+		if !cmp.Equal(expected, conflicts) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n", expected, conflicts, cmp.Diff(expected, conflicts))
+		}
+	})
+
+	t.Run("same scenario name in different libraries", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
+		mockProcessor := processor.NewMockProcessor(ctrl)
+		subject := &StaticConflictDetector{
+			ProcessorFactory: mockProcessorFactory,
+		}
+
+		first := step("/a.yml", nil, "base")
+		first.Library = "/lib/a.yml"
+		second := step("/b.yml", nil, "base")
+		second.Library = "/lib/b.yml"
+		third := step("/c.yml", nil, "base")
+		third.Library = "/lib/b.yml"
+
+		mockProcessorFactory.EXPECT().Create(library.OpsFile).Times(3).Return(mockProcessor, nil)
+		mockProcessor.EXPECT().ValidateSnippet(gomock.Any()).Times(3).Return(processor.SnippetHint{
+			Valid: true,
+			Paths: []string{"/foo"},
+		}, nil)
+
+		conflicts, err := subject.Conflicts(&Plan{Steps: []*Step{first, second, third}})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expected := []Conflict{
+			{
+				Overridden: ConflictStep{Step: 1, Snippet: "/a.yml", Scenarios: []string{"base"}, Paths: []string{"/foo"}},
+				Winner:     ConflictStep{Step: 2, Snippet: "/b.yml", Scenarios: []string{"base"}, Paths: []string{"/foo"}},
+			},
+			{
+				Overridden: ConflictStep{Step: 1, Snippet: "/a.yml", Scenarios: []string{"base"}, Paths: []string{"/foo"}},
+				Winner:     ConflictStep{Step: 3, Snippet: "/c.yml", Scenarios: []string{"base"}, Paths: []string{"/foo"}},
+			},
+		}
+
+		if !cmp.Equal(expected, conflicts) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n", expected, conflicts, cmp.Diff(expected, conflicts))
+		}
+	})
+
+	t.Run("appends to the same list", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
+		mockProcessor := processor.NewMockProcessor(ctrl)
+		subject := &StaticConflictDetector{
+			ProcessorFactory: mockProcessorFactory,
+		}
+
+		mockProcessorFactory.EXPECT().Create(library.OpsFile).Times(2).Return(mockProcessor, nil)
+		mockProcessor.EXPECT().ValidateSnippet(gomock.Any()).Times(2).Return(processor.SnippetHint{
+			Valid: true,
+			Paths: []string{"/jobs/-"},
+		}, nil)
+
+		conflicts, err := subject.Conflicts(&Plan{Steps: []*Step{step("/a.yml", nil, "first"), step("/b.yml", nil, "second")}})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		if len(conflicts) != 0 {
+			t.Errorf("Expected no conflicts but found %v", conflicts)
+		}
+	})
+
+	t.Run("snippet error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
+		mockProcessor := processor.NewMockProcessor(ctrl)
+		subject := &StaticConflictDetector{
+			ProcessorFactory: mockProcessorFactory,
+		}
+
+		mockProcessorFactory.EXPECT().Create(library.OpsFile).Times(1).Return(mockProcessor, nil)
+		mockProcessor.EXPECT().ValidateSnippet("/a.yml").Times(1).Return(processor.SnippetHint{}, errors.New("test"))
+
+		_, err := subject.Conflicts(&Plan{Steps: []*Step{step("/a.yml", nil, "first")}})
+
+		expectedError := errors.New("test\n  while parsing snippet /a.yml\n  while analyzing step 1")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+}
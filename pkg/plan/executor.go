@@ -7,6 +7,7 @@ import (
 	"github.com/cjnosal/manifer/v2/pkg/interpolator"
 	"github.com/cjnosal/manifer/v2/pkg/library"
 	"github.com/cjnosal/manifer/v2/pkg/processor/factory"
+	"github.com/cjnosal/manifer/v2/pkg/redact"
 	"github.com/cjnosal/manifer/v2/pkg/yaml"
 	"io"
 )
@@ -22,6 +23,7 @@ type InterpolationExecutor struct {
 	Output           io.Writer
 	File             file.FileAccess
 	Yaml             yaml.YamlAccess
+	Redactor         redact.Redactor
 }
 
 type ExecutorStep struct {
@@ -31,13 +33,22 @@ type ExecutorStep struct {
 }
 
 func (i *InterpolationExecutor) Execute(showPlan bool, showDiff bool, template *file.TaggedBytes, snippet *file.TaggedBytes, snippetProcessor *library.Processor, snippetVars library.InterpolatorParams, globals library.InterpolatorParams) ([]byte, error) {
+	bytes, err := i.execute(showPlan, showDiff, template, snippet, snippetProcessor, snippetVars, globals)
+	return bytes, i.Redactor.Error(err)
+}
+
+func (i *InterpolationExecutor) execute(showPlan bool, showDiff bool, template *file.TaggedBytes, snippet *file.TaggedBytes, snippetProcessor *library.Processor, snippetVars library.InterpolatorParams, globals library.InterpolatorParams) ([]byte, error) {
 	var snippetPath string
 	if snippet != nil {
 		snippetPath = snippet.Tag
 	}
+	err := i.track(snippetVars, globals)
+	if err != nil {
+		return nil, err
+	}
 	if showPlan {
 		step := ExecutorStep{
-			Interpolator: snippetVars.Merge(globals),
+			Interpolator: i.Redactor.Params(snippetVars.Merge(globals)),
 			Processor:    snippetProcessor,
 		}
 		if snippet != nil {
@@ -52,25 +63,44 @@ func (i *InterpolationExecutor) Execute(showPlan bool, showDiff bool, template *
 			return nil, fmt.Errorf("%w\n  while marshaling execution step", err)
 		}
 		i.Output.Write([]byte("\n"))
-		i.Output.Write(bytes)
+		i.Output.Write([]byte(i.Redactor.Redact(string(bytes))))
 	}
 	bytes, err := i.processSnippet(template, snippet, snippetProcessor, snippetVars, globals)
+	if snippetVars.VarsStore != "" || globals.VarsStore != "" || snippetVars.VarsStoreSeed != "" || globals.VarsStoreSeed != "" {
+		// pick up values generated during interpolation
+		trackErr := i.track(snippetVars, globals)
+		if trackErr != nil {
+			return nil, trackErr
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%w\n  while processing snippet %s", err, snippet)
 	}
 	if showDiff {
 		i.Output.Write([]byte("\nDiff:\n"))
 		diff := i.Diff.StringDiff(string(template.Bytes), string(bytes))
-		i.Output.Write([]byte(diff))
+		i.Output.Write([]byte(i.Redactor.Redact(diff)))
 	}
 	return bytes, nil
 }
 
+func (i *InterpolationExecutor) track(snippetVars library.InterpolatorParams, globals library.InterpolatorParams) error {
+	for _, params := range []library.InterpolatorParams{snippetVars, globals} {
+		err := i.Redactor.Track(params)
+		if err != nil {
+			return fmt.Errorf("%w\n  while tracking secret values", err)
+		}
+	}
+	return nil
+}
+
 func (i *InterpolationExecutor) processSnippet(template *file.TaggedBytes, snippet *file.TaggedBytes, snippetProcessor *library.Processor, snippetVars library.InterpolatorParams, globals library.InterpolatorParams) ([]byte, error) {
 
 	var processedTemplate *file.TaggedBytes
 	var intSnippet *file.TaggedBytes
-	if snippet != nil {
+	if snippet != nil && snippetProcessor != nil && i.ProcessorFactory.Capabilities(snippetProcessor.Type).Rendered {
+		intSnippet = snippet
+	} else if snippet != nil {
 		snippetBytes, err := i.Interpolator.Interpolate(snippet, snippetVars.Merge(globals))
 		if err != nil {
 			return nil, fmt.Errorf("%w\n  while trying to interpolate snippet", err)
@@ -86,7 +116,11 @@ func (i *InterpolationExecutor) processSnippet(template *file.TaggedBytes, snipp
 		if err != nil {
 			return nil, fmt.Errorf("%w\n  while initializing processor of type %s", err, snippetProcessor.Type)
 		}
-		processedBytes, err := processor.ProcessTemplate(template, intSnippet, snippetProcessor.Options)
+		vars, err := i.vars(snippetVars.Merge(globals))
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while trying to resolve vars", err)
+		}
+		processedBytes, err := processor.ProcessTemplate(template, intSnippet, snippetProcessor.Options, vars)
 		if err != nil {
 			return nil, fmt.Errorf("%w\n  while trying to process template", err)
 		}
@@ -106,3 +140,22 @@ func (i *InterpolationExecutor) processSnippet(template *file.TaggedBytes, snipp
 
 	return intTemplateBytes, nil
 }
+
+// the highest precedence value of each variable, including values generated while interpolating the snippet
+func (i *InterpolationExecutor) vars(params library.InterpolatorParams) (map[string]interface{}, error) {
+	values, err := i.Interpolator.Values(params)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := i.Interpolator.Stored(params)
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]interface{}{}
+	for _, v := range append(values, stored...) {
+		if _, found := vars[v.Name]; !found {
+			vars[v.Name] = v.Value
+		}
+	}
+	return vars, nil
+}
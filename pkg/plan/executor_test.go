@@ -10,6 +10,7 @@ import (
 	"github.com/cjnosal/manifer/v2/pkg/library"
 	"github.com/cjnosal/manifer/v2/pkg/processor"
 	"github.com/cjnosal/manifer/v2/pkg/processor/factory"
+	"github.com/cjnosal/manifer/v2/pkg/redact"
 	"github.com/cjnosal/manifer/v2/pkg/yaml"
 	"github.com/cjnosal/manifer/v2/test"
 	"github.com/golang/mock/gomock"
@@ -26,6 +27,7 @@ func TestExecute(t *testing.T) {
 		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
 		mockFile := file.NewMockFileAccess(ctrl)
 		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		mockRedactor := redact.NewMockRedactor(ctrl)
 		writer := &test.StringWriter{}
 		defer ctrl.Finish()
 
@@ -36,6 +38,7 @@ func TestExecute(t *testing.T) {
 			Output:           writer,
 			File:             mockFile,
 			Yaml:             mockYaml,
+			Redactor:         mockRedactor,
 		}
 
 		in := &file.TaggedBytes{Tag: "in", Bytes: []byte("foo: bar")}
@@ -61,9 +64,24 @@ func TestExecute(t *testing.T) {
 
 		mockInterpolator.EXPECT().Interpolate(snippet, library.InterpolatorParams{Vars: map[string]interface{}{"snippet": "sargs", "global": "gargs"}, RawArgs: []string{"-vfoo=bar"}}).Times(1).Return([]byte("intSnippetBytes"), nil)
 		mockInterpolator.EXPECT().Interpolate(processedIn, library.InterpolatorParams{Vars: map[string]interface{}{"global": "gargs"}, RawArgs: []string{"-vfoo=bar"}}).Times(1).Return([]byte("intTemplateBytes"), nil)
+		mockProcessorFactory.EXPECT().Capabilities(library.OpsFile).Times(1).Return(factory.Capabilities{})
 		mockProcessorFactory.EXPECT().Create(library.OpsFile).Times(1).Return(mockProcessor, nil)
-		mockProcessor.EXPECT().ProcessTemplate(in, intSnippet, snippetProcessor.Options).Times(1).Return([]byte("bytes"), nil)
+		mockInterpolator.EXPECT().Values(snippetVars.Merge(globals)).Times(1).Return([]interpolator.VarValue{
+			{Name: "foo", Value: "bar", Via: interpolator.ViaVars, Raw: true},
+			{Name: "global", Value: "gargs", Via: interpolator.ViaVars},
+			{Name: "snippet", Value: "sargs", Via: interpolator.ViaVars},
+			{Name: "foo", Value: "other", Via: interpolator.ViaVarsFiles},
+		}, nil)
+		mockInterpolator.EXPECT().Stored(snippetVars.Merge(globals)).Times(1).Return([]interpolator.VarValue{
+			{Name: "password", Value: "generated", Via: interpolator.ViaVarsStore},
+		}, nil)
+		mockProcessor.EXPECT().ProcessTemplate(in, intSnippet, snippetProcessor.Options, map[string]interface{}{"foo": "bar", "global": "gargs", "snippet": "sargs", "password": "generated"}).Times(1).Return([]byte("bytes"), nil)
 		mockFile.EXPECT().ResolveRelativeFromWD("snippet").Times(1).Return("../snippet", nil)
+		mockRedactor.EXPECT().Track(snippetVars).Return(nil)
+		mockRedactor.EXPECT().Track(globals).Return(nil)
+		mockRedactor.EXPECT().Params(snippetVars.Merge(globals)).Return(executorStep.Interpolator)
+		mockRedactor.EXPECT().Redact("yamlstep").Return("yamlstep")
+		mockRedactor.EXPECT().Error(nil).Return(nil)
 		mockYaml.EXPECT().Marshal(executorStep).Times(1).Return([]byte("yamlstep"), nil)
 		bytes, err := subject.Execute(true, false, in, snippet, snippetProcessor, snippetVars, globals)
 
@@ -88,6 +106,7 @@ func TestExecute(t *testing.T) {
 		mockInterpolator := interpolator.NewMockInterpolator(ctrl)
 		mockFile := file.NewMockFileAccess(ctrl)
 		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		mockRedactor := redact.NewMockRedactor(ctrl)
 		writer := &test.StringWriter{}
 		defer ctrl.Finish()
 
@@ -98,6 +117,7 @@ func TestExecute(t *testing.T) {
 			Output:           writer,
 			File:             mockFile,
 			Yaml:             mockYaml,
+			Redactor:         mockRedactor,
 		}
 
 		expectedDiff := "\nDiff:\ndiff"
@@ -119,9 +139,16 @@ func TestExecute(t *testing.T) {
 
 		mockInterpolator.EXPECT().Interpolate(snippet, library.InterpolatorParams{Vars: map[string]interface{}{"snippet": "sargs", "global": "gargs"}, RawArgs: []string{"-vfoo=bar"}}).Times(1).Return([]byte("intSnippetBytes"), nil)
 		mockInterpolator.EXPECT().Interpolate(processedIn, library.InterpolatorParams{Vars: map[string]interface{}{"global": "gargs"}, RawArgs: []string{"-vfoo=bar"}}).Times(1).Return([]byte("intTemplateBytes"), nil)
+		mockProcessorFactory.EXPECT().Capabilities(library.OpsFile).Times(1).Return(factory.Capabilities{})
 		mockProcessorFactory.EXPECT().Create(library.OpsFile).Times(1).Return(mockProcessor, nil)
-		mockProcessor.EXPECT().ProcessTemplate(in, intSnippet, snippetProcessor.Options).Times(1).Return([]byte("bytes"), nil)
+		mockInterpolator.EXPECT().Values(snippetVars.Merge(globals)).Times(1).Return([]interpolator.VarValue{}, nil)
+		mockInterpolator.EXPECT().Stored(snippetVars.Merge(globals)).Times(1).Return([]interpolator.VarValue{}, nil)
+		mockProcessor.EXPECT().ProcessTemplate(in, intSnippet, snippetProcessor.Options, map[string]interface{}{}).Times(1).Return([]byte("bytes"), nil)
 		mockDiff.EXPECT().StringDiff("foo: bar", "intTemplateBytes").Times(1).Return("diff")
+		mockRedactor.EXPECT().Track(snippetVars).Return(nil)
+		mockRedactor.EXPECT().Track(globals).Return(nil)
+		mockRedactor.EXPECT().Redact("diff").Return("diff")
+		mockRedactor.EXPECT().Error(nil).Return(nil)
 
 		bytes, err := subject.Execute(false, true, in, snippet, snippetProcessor, snippetVars, globals)
 
@@ -143,6 +170,7 @@ func TestExecute(t *testing.T) {
 		mockInterpolator := interpolator.NewMockInterpolator(ctrl)
 		mockFile := file.NewMockFileAccess(ctrl)
 		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		mockRedactor := redact.NewMockRedactor(ctrl)
 		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
 		writer := &test.StringWriter{}
 		defer ctrl.Finish()
@@ -154,6 +182,7 @@ func TestExecute(t *testing.T) {
 			Output:           writer,
 			File:             mockFile,
 			Yaml:             mockYaml,
+			Redactor:         mockRedactor,
 		}
 
 		expectedError := errors.New("test\n  while trying to interpolate snippet\n  while processing snippet &{bizz: bazz snippet}")
@@ -171,8 +200,12 @@ func TestExecute(t *testing.T) {
 			Options: map[string]interface{}{},
 		}
 
+		mockProcessorFactory.EXPECT().Capabilities(library.OpsFile).Times(1).Return(factory.Capabilities{})
 		mockInterpolator.EXPECT().Interpolate(snippet, library.InterpolatorParams{Vars: map[string]interface{}{"snippet": "sargs", "global": "gargs"}, RawArgs: []string{"-vfoo=bar"}}).Times(1).Return(nil, errors.New("test"))
 
+		mockRedactor.EXPECT().Track(snippetVars).Return(nil)
+		mockRedactor.EXPECT().Track(globals).Return(nil)
+		mockRedactor.EXPECT().Error(gomock.Any()).DoAndReturn(func(err error) error { return err })
 		_, err := subject.Execute(false, false, in, snippet, snippetProcessor, snippetVars, globals)
 
 		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
@@ -188,6 +221,7 @@ func TestExecute(t *testing.T) {
 		mockInterpolator := interpolator.NewMockInterpolator(ctrl)
 		mockFile := file.NewMockFileAccess(ctrl)
 		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		mockRedactor := redact.NewMockRedactor(ctrl)
 		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
 		writer := &test.StringWriter{}
 		defer ctrl.Finish()
@@ -199,6 +233,7 @@ func TestExecute(t *testing.T) {
 			Output:           writer,
 			File:             mockFile,
 			Yaml:             mockYaml,
+			Redactor:         mockRedactor,
 		}
 
 		expectedError := errors.New("test\n  while trying to process template\n  while processing snippet &{bizz: bazz snippet}")
@@ -218,9 +253,69 @@ func TestExecute(t *testing.T) {
 		}
 
 		mockInterpolator.EXPECT().Interpolate(snippet, library.InterpolatorParams{Vars: map[string]interface{}{"snippet": "sargs", "global": "gargs"}, RawArgs: []string{"-vfoo=bar"}}).Times(1).Return([]byte("intSnippetBytes"), nil)
+		mockProcessorFactory.EXPECT().Capabilities(library.OpsFile).Times(1).Return(factory.Capabilities{})
 		mockProcessorFactory.EXPECT().Create(library.OpsFile).Times(1).Return(mockProcessor, nil)
-		mockProcessor.EXPECT().ProcessTemplate(in, intSnippet, snippetProcessor.Options).Times(1).Return(nil, errors.New("test"))
+		mockInterpolator.EXPECT().Values(snippetVars.Merge(globals)).Times(1).Return([]interpolator.VarValue{}, nil)
+		mockInterpolator.EXPECT().Stored(snippetVars.Merge(globals)).Times(1).Return([]interpolator.VarValue{}, nil)
+		mockProcessor.EXPECT().ProcessTemplate(in, intSnippet, snippetProcessor.Options, map[string]interface{}{}).Times(1).Return(nil, errors.New("test"))
 
+		mockRedactor.EXPECT().Track(snippetVars).Return(nil)
+		mockRedactor.EXPECT().Track(globals).Return(nil)
+		mockRedactor.EXPECT().Error(gomock.Any()).DoAndReturn(func(err error) error { return err })
+		_, err := subject.Execute(false, false, in, snippet, snippetProcessor, snippetVars, globals)
+
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+
+	})
+
+	t.Run("Rendered snippet is not interpolated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockDiff := diff.NewMockDiff(ctrl)
+		mockProcessor := processor.NewMockProcessor(ctrl)
+		mockInterpolator := interpolator.NewMockInterpolator(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		mockRedactor := redact.NewMockRedactor(ctrl)
+		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
+		writer := &test.StringWriter{}
+		defer ctrl.Finish()
+
+		subject := &InterpolationExecutor{
+			Diff:             mockDiff,
+			ProcessorFactory: mockProcessorFactory,
+			Interpolator:     mockInterpolator,
+			Output:           writer,
+			File:             mockFile,
+			Yaml:             mockYaml,
+			Redactor:         mockRedactor,
+		}
+
+		expectedError := errors.New("test\n  while trying to process template\n  while processing snippet &{bizz: bazz snippet}")
+		in := &file.TaggedBytes{Tag: "in", Bytes: []byte("foo: bar")}
+		snippet := &file.TaggedBytes{Tag: "snippet", Bytes: []byte("bizz: bazz")}
+		globals := library.InterpolatorParams{
+			Vars:    map[string]interface{}{"global": "gargs"},
+			RawArgs: []string{"-vfoo=bar"},
+		}
+		snippetVars := library.InterpolatorParams{
+			Vars: map[string]interface{}{"snippet": "sargs"},
+		}
+		snippetProcessor := &library.Processor{
+			Type:    library.GoTemplate,
+			Options: map[string]interface{}{},
+		}
+
+		mockProcessorFactory.EXPECT().Capabilities(library.GoTemplate).Times(1).Return(factory.Capabilities{Rendered: true})
+		mockProcessorFactory.EXPECT().Create(library.GoTemplate).Times(1).Return(mockProcessor, nil)
+		mockInterpolator.EXPECT().Values(snippetVars.Merge(globals)).Times(1).Return([]interpolator.VarValue{}, nil)
+		mockInterpolator.EXPECT().Stored(snippetVars.Merge(globals)).Times(1).Return([]interpolator.VarValue{}, nil)
+		mockProcessor.EXPECT().ProcessTemplate(in, snippet, snippetProcessor.Options, map[string]interface{}{}).Times(1).Return(nil, errors.New("test"))
+
+		mockRedactor.EXPECT().Track(snippetVars).Return(nil)
+		mockRedactor.EXPECT().Track(globals).Return(nil)
+		mockRedactor.EXPECT().Error(gomock.Any()).DoAndReturn(func(err error) error { return err })
 		_, err := subject.Execute(false, false, in, snippet, snippetProcessor, snippetVars, globals)
 
 		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
@@ -237,6 +332,7 @@ func TestExecute(t *testing.T) {
 		mockInterpolator := interpolator.NewMockInterpolator(ctrl)
 		mockFile := file.NewMockFileAccess(ctrl)
 		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		mockRedactor := redact.NewMockRedactor(ctrl)
 		writer := &test.StringWriter{}
 		defer ctrl.Finish()
 
@@ -247,6 +343,7 @@ func TestExecute(t *testing.T) {
 			Output:           writer,
 			File:             mockFile,
 			Yaml:             mockYaml,
+			Redactor:         mockRedactor,
 		}
 
 		in := &file.TaggedBytes{Tag: "in", Bytes: []byte("foo: bar")}
@@ -268,14 +365,95 @@ func TestExecute(t *testing.T) {
 		mockInterpolator.EXPECT().Interpolate(snippet, library.InterpolatorParams{Vars: map[string]interface{}{"snippet": "sargs", "global": "gargs"}, RawArgs: []string{"-vfoo=bar"}}).Times(1).Return([]byte("intSnippetBytes"), nil)
 		mockInterpolator.EXPECT().Interpolate(processedIn, library.InterpolatorParams{Vars: map[string]interface{}{"global": "gargs"}, RawArgs: []string{"-vfoo=bar"}}).Times(1).Return(nil, errors.New("test"))
 
+		mockProcessorFactory.EXPECT().Capabilities(library.OpsFile).Times(1).Return(factory.Capabilities{})
 		mockProcessorFactory.EXPECT().Create(library.OpsFile).Times(1).Return(mockProcessor, nil)
-		mockProcessor.EXPECT().ProcessTemplate(in, intSnippet, snippetProcessor.Options).Times(1).Return([]byte("bytes"), nil)
+		mockInterpolator.EXPECT().Values(snippetVars.Merge(globals)).Times(1).Return([]interpolator.VarValue{}, nil)
+		mockInterpolator.EXPECT().Stored(snippetVars.Merge(globals)).Times(1).Return([]interpolator.VarValue{}, nil)
+		mockProcessor.EXPECT().ProcessTemplate(in, intSnippet, snippetProcessor.Options, map[string]interface{}{}).Times(1).Return([]byte("bytes"), nil)
 
 		expectedError := errors.New("test\n  while trying to interpolate template\n  while processing snippet &{bizz: bazz snippet}")
+		mockRedactor.EXPECT().Track(snippetVars).Return(nil)
+		mockRedactor.EXPECT().Track(globals).Return(nil)
+		mockRedactor.EXPECT().Error(gomock.Any()).DoAndReturn(func(err error) error { return err })
 		_, err := subject.Execute(false, false, in, snippet, snippetProcessor, snippetVars, globals)
 
 		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
 			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedError, err)
 		}
 	})
+
+	t.Run("Redact secrets", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockDiff := diff.NewMockDiff(ctrl)
+		mockInterpolator := interpolator.NewMockInterpolator(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		mockRedactor := redact.NewMockRedactor(ctrl)
+		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
+		writer := &test.StringWriter{}
+		defer ctrl.Finish()
+
+		subject := &InterpolationExecutor{
+			Diff:             mockDiff,
+			ProcessorFactory: mockProcessorFactory,
+			Interpolator:     mockInterpolator,
+			Output:           writer,
+			File:             mockFile,
+			Yaml:             mockYaml,
+			Redactor:         mockRedactor,
+		}
+
+		in := &file.TaggedBytes{Tag: "in", Bytes: []byte("foo: bar")}
+		globals := library.InterpolatorParams{
+			VarsStore: "creds.yml",
+		}
+		snippetVars := library.InterpolatorParams{}
+
+		// vars generated into the store are tracked after interpolation
+		mockRedactor.EXPECT().Track(snippetVars).Times(2).Return(nil)
+		mockRedactor.EXPECT().Track(globals).Times(2).Return(nil)
+		mockInterpolator.EXPECT().Interpolate(in, globals).Times(1).Return([]byte("foo: hunter2"), nil)
+		mockDiff.EXPECT().StringDiff("foo: bar", "foo: hunter2").Times(1).Return("+foo: hunter2")
+		mockRedactor.EXPECT().Redact("+foo: hunter2").Return("+foo: ***")
+		mockRedactor.EXPECT().Error(nil).Return(nil)
+
+		bytes, err := subject.Execute(false, true, in, nil, nil, snippetVars, globals)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		} else if !cmp.Equal(bytes, []byte("foo: hunter2")) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", "foo: hunter2", string(bytes))
+		}
+
+		expectedDiff := "\nDiff:\n+foo: ***"
+		if writer.String() != expectedDiff {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedDiff, writer.String())
+		}
+	})
+
+	t.Run("Track error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRedactor := redact.NewMockRedactor(ctrl)
+		defer ctrl.Finish()
+
+		subject := &InterpolationExecutor{
+			Redactor: mockRedactor,
+		}
+
+		in := &file.TaggedBytes{Tag: "in", Bytes: []byte("foo: bar")}
+		globals := library.InterpolatorParams{
+			RawArgs: []string{"--oops"},
+		}
+
+		mockRedactor.EXPECT().Track(library.InterpolatorParams{}).Return(nil)
+		mockRedactor.EXPECT().Track(globals).Return(errors.New("test"))
+		mockRedactor.EXPECT().Error(gomock.Any()).DoAndReturn(func(err error) error { return err })
+
+		_, err := subject.Execute(false, false, in, nil, nil, library.InterpolatorParams{}, globals)
+
+		expectedError := errors.New("test\n  while tracking secret values")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
 }
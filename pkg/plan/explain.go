@@ -0,0 +1,104 @@
+package plan
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cjnosal/manifer/v2/pkg/interpolator"
+	"github.com/cjnosal/manifer/v2/pkg/redact"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+)
+
+const GlobalScope = "global"
+
+type VarsExplainer interface {
+	// effective value of each variable supplied to each step, and the values it shadowed
+	Explain(plan *Plan) ([]StepVars, error)
+}
+
+type ParamsExplainer struct {
+	Interpolator interpolator.Interpolator
+	Redactor     redact.Redactor
+}
+
+type StepVars struct {
+	Step    int              `yaml:"step"` // 1-based plan index
+	Snippet string           `yaml:"snippet,omitempty"`
+	Vars    []VarExplanation `yaml:"vars"`
+}
+
+type VarExplanation struct {
+	Name     string        `yaml:"name"`
+	Value    interface{}   `yaml:"value"`
+	Scope    string        `yaml:"scope"` // params tag or global
+	Via      string        `yaml:"via"`
+	Shadowed []ShadowedVar `yaml:"shadowed,omitempty"` // highest precedence first
+}
+
+type ShadowedVar struct {
+	Value interface{} `yaml:"value"`
+	Scope string      `yaml:"scope"`
+	Via   string      `yaml:"via"`
+}
+
+func (e *ParamsExplainer) Explain(plan *Plan) ([]StepVars, error) {
+	globals, err := e.Interpolator.Values(plan.Global)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while listing global variables", err)
+	}
+
+	result := []StepVars{}
+	for i, step := range plan.Steps {
+		// globals override the step's params, and outer scenarios override inner scenarios
+		tags := []string{GlobalScope}
+		scoped := [][]interpolator.VarValue{globals}
+		for j := len(step.Params) - 1; j >= 0; j-- {
+			values, err := e.Interpolator.Values(step.Params[j].Interpolator)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while listing variables of %s for step %d", err, step.Params[j].Tag, i+1)
+			}
+			tags = append(tags, step.Params[j].Tag)
+			scoped = append(scoped, values)
+		}
+
+		explained := map[string]*VarExplanation{}
+		names := []string{}
+		for _, ranked := range interpolator.Rank(scoped) {
+			value := e.value(ranked.VarValue)
+			if v, ok := explained[ranked.Name]; ok {
+				v.Shadowed = append(v.Shadowed, ShadowedVar{
+					Value: value,
+					Scope: tags[ranked.Scope],
+					Via:   ranked.Source(),
+				})
+				continue
+			}
+			explained[ranked.Name] = &VarExplanation{
+				Name:  ranked.Name,
+				Value: value,
+				Scope: tags[ranked.Scope],
+				Via:   ranked.Source(),
+			}
+			names = append(names, ranked.Name)
+		}
+		sort.Strings(names)
+
+		vars := []VarExplanation{}
+		for _, name := range names {
+			vars = append(vars, *explained[name])
+		}
+		result = append(result, StepVars{
+			Step:    i + 1,
+			Snippet: step.Snippet,
+			Vars:    vars,
+		})
+	}
+	return result, nil
+}
+
+func (e *ParamsExplainer) value(v interpolator.VarValue) interface{} {
+	if e.Redactor.IsSecret(v) {
+		return redact.Mask
+	}
+	return yaml.StringKeys(v.Value)
+}
@@ -0,0 +1,86 @@
+package plan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cjnosal/manifer/v2/pkg/interpolator/bosh"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/redact"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExplain(t *testing.T) {
+
+	t.Run("effective and shadowed values", func(t *testing.T) {
+		boshInterpolator := bosh.NewBoshInterpolator()
+		subject := &ParamsExplainer{
+			Interpolator: boshInterpolator,
+			Redactor:     redact.NewSecretRedactor(boshInterpolator),
+		}
+
+		executionPlan := &Plan{
+			Global: library.InterpolatorParams{
+				VarsFiles: []string{"../../test/data/v2/vars.yml"},
+				RawArgs:   []string{"-vbar=cli"},
+			},
+			Steps: []*Step{
+				{
+					Snippet: "/a.yml",
+					Params: []TaggedParams{
+						{Tag: "snippet", Interpolator: library.InterpolatorParams{Vars: map[string]interface{}{"foo": "snippet", "db_password": "hunter2"}}},
+						{Tag: "inner", Interpolator: library.InterpolatorParams{Vars: map[string]interface{}{"foo": "inner", "map": map[interface{}]interface{}{"a": 1}}}},
+						{Tag: "outer", Interpolator: library.InterpolatorParams{Vars: map[string]interface{}{"foo": "outer"}}},
+					},
+				},
+			},
+		}
+
+		explained, err := subject.Explain(executionPlan)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expected := []StepVars{
+			{
+				Step:    1,
+				Snippet: "/a.yml",
+				Vars: []VarExplanation{
+					{Name: "bar", Value: "cli", Scope: "global", Via: "raw_args", Shadowed: []ShadowedVar{
+						{Value: "fromvarsfile", Scope: "global", Via: "vars_files"},
+					}},
+					{Name: "db_password", Value: "***", Scope: "snippet", Via: "vars"},
+					{Name: "foo", Value: "outer", Scope: "outer", Via: "vars", Shadowed: []ShadowedVar{
+						{Value: "inner", Scope: "inner", Via: "vars"},
+						{Value: "snippet", Scope: "snippet", Via: "vars"},
+						{Value: "yay", Scope: "global", Via: "vars_files"},
+					}},
+					{Name: "map", Value: map[string]interface{}{"a": 1}, Scope: "inner", Via: "vars"},
+					{Name: "value2", Value: "catchall", Scope: "global", Via: "vars_files"},
+				},
+			},
+		}
+		if err == nil && !cmp.Equal(explained, expected) {
+			t.Errorf("Expected:\n'''%+v'''\nActual:\n'''%+v'''\nDiff:\n%s", expected, explained, cmp.Diff(expected, explained))
+		}
+	})
+
+	t.Run("invalid args", func(t *testing.T) {
+		boshInterpolator := bosh.NewBoshInterpolator()
+		subject := &ParamsExplainer{
+			Interpolator: boshInterpolator,
+			Redactor:     redact.NewSecretRedactor(boshInterpolator),
+		}
+
+		_, err := subject.Explain(&Plan{
+			Global: library.InterpolatorParams{RawArgs: []string{"--oops"}},
+		})
+
+		expectedError := errors.New("unknown flag `oops'\n  while trying to parse vars\n  while listing global variables")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
@@ -18,6 +18,7 @@ func Append(a *Plan, b *Plan) *Plan {
 
 type Step struct {
 	Snippet   string            `yaml:"snippet,omitempty"`
+	Library   string            `yaml:"library,omitempty"`
 	Params    []TaggedParams    `yaml:"params,omitempty"`
 	Processor library.Processor `yaml:"processor,omitempty"`
 }
@@ -37,6 +38,28 @@ func (s *Step) FlattenParams() library.InterpolatorParams {
 	return intParams
 }
 
+// true if the step was contributed by the named scenario or one of its dependencies
+func (s *Step) HasScenario(name string) bool {
+	for _, tag := range s.ScenarioChain() {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+// scenario names from the innermost scenario to the outermost
+func (s *Step) ScenarioChain() []string {
+	chain := []string{}
+	for i, tp := range s.Params {
+		if i == 0 && tp.Tag == "snippet" {
+			continue
+		}
+		chain = append(chain, tp.Tag)
+	}
+	return chain
+}
+
 type TaggedParams struct {
 	Tag          string                     `yaml:"tag,omitempty"`
 	Interpolator library.InterpolatorParams `yaml:"interpolator,omitempty"`
@@ -75,6 +98,7 @@ func fromNode(node *library.ScenarioNode, plan *Plan, inherited []TaggedParams)
 		}
 		plan.Steps = append(plan.Steps, &Step{
 			Snippet:   snippet.Path,
+			Library:   node.LibraryPath,
 			Params:    append([]TaggedParams{snippetParams}, newTaggedParams...),
 			Processor: snippet.Processor,
 		})
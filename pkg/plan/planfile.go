@@ -0,0 +1,132 @@
+package plan
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+)
+
+// increment when the saved plan format changes incompatibly
+const PlanFileVersion = 2
+
+type PlanFile struct {
+	Version int                        `yaml:"version"`
+	Global  library.InterpolatorParams `yaml:"global,omitempty"`
+	Steps   []*SavedStep               `yaml:"steps,omitempty"`
+}
+
+// digest of the snippet content when the plan was saved
+type SavedStep struct {
+	Step   `yaml:",inline"`
+	Digest string `yaml:"digest,omitempty"`
+}
+
+type PlanAccess interface {
+	Read(path string) (*Plan, error)
+	Write(path string, plan *Plan) error
+}
+
+type PlanIO struct {
+	File file.FileAccess
+	Yaml yaml.YamlAccess
+}
+
+// snippet paths may be absolute or relative to the plan file
+// snippet content must match the digest saved with the plan
+func (p *PlanIO) Read(path string) (*Plan, error) {
+	bytes, err := p.File.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while reading plan file %s", err, path)
+	}
+	planFile := &PlanFile{}
+	err = p.Yaml.Unmarshal(bytes, planFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while parsing plan file %s", err, path)
+	}
+	if planFile.Version == 0 {
+		return nil, fmt.Errorf("Missing version in plan file %s", path)
+	}
+	if planFile.Version > PlanFileVersion {
+		return nil, fmt.Errorf("Unsupported plan file version %d in %s (expected %d)", planFile.Version, path, PlanFileVersion)
+	}
+
+	loaded := &Plan{
+		Global: library.InterpolatorParams{
+			Vars:      map[string]interface{}{},
+			VarFiles:  map[string]string{},
+			VarsFiles: []string{},
+			VarsEnv:   []string{},
+			VarsStore: "",
+			RawArgs:   []string{},
+		},
+		Steps: []*Step{},
+	}
+	loaded.Global = loaded.Global.Merge(planFile.Global)
+	for _, saved := range planFile.Steps {
+		step := saved.Step
+		if step.Snippet != "" {
+			absPath, err := p.File.ResolveRelativeTo(step.Snippet, path)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while resolving snippet path %s from %s", err, step.Snippet, path)
+			}
+			if saved.Digest == "" {
+				return nil, fmt.Errorf("Missing digest for snippet %s in plan file %s", step.Snippet, path)
+			}
+			digest, err := p.digest(absPath)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while verifying snippet %s from plan file %s", err, step.Snippet, path)
+			}
+			if digest != saved.Digest {
+				return nil, fmt.Errorf("Snippet %s changed since plan file %s was saved (expected %s, found %s)", absPath, path, saved.Digest, digest)
+			}
+			step.Snippet = absPath
+		}
+		loaded.Steps = append(loaded.Steps, &step)
+	}
+	return loaded, nil
+}
+
+// snippet paths are saved relative to the plan file
+func (p *PlanIO) Write(path string, plan *Plan) error {
+	planFile := &PlanFile{
+		Version: PlanFileVersion,
+		Global:  plan.Global,
+		Steps:   []*SavedStep{},
+	}
+	for _, step := range plan.Steps {
+		saved := SavedStep{Step: *step}
+		if step.Snippet != "" {
+			rel, err := p.File.ResolveRelativeFrom(step.Snippet, filepath.Dir(path))
+			if err != nil {
+				return fmt.Errorf("%w\n  while finding relative path from %s to %s", err, path, step.Snippet)
+			}
+			saved.Snippet = rel
+			saved.Digest, err = p.digest(step.Snippet)
+			if err != nil {
+				return fmt.Errorf("%w\n  while saving plan file %s", err, path)
+			}
+		}
+		planFile.Steps = append(planFile.Steps, &saved)
+	}
+	bytes, err := p.Yaml.Marshal(planFile)
+	if err != nil {
+		return fmt.Errorf("%w\n  while marshaling plan file", err)
+	}
+	err = p.File.Write(path, bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("%w\n  while writing plan file %s", err, path)
+	}
+	return nil
+}
+
+func (p *PlanIO) digest(snippet string) (string, error) {
+	bytes, err := p.File.Read(snippet)
+	if err != nil {
+		return "", fmt.Errorf("%w\n  while reading snippet %s", err, snippet)
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(bytes)), nil
+}
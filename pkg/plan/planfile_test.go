@@ -0,0 +1,312 @@
+package plan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadPlan(t *testing.T) {
+
+	t.Run("resolve snippet paths", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		defer ctrl.Finish()
+
+		subject := &PlanIO{
+			File: mockFile,
+			Yaml: mockYaml,
+		}
+
+		saved := PlanFile{
+			Version: 2,
+			Global: library.InterpolatorParams{
+				Vars: map[string]interface{}{"global": "garg"},
+			},
+			Steps: []*SavedStep{
+				{
+					Step: Step{
+						Snippet: "./snippet.yml",
+						Params: []TaggedParams{
+							{
+								Tag:          "snippet",
+								Interpolator: library.InterpolatorParams{Vars: map[string]interface{}{"foo": "bar"}},
+							},
+						},
+						Processor: library.Processor{Type: library.OpsFile},
+					},
+					Digest: "sha256:d3086fc13819f64ed130eb6e6faeecd99fa36010867d2a58eba7a848807af612",
+				},
+				{
+					Step: Step{
+						Snippet:   "/abs/snippet.yml",
+						Processor: library.Processor{Type: library.OpsFile},
+					},
+					Digest: "sha256:d3086fc13819f64ed130eb6e6faeecd99fa36010867d2a58eba7a848807af612",
+				},
+				{
+					Step: Step{
+						Processor: library.Processor{Type: library.Yq, Options: map[string]interface{}{"command": "prefix"}},
+					},
+				},
+			},
+		}
+		expectedPlan := &Plan{
+			Global: library.InterpolatorParams{
+				Vars:      map[string]interface{}{"global": "garg"},
+				VarFiles:  map[string]string{},
+				VarsFiles: []string{},
+				VarsEnv:   []string{},
+				RawArgs:   []string{},
+			},
+			Steps: []*Step{
+				{
+					Snippet: "/plans/snippet.yml",
+					Params: []TaggedParams{
+						{
+							Tag:          "snippet",
+							Interpolator: library.InterpolatorParams{Vars: map[string]interface{}{"foo": "bar"}},
+						},
+					},
+					Processor: library.Processor{Type: library.OpsFile},
+				},
+				{
+					Snippet:   "/abs/snippet.yml",
+					Processor: library.Processor{Type: library.OpsFile},
+				},
+				{
+					Processor: library.Processor{Type: library.Yq, Options: map[string]interface{}{"command": "prefix"}},
+				},
+			},
+		}
+
+		mockFile.EXPECT().Read("/plans/plan.yml").Times(1).Return([]byte("bytes"), nil)
+		mockYaml.EXPECT().Unmarshal([]byte("bytes"), &PlanFile{}).Times(1).Return(nil).Do(func(bytes []byte, p *PlanFile) {
+			*p = saved
+		})
+		mockFile.EXPECT().ResolveRelativeTo("./snippet.yml", "/plans/plan.yml").Times(1).Return("/plans/snippet.yml", nil)
+		mockFile.EXPECT().ResolveRelativeTo("/abs/snippet.yml", "/plans/plan.yml").Times(1).Return("/abs/snippet.yml", nil)
+		mockFile.EXPECT().Read("/plans/snippet.yml").Times(1).Return([]byte("snippet"), nil)
+		mockFile.EXPECT().Read("/abs/snippet.yml").Times(1).Return([]byte("snippet"), nil)
+
+		loaded, err := subject.Read("/plans/plan.yml")
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		} else if !cmp.Equal(expectedPlan, loaded) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n", expectedPlan, loaded, cmp.Diff(expectedPlan, loaded))
+		}
+	})
+
+	t.Run("changed snippet", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		defer ctrl.Finish()
+
+		subject := &PlanIO{
+			File: mockFile,
+			Yaml: mockYaml,
+		}
+
+		mockFile.EXPECT().Read("/plans/plan.yml").Times(1).Return([]byte("bytes"), nil)
+		mockYaml.EXPECT().Unmarshal([]byte("bytes"), &PlanFile{}).Times(1).Return(nil).Do(func(bytes []byte, p *PlanFile) {
+			p.Version = 2
+			p.Steps = []*SavedStep{{Step: Step{Snippet: "./snippet.yml"}, Digest: "sha256:d3086fc13819f64ed130eb6e6faeecd99fa36010867d2a58eba7a848807af612"}}
+		})
+		mockFile.EXPECT().ResolveRelativeTo("./snippet.yml", "/plans/plan.yml").Times(1).Return("/plans/snippet.yml", nil)
+		mockFile.EXPECT().Read("/plans/snippet.yml").Times(1).Return([]byte("changed"), nil)
+
+		_, err := subject.Read("/plans/plan.yml")
+
+		expectedError := errors.New("Snippet /plans/snippet.yml changed since plan file /plans/plan.yml was saved (expected sha256:d3086fc13819f64ed130eb6e6faeecd99fa36010867d2a58eba7a848807af612, found sha256:d67e2e944994496c8d8ec76eed0cf9f09679448d584b532bebf941852a37f5ed)")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+
+	t.Run("missing digest", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		defer ctrl.Finish()
+
+		subject := &PlanIO{
+			File: mockFile,
+			Yaml: mockYaml,
+		}
+
+		mockFile.EXPECT().Read("/plans/plan.yml").Times(1).Return([]byte("bytes"), nil)
+		mockYaml.EXPECT().Unmarshal([]byte("bytes"), &PlanFile{}).Times(1).Return(nil).Do(func(bytes []byte, p *PlanFile) {
+			p.Version = 1
+			p.Steps = []*SavedStep{{Step: Step{Snippet: "./snippet.yml"}}}
+		})
+		mockFile.EXPECT().ResolveRelativeTo("./snippet.yml", "/plans/plan.yml").Times(1).Return("/plans/snippet.yml", nil)
+
+		_, err := subject.Read("/plans/plan.yml")
+
+		expectedError := errors.New("Missing digest for snippet ./snippet.yml in plan file /plans/plan.yml")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+
+	t.Run("missing version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		defer ctrl.Finish()
+
+		subject := &PlanIO{
+			File: mockFile,
+			Yaml: mockYaml,
+		}
+
+		mockFile.EXPECT().Read("plan.yml").Times(1).Return([]byte("bytes"), nil)
+		mockYaml.EXPECT().Unmarshal([]byte("bytes"), &PlanFile{}).Times(1).Return(nil)
+
+		_, err := subject.Read("plan.yml")
+
+		expectedError := errors.New("Missing version in plan file plan.yml")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		defer ctrl.Finish()
+
+		subject := &PlanIO{
+			File: mockFile,
+			Yaml: mockYaml,
+		}
+
+		mockFile.EXPECT().Read("plan.yml").Times(1).Return([]byte("bytes"), nil)
+		mockYaml.EXPECT().Unmarshal([]byte("bytes"), &PlanFile{}).Times(1).Return(nil).Do(func(bytes []byte, p *PlanFile) {
+			p.Version = 3
+		})
+
+		_, err := subject.Read("plan.yml")
+
+		expectedError := errors.New("Unsupported plan file version 3 in plan.yml (expected 2)")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		defer ctrl.Finish()
+
+		subject := &PlanIO{
+			File: mockFile,
+			Yaml: mockYaml,
+		}
+
+		mockFile.EXPECT().Read("plan.yml").Times(1).Return(nil, errors.New("test"))
+
+		_, err := subject.Read("plan.yml")
+
+		expectedError := errors.New("test\n  while reading plan file plan.yml")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+}
+
+func TestWritePlan(t *testing.T) {
+
+	t.Run("relative snippet paths", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		defer ctrl.Finish()
+
+		subject := &PlanIO{
+			File: mockFile,
+			Yaml: mockYaml,
+		}
+
+		global := library.InterpolatorParams{
+			RawArgs: []string{"-vfoo=bar"},
+		}
+		executionPlan := &Plan{
+			Global: global,
+			Steps: []*Step{
+				{
+					Snippet:   "/lib/snippet.yml",
+					Processor: library.Processor{Type: library.OpsFile},
+				},
+				{
+					Processor: library.Processor{Type: library.OpsFile, Options: map[string]interface{}{"path": "/foo"}},
+				},
+			},
+		}
+		expectedFile := &PlanFile{
+			Version: 2,
+			Global:  global,
+			Steps: []*SavedStep{
+				{
+					Step: Step{
+						Snippet:   "../lib/snippet.yml",
+						Processor: library.Processor{Type: library.OpsFile},
+					},
+					Digest: "sha256:d3086fc13819f64ed130eb6e6faeecd99fa36010867d2a58eba7a848807af612",
+				},
+				{
+					Step: Step{
+						Processor: library.Processor{Type: library.OpsFile, Options: map[string]interface{}{"path": "/foo"}},
+					},
+				},
+			},
+		}
+
+		mockFile.EXPECT().ResolveRelativeFrom("/lib/snippet.yml", "/plans").Times(1).Return("../lib/snippet.yml", nil)
+		mockFile.EXPECT().Read("/lib/snippet.yml").Times(1).Return([]byte("snippet"), nil)
+		mockYaml.EXPECT().Marshal(expectedFile).Times(1).Return([]byte("bytes"), nil)
+		mockFile.EXPECT().Write("/plans/plan.yml", []byte("bytes"), gomock.Any()).Times(1).Return(nil)
+
+		err := subject.Write("/plans/plan.yml", executionPlan)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		if executionPlan.Steps[0].Snippet != "/lib/snippet.yml" {
+			t.Errorf("Original plan was modified: %s", executionPlan.Steps[0].Snippet)
+		}
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		defer ctrl.Finish()
+
+		subject := &PlanIO{
+			File: mockFile,
+			Yaml: mockYaml,
+		}
+
+		mockYaml.EXPECT().Marshal(gomock.Any()).Times(1).Return([]byte("bytes"), nil)
+		mockFile.EXPECT().Write("plan.yml", []byte("bytes"), gomock.Any()).Times(1).Return(errors.New("test"))
+
+		err := subject.Write("plan.yml", &Plan{})
+
+		expectedError := errors.New("test\n  while writing plan file plan.yml")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package plan
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/interpolator"
+	"github.com/cjnosal/manifer/v2/pkg/processor/factory"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	y "gopkg.in/yaml.v3"
+)
+
+type VarsReporter interface {
+	// placeholders left in a composed document
+	Unresolved(output []byte) ([]UnresolvedVar, error)
+
+	// unresolved placeholders in the output, and variables provided to the plan that no snippet receiving them or template references
+	Report(template *file.TaggedBytes, plan *Plan, output []byte) (*VarsReport, error)
+}
+
+// bosh's ExpectAllKeys and ExpectAllVarsUsed only see a single evaluation, but each snippet and the template after each step
+// are evaluated with a subset of the plan's vars: placeholders may be resolved by later steps (or not evaluated again without globals),
+// and vars may be used by other steps. So placeholders are found in the final output with bosh's pattern and vars are checked across the plan.
+type StaticVarsReporter struct {
+	File             file.FileAccess
+	Yaml             yaml.YamlAccess
+	Interpolator     interpolator.Interpolator
+	ProcessorFactory factory.ProcessorFactory
+}
+
+type VarsReport struct {
+	Unresolved    []UnresolvedVar `yaml:"unresolved"`
+	Unused        []UnusedVars    `yaml:"unused"`
+	UnusedGlobals []string        `yaml:"unused_globals"`
+}
+
+type UnresolvedVar struct {
+	Path     string `yaml:"path"`
+	Variable string `yaml:"variable"`
+}
+
+// variables provided for a single snippet, or by a scenario to all of its snippets, that none of them reference
+type UnusedVars struct {
+	Step      int      `yaml:"step,omitempty"` // 1-based plan index, unset for scenario vars
+	Snippet   string   `yaml:"snippet,omitempty"`
+	Scenarios []string `yaml:"scenarios,omitempty"` // innermost first
+	Vars      []string `yaml:"vars"`
+}
+
+func (r *StaticVarsReporter) Unresolved(output []byte) ([]UnresolvedVar, error) {
+	node := &y.Node{}
+	err := r.Yaml.Unmarshal(output, node)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while parsing output", err)
+	}
+	unresolved := []UnresolvedVar{}
+	for _, leaf := range yaml.Leaves(node) {
+		// placeholders in keys are part of the path
+		names := r.Interpolator.Referenced(&file.TaggedBytes{Bytes: []byte(leaf.Path + " " + leaf.Value)})
+		for _, name := range names {
+			unresolved = append(unresolved, UnresolvedVar{Path: leaf.Path, Variable: name})
+		}
+	}
+	return unresolved, nil
+}
+
+func (r *StaticVarsReporter) Report(template *file.TaggedBytes, plan *Plan, output []byte) (*VarsReport, error) {
+	unresolved, err := r.Unresolved(output)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while finding unresolved variables", err)
+	}
+
+	// globals interpolate every snippet and the template after every step
+	referenced := rootNames(r.Interpolator.Referenced(template))
+
+	// snippet vars are checked against their step, scenario vars against every step of the scenario and its dependencies
+	candidates := []*UnusedVars{}
+	scenarios := map[string]*UnusedVars{}
+	usesAll := false
+	for i, step := range plan.Steps {
+		if step.Snippet == "" {
+			continue
+		}
+		// processors that read vars directly may use any of them
+		capabilities := r.ProcessorFactory.Capabilities(step.Processor.Type)
+		stepUsesAll := capabilities.Vars || capabilities.Rendered
+		stepReferenced := []string{}
+		if !stepUsesAll {
+			snippet, err := r.File.ReadAndTag(step.Snippet)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while trying to load snippet %s", err, step.Snippet)
+			}
+			stepReferenced = rootNames(r.Interpolator.Referenced(snippet))
+			referenced = union(referenced, stepReferenced)
+		}
+		usesAll = usesAll || stepUsesAll
+
+		for j, tp := range step.Params {
+			var candidate *UnusedVars
+			if j == 0 && tp.Tag == "snippet" {
+				candidate = &UnusedVars{
+					Step:      i + 1,
+					Snippet:   step.Snippet,
+					Scenarios: step.ScenarioChain(),
+				}
+			} else {
+				chain := (&Step{Params: step.Params[j:]}).ScenarioChain()
+				key := strings.Join(chain, "\n")
+				candidate = scenarios[key]
+				if candidate != nil {
+					candidate.Vars = remaining(candidate.Vars, stepReferenced, stepUsesAll)
+					continue
+				}
+				candidate = &UnusedVars{Scenarios: chain}
+				scenarios[key] = candidate
+			}
+			provided, err := r.Interpolator.Provided(tp.Interpolator)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while listing variables for step %d", err, i+1)
+			}
+			candidate.Vars = remaining(provided, stepReferenced, stepUsesAll)
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	unused := []UnusedVars{}
+	for _, candidate := range candidates {
+		if len(candidate.Vars) > 0 {
+			unused = append(unused, *candidate)
+		}
+	}
+
+	globals, err := r.Interpolator.Provided(plan.Global)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while listing global variables", err)
+	}
+
+	return &VarsReport{
+		Unresolved:    unresolved,
+		Unused:        unused,
+		UnusedGlobals: remaining(globals, referenced, usesAll),
+	}, nil
+}
+
+func remaining(provided []string, referenced []string, usesAll bool) []string {
+	if usesAll {
+		return []string{}
+	}
+	return difference(provided, referenced)
+}
+
+// ((creds.password)) is provided by the creds variable
+func rootNames(names []string) []string {
+	roots := []string{}
+	for _, name := range names {
+		roots = insert(roots, strings.Split(name, ".")[0])
+	}
+	return roots
+}
+
+func union(a []string, b []string) []string {
+	result := append([]string{}, a...)
+	for _, s := range b {
+		result = insert(result, s)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func difference(a []string, b []string) []string {
+	result := []string{}
+	for _, s := range a {
+		if !containsString(b, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func containsString(collection []string, value string) bool {
+	for _, c := range collection {
+		if c == value {
+			return true
+		}
+	}
+	return false
+}
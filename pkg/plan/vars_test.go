@@ -0,0 +1,268 @@
+package plan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/interpolator/bosh"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor/factory"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVarsReport(t *testing.T) {
+
+	t.Run("unresolved placeholders", func(t *testing.T) {
+		subject := &StaticVarsReporter{
+			Yaml:         &yaml.Yaml{},
+			Interpolator: bosh.NewBoshInterpolator(),
+		}
+
+		unresolved, err := subject.Unresolved([]byte("foo: ((bar))\nlist:\n- name: a\n  value: x ((fizz)) ((buzz.key))\n((key)): done\n"))
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expected := []UnresolvedVar{
+			{Path: "/foo", Variable: "bar"},
+			{Path: "/list/name=a/value", Variable: "buzz.key"},
+			{Path: "/list/name=a/value", Variable: "fizz"},
+			{Path: "/((key))", Variable: "key"},
+		}
+		if err == nil && !cmp.Equal(unresolved, expected) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expected, unresolved)
+		}
+	})
+
+	t.Run("unresolved placeholders match bosh's missing keys in the final output", func(t *testing.T) {
+		subject := &StaticVarsReporter{
+			Yaml:         &yaml.Yaml{},
+			Interpolator: bosh.NewBoshInterpolator(),
+		}
+		output := []byte("foo: ((bar))\nlist:\n- name: a\n  value: x ((fizz)) ((!buzz.key))\n")
+
+		unresolved, err := subject.Unresolved(output)
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		names := []string{}
+		for _, u := range unresolved {
+			names = append(names, u.Variable)
+		}
+
+		_, err = boshtpl.NewTemplate(output).Evaluate(boshtpl.StaticVariables{}, nil, boshtpl.EvaluateOpts{ExpectAllKeys: true})
+
+		// bosh reports the variable a nested name is read from
+		expectedNames := []string{"bar", "buzz.key", "fizz"}
+		expectedError := errors.New("Expected to find variables: bar\nbuzz\nfizz")
+		if !cmp.Equal(names, expectedNames) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNames, names)
+		}
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+
+	t.Run("bosh checks each evaluation on its own", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
+		subject := &StaticVarsReporter{
+			File:             mockFile,
+			Yaml:             &yaml.Yaml{},
+			Interpolator:     bosh.NewBoshInterpolator(),
+			ProcessorFactory: mockProcessorFactory,
+		}
+
+		executionPlan := &Plan{
+			Global: library.InterpolatorParams{
+				Vars: map[string]interface{}{"first": "a", "second": "b"},
+			},
+			Steps: []*Step{
+				{Snippet: "/a.yml", Processor: library.Processor{Type: library.OpsFile}},
+				{Snippet: "/b.yml", Processor: library.Processor{Type: library.OpsFile}},
+			},
+		}
+		first := []byte("- type: replace\n  path: /first?\n  value: ((first))\n")
+		second := []byte("- type: replace\n  path: /second?\n  value: ((second))\n")
+
+		mockProcessorFactory.EXPECT().Capabilities(library.OpsFile).Times(2).Return(factory.Capabilities{})
+		mockFile.EXPECT().ReadAndTag("/a.yml").Return(&file.TaggedBytes{Tag: "/a.yml", Bytes: first}, nil)
+		mockFile.EXPECT().ReadAndTag("/b.yml").Return(&file.TaggedBytes{Tag: "/b.yml", Bytes: second}, nil)
+
+		report, err := subject.Report(&file.TaggedBytes{}, executionPlan, []byte("first: a\nsecond: b\n"))
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		if err == nil && len(report.UnusedGlobals) > 0 {
+			t.Errorf("Expected globals used by any step to be used, got %v", report.UnusedGlobals)
+		}
+
+		// globals are applied to each snippet, so every snippet would leave one unused
+		globals := boshtpl.StaticVariables{"first": "a", "second": "b"}
+		_, err = boshtpl.NewTemplate(first).Evaluate(globals, nil, boshtpl.EvaluateOpts{ExpectAllVarsUsed: true})
+
+		expectedError := errors.New("Expected to use variables: second")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+
+		// a placeholder added by a step may be resolved by a later snippet's vars
+		_, err = boshtpl.NewTemplate([]byte("first: ((first))\n")).Evaluate(boshtpl.StaticVariables{}, nil, boshtpl.EvaluateOpts{ExpectAllKeys: true})
+
+		expectedError = errors.New("Expected to find variables: first")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+
+	t.Run("unused vars are scoped per scenario", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
+		subject := &StaticVarsReporter{
+			File:             mockFile,
+			Yaml:             &yaml.Yaml{},
+			Interpolator:     bosh.NewBoshInterpolator(),
+			ProcessorFactory: mockProcessorFactory,
+		}
+
+		outer := TaggedParams{Tag: "outer", Interpolator: library.InterpolatorParams{Vars: map[string]interface{}{"in_second": "d", "never": "e"}}}
+		executionPlan := &Plan{
+			Global: library.InterpolatorParams{
+				Vars: map[string]interface{}{"shared": "x", "in_template": "y", "extra": "z"},
+			},
+			Steps: []*Step{
+				{
+					Snippet:   "/a.yml",
+					Processor: library.Processor{Type: library.OpsFile},
+					Params: []TaggedParams{
+						{Tag: "snippet", Interpolator: library.InterpolatorParams{Vars: map[string]interface{}{"used": "a", "unused": "b"}}},
+						{Tag: "first", Interpolator: library.InterpolatorParams{Vars: map[string]interface{}{"creds": "c"}}},
+						outer,
+					},
+				},
+				{
+					Snippet:   "/b.yml",
+					Processor: library.Processor{Type: library.OpsFile},
+					Params: []TaggedParams{
+						{Tag: "second", Interpolator: library.InterpolatorParams{Vars: map[string]interface{}{"used": "a"}}},
+						outer,
+					},
+				},
+			},
+		}
+
+		template := &file.TaggedBytes{Tag: "template.yml", Bytes: []byte("foo: ((in_template))\n")}
+		mockProcessorFactory.EXPECT().Capabilities(library.OpsFile).Times(2).Return(factory.Capabilities{})
+		mockFile.EXPECT().ReadAndTag("/a.yml").Return(&file.TaggedBytes{Tag: "/a.yml", Bytes: []byte("- path: /((used))\n  value: ((creds.password))\n")}, nil)
+		mockFile.EXPECT().ReadAndTag("/b.yml").Return(&file.TaggedBytes{Tag: "/b.yml", Bytes: []byte("- path: /((shared))\n  value: ((missing)) ((in_second))\n")}, nil)
+
+		report, err := subject.Report(template, executionPlan, []byte("x: ((missing))\n"))
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expected := &VarsReport{
+			Unresolved: []UnresolvedVar{
+				{Path: "/x", Variable: "missing"},
+			},
+			Unused: []UnusedVars{
+				{Step: 1, Snippet: "/a.yml", Scenarios: []string{"first", "outer"}, Vars: []string{"unused"}},
+				{Scenarios: []string{"outer"}, Vars: []string{"never"}},
+				{Scenarios: []string{"second", "outer"}, Vars: []string{"used"}},
+			},
+			UnusedGlobals: []string{"extra"},
+		}
+		if err == nil && !cmp.Equal(report, expected) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expected, report)
+		}
+	})
+
+	t.Run("processors reading vars use all of them", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
+		subject := &StaticVarsReporter{
+			File:             mockFile,
+			Yaml:             &yaml.Yaml{},
+			Interpolator:     bosh.NewBoshInterpolator(),
+			ProcessorFactory: mockProcessorFactory,
+		}
+
+		scenario := TaggedParams{Tag: "scenario", Interpolator: library.InterpolatorParams{Vars: map[string]interface{}{"groups": "a,b", "unused": "c"}}}
+		executionPlan := &Plan{
+			Global: library.InterpolatorParams{
+				Vars: map[string]interface{}{"global": "x"},
+			},
+			Steps: []*Step{
+				{
+					Snippet:   "/a.yml",
+					Processor: library.Processor{Type: library.OpsFile},
+					Params:    []TaggedParams{scenario},
+				},
+				{
+					Snippet:   "/b.tmpl",
+					Processor: library.Processor{Type: library.GoTemplate},
+					Params:    []TaggedParams{scenario},
+				},
+			},
+		}
+
+		mockProcessorFactory.EXPECT().Capabilities(library.OpsFile).Return(factory.Capabilities{})
+		mockProcessorFactory.EXPECT().Capabilities(library.GoTemplate).Return(factory.Capabilities{Rendered: true, Vars: true})
+		mockFile.EXPECT().ReadAndTag("/a.yml").Return(&file.TaggedBytes{Tag: "/a.yml", Bytes: []byte("[]\n")}, nil)
+
+		report, err := subject.Report(&file.TaggedBytes{}, executionPlan, []byte("{}\n"))
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expected := &VarsReport{
+			Unresolved:    []UnresolvedVar{},
+			Unused:        []UnusedVars{},
+			UnusedGlobals: []string{},
+		}
+		if err == nil && !cmp.Equal(report, expected) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expected, report)
+		}
+	})
+
+	t.Run("missing snippet", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockFile := file.NewMockFileAccess(ctrl)
+		mockProcessorFactory := factory.NewMockProcessorFactory(ctrl)
+		subject := &StaticVarsReporter{
+			File:             mockFile,
+			Yaml:             &yaml.Yaml{},
+			Interpolator:     bosh.NewBoshInterpolator(),
+			ProcessorFactory: mockProcessorFactory,
+		}
+
+		executionPlan := &Plan{
+			Steps: []*Step{{Snippet: "/a.yml", Processor: library.Processor{Type: library.OpsFile}}},
+		}
+
+		mockProcessorFactory.EXPECT().Capabilities(library.OpsFile).Return(factory.Capabilities{})
+		mockFile.EXPECT().ReadAndTag("/a.yml").Return(nil, errors.New("test"))
+
+		_, err := subject.Report(&file.TaggedBytes{}, executionPlan, []byte{})
+
+		expectedError := errors.New("test\n  while trying to load snippet /a.yml")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/jessevdk/go-flags"
+	y "gopkg.in/yaml.v3"
+)
+
+// libraries can run arbitrary commands, so exec snippets only run when this is set to true
+const AllowEnv = "MANIFER_ALLOW_EXEC"
+
+func NewExecProcessor(yaml yaml.YamlAccess, file file.FileAccess) processor.Processor {
+	return &execProcessor{
+		yaml: yaml,
+		file: file,
+	}
+}
+
+type execProcessor struct {
+	yaml yaml.YamlAccess
+	file file.FileAccess
+}
+
+// sent to the command on stdin
+type request struct {
+	Template string                 `json:"template"`
+	Snippet  string                 `json:"snippet"`
+	Options  map[string]interface{} `json:"options"`
+}
+
+type commandFlags struct {
+	Commands []string `long:"exec" value-name:"COMMAND" description:"Replace the template with the output of a command given the template on stdin"`
+}
+
+func (i *execProcessor) ValidateSnippet(path string) (processor.SnippetHint, error) {
+	hint := processor.SnippetHint{
+		Valid: false,
+	}
+	_, err := i.file.Read(path)
+	if err != nil {
+		return hint, fmt.Errorf("%w\n  while validating exec snippet %s", err, path)
+	}
+	// the command can rewrite any part of the document
+	hint.Valid = true
+	hint.Element = "/"
+	hint.Action = "exec"
+	hint.Paths = []string{"/"}
+	return hint, nil
+}
+
+func (i *execProcessor) ParsePassthroughFlags(args []string) (*library.ScenarioNode, []string, error) {
+	var node *library.ScenarioNode
+	commandFlags := commandFlags{}
+	remainder, err := flags.NewParser(&commandFlags, flags.IgnoreUnknown).ParseArgs(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w\n  while trying to parse exec args", err)
+	}
+	if len(commandFlags.Commands) > 0 {
+		snippets := []library.Snippet{}
+		for _, c := range commandFlags.Commands {
+			snippets = append(snippets, library.Snippet{
+				Processor: library.Processor{
+					Type: library.Exec,
+					Options: map[string]interface{}{
+						"command": c,
+					},
+				},
+			})
+		}
+		node = &library.ScenarioNode{
+			Name:        "passthrough exec",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets:    snippets,
+		}
+	}
+	return node, remainder, nil
+}
+
+func (i *execProcessor) Options() processor.OptionSchema {
+	return processor.OptionSchema{
+		{Name: "command", Type: processor.StringOption, Required: true},
+		{Name: "args", Type: processor.ListOption},
+	}
+}
+
+func (i *execProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBytes *file.TaggedBytes, options map[string]interface{}, vars map[string]interface{}) ([]byte, error) {
+	err := i.Options().Validate(options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while validating processor options", err)
+	}
+	command := options["command"].(string)
+	if os.Getenv(AllowEnv) != "true" {
+		return nil, fmt.Errorf("Running command %s is not allowed, set %s=true to enable exec snippets", command, AllowEnv)
+	}
+	args, err := parseArgs(options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse exec options", err)
+	}
+	req := request{
+		Template: string(templateBytes.Bytes),
+		Options:  yaml.StringKeys(options).(map[string]interface{}),
+	}
+	if snippetBytes != nil {
+		req.Snippet = string(snippetBytes.Bytes)
+	}
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to encode request for command %s", err, command)
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := osexec.Command(command, args...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err = cmd.Run()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("%w\n  while running command %s against template %s", err, command, templateBytes.Tag)
+	}
+
+	// json is parsed as yaml so numbers keep their precision, yaml output keeps its comments, json output is converted to block style
+	node := &y.Node{}
+	err = i.yaml.Unmarshal(stdout.Bytes(), node)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse output of command %s", err, command)
+	}
+	if len(node.Content) == 0 {
+		return nil, fmt.Errorf("Expected command %s to produce a document", command)
+	}
+	out := node
+	if json.Valid(stdout.Bytes()) {
+		out = yaml.CopyNode(node)
+	}
+	outBytes, err := i.yaml.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to marshal output of command %s", err, command)
+	}
+	return outBytes, nil
+}
+
+// the schema checks that args is a list, but not what it contains
+func parseArgs(options map[string]interface{}) ([]string, error) {
+	args := []string{}
+	list, _ := options["args"].([]interface{})
+	for _, a := range list {
+		s, ok := a.(string)
+		if !ok {
+			return nil, fmt.Errorf("args must be a list of strings")
+		}
+		args = append(args, s)
+	}
+	return args, nil
+}
@@ -0,0 +1,263 @@
+package exec
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePassthroughFlags(t *testing.T) {
+
+	t.Run("commands", func(t *testing.T) {
+		subject := execProcessor{}
+		flags := []string{"--exec", "foo", "--exec=bar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expectedNode := &library.ScenarioNode{
+			Name:        "passthrough exec",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets: []library.Snippet{
+				{
+					Processor: library.Processor{
+						Type: library.Exec,
+						Options: map[string]interface{}{
+							"command": "foo",
+						},
+					},
+				},
+				{
+					Processor: library.Processor{
+						Type: library.Exec,
+						Options: map[string]interface{}{
+							"command": "bar",
+						},
+					},
+				},
+			},
+		}
+		if !cmp.Equal(*expectedNode, *node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", *expectedNode, *node)
+		}
+
+		expectedRemainder := []string{}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("ignore other flags", func(t *testing.T) {
+		subject := execProcessor{}
+		flags := []string{"-ofoo", "-vbar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		var expectedNode *library.ScenarioNode
+		if !cmp.Equal(expectedNode, node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNode, node)
+		}
+
+		expectedRemainder := []string{"-ofoo", "-vbar"}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		subject := execProcessor{}
+		flags := []string{"--exec"}
+		_, _, err := subject.ParsePassthroughFlags(flags)
+
+		expectedError := "expected argument for flag `--exec'\n  while trying to parse exec args"
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+
+	t.Run("any file", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+
+		subject := NewExecProcessor(&yaml.Yaml{}, mockFile)
+
+		mockFile.EXPECT().Read("/foo").Times(1).Return([]byte("anything"), nil)
+
+		hint, err := subject.ValidateSnippet("/foo")
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expectedHint := processor.SnippetHint{
+			Valid:   true,
+			Element: "/",
+			Action:  "exec",
+			Paths:   []string{"/"},
+		}
+		if !cmp.Equal(expectedHint, hint) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedHint, hint)
+		}
+	})
+
+	t.Run("file error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+
+		subject := NewExecProcessor(&yaml.Yaml{}, mockFile)
+
+		mockFile.EXPECT().Read("/foo").Times(1).Return(nil, errors.New("oops"))
+
+		hint, err := subject.ValidateSnippet("/foo")
+
+		expectedError := errors.New("oops\n  while validating exec snippet /foo")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+
+		if hint.Valid {
+			t.Errorf("Expected ValidateSnippet to return false")
+		}
+	})
+}
+
+func TestProcessTemplate(t *testing.T) {
+	os.Setenv(AllowEnv, "true")
+	defer os.Unsetenv(AllowEnv)
+
+	cases := []struct {
+		name    string
+		in      string
+		snippet string
+		options map[string]interface{}
+
+		expectedError error
+		expectedOut   string
+	}{
+		{
+			name:    "envelope",
+			in:      "foo: bar\n",
+			snippet: "bizz: bazz\n",
+			options: map[string]interface{}{"command": "sh", "args": []interface{}{"-c", "printf '{\"received\": '; cat; printf '}'"}},
+			expectedOut: `received:
+    template: |
+        foo: bar
+    snippet: |
+        bizz: bazz
+    options:
+        args:
+          - -c
+          - 'printf ''{"received": ''; cat; printf ''}'''
+        command: sh
+`,
+		},
+		{
+			name:        "json numbers",
+			in:          "foo: bar\n",
+			options:     map[string]interface{}{"command": "sh", "args": []interface{}{"-c", "cat > /dev/null; echo '{\"disk\": 1073741824, \"big\": 9007199254740993, \"ratio\": 0.5, \"id\": \"1\"}'"}},
+			expectedOut: "disk: 1073741824\nbig: 9007199254740993\nratio: 0.5\nid: \"1\"\n",
+		},
+		{
+			name:        "no snippet",
+			in:          "foo: bar\n",
+			options:     map[string]interface{}{"command": "sh", "args": []interface{}{"-c", "cat > /dev/null; echo '# generated'; echo 'foo: baz'"}},
+			expectedOut: "# generated\nfoo: baz\n",
+		},
+		{
+			name:          "exit code",
+			in:            "foo: bar\n",
+			options:       map[string]interface{}{"command": "sh", "args": []interface{}{"-c", "echo 'something broke' >&2; exit 3"}},
+			expectedError: errors.New("exit status 3: something broke\n  while running command sh against template template.yml"),
+		},
+		{
+			name:          "missing command",
+			in:            "foo: bar\n",
+			options:       map[string]interface{}{"command": "./does-not-exist"},
+			expectedError: errors.New("fork/exec ./does-not-exist: no such file or directory\n  while running command ./does-not-exist against template template.yml"),
+		},
+		{
+			name:          "no command",
+			in:            "foo: bar\n",
+			expectedError: errors.New("Option command is required\n  while validating processor options"),
+		},
+		{
+			name:          "bad args",
+			in:            "foo: bar\n",
+			options:       map[string]interface{}{"command": "sh", "args": "-c"},
+			expectedError: errors.New("Option args must be a list, not string\n  while validating processor options"),
+		},
+		{
+			name:          "bad arg",
+			in:            "foo: bar\n",
+			options:       map[string]interface{}{"command": "sh", "args": []interface{}{"-c", 1}},
+			expectedError: errors.New("args must be a list of strings\n  while trying to parse exec options"),
+		},
+		{
+			name:          "invalid output",
+			in:            "foo: bar\n",
+			options:       map[string]interface{}{"command": "sh", "args": []interface{}{"-c", "echo '[1'"}},
+			expectedError: errors.New("yaml: line 1: did not find expected ',' or ']'\n  while unmarshalling yaml\n  while trying to parse output of command sh"),
+		},
+		{
+			name:          "no output",
+			in:            "foo: bar\n",
+			options:       map[string]interface{}{"command": "true"},
+			expectedError: errors.New("Expected command true to produce a document"),
+		},
+	}
+
+	t.Run("not allowed", func(t *testing.T) {
+		os.Setenv(AllowEnv, "false")
+		defer os.Setenv(AllowEnv, "true")
+		subject := NewExecProcessor(&yaml.Yaml{}, nil)
+
+		_, err := subject.ProcessTemplate(&file.TaggedBytes{Tag: "template.yml", Bytes: []byte("foo: bar\n")}, nil, map[string]interface{}{"command": "true"}, nil)
+
+		expectedError := "Running command true is not allowed, set MANIFER_ALLOW_EXEC=true to enable exec snippets"
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			subject := NewExecProcessor(&yaml.Yaml{}, nil)
+
+			var snippet *file.TaggedBytes
+			if c.snippet != "" {
+				snippet = &file.TaggedBytes{Tag: "snippet", Bytes: []byte(c.snippet)}
+			}
+			templateBytes, err := subject.ProcessTemplate(&file.TaggedBytes{Tag: "template.yml", Bytes: []byte(c.in)}, snippet, c.options, nil)
+
+			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
+			}
+
+			if err == nil && string(templateBytes) != c.expectedOut {
+				t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedOut, templateBytes)
+			}
+		})
+	}
+}
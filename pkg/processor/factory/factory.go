@@ -5,42 +5,148 @@ import (
 	"github.com/cjnosal/manifer/v2/pkg/file"
 	"github.com/cjnosal/manifer/v2/pkg/library"
 	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/processor/exec"
+	"github.com/cjnosal/manifer/v2/pkg/processor/gotemplate"
+	"github.com/cjnosal/manifer/v2/pkg/processor/jq"
+	"github.com/cjnosal/manifer/v2/pkg/processor/jsonpatch"
+	"github.com/cjnosal/manifer/v2/pkg/processor/mergepatch"
 	"github.com/cjnosal/manifer/v2/pkg/processor/opsfile"
 	"github.com/cjnosal/manifer/v2/pkg/processor/yq"
+	"github.com/cjnosal/manifer/v2/pkg/processor/yq4"
 	"github.com/cjnosal/manifer/v2/pkg/yaml"
 )
 
+type ProcessorConstructor func(yaml yaml.YamlAccess, file file.FileAccess) processor.Processor
+
+type GeneratorConstructor func(yaml yaml.YamlAccess) processor.SnippetGenerator
+
+// how snippets of a registered type are handled outside of the processor
+type Capabilities struct {
+	// snippets can be recognized by their contents, so import looks for them
+	Import bool
+	// snippets are rendered by the processor with the step's vars instead of being interpolated first
+	Rendered bool
+	// snippets can read any of the step's vars without a ((placeholder))
+	Vars bool
+}
+
 type ProcessorFactory interface {
 	Create(t library.Type) (processor.Processor, error)
 	CreateGenerator(t library.Type) (processor.SnippetGenerator, error)
+
+	// add a processor type, newGenerator may be nil if the type can't generate snippets
+	Register(t library.Type, newProcessor ProcessorConstructor, newGenerator GeneratorConstructor, capabilities Capabilities) error
+
+	// registered types in the order their passthrough flags are parsed
+	Types() []library.Type
+
+	// capabilities given when the type was registered, or none for unknown types
+	Capabilities(t library.Type) Capabilities
+
+	// check options against the schema of the type's processor
+	ValidateOptions(t library.Type, options map[string]interface{}) error
+}
+
+type registration struct {
+	newProcessor ProcessorConstructor
+	newGenerator GeneratorConstructor
+	capabilities Capabilities
 }
 
 type processorFactory struct {
-	yaml yaml.YamlAccess
-	file file.FileAccess
+	yaml          yaml.YamlAccess
+	file          file.FileAccess
+	types         []library.Type
+	registrations map[library.Type]registration
 }
 
 func NewProcessorFactory(yaml yaml.YamlAccess, file file.FileAccess) ProcessorFactory {
-	return &processorFactory{
-		yaml: yaml,
-		file: file,
+	f := &processorFactory{
+		yaml:          yaml,
+		file:          file,
+		types:         []library.Type{},
+		registrations: map[library.Type]registration{},
+	}
+	f.registerBuiltins()
+	return f
+}
+
+// only opsfiles, yq scripts, and json patches are imported: any yq script is also a valid merge patch,
+// yq4 expressions, jq programs, and go templates are just strings, and exec snippets are anything
+func (i *processorFactory) registerBuiltins() {
+	importable := Capabilities{Import: true}
+	i.Register(library.OpsFile, opsfile.NewOpsFileProcessor, pathGenerator(opsfile.NewPathBuilder()), importable)
+	i.Register(library.Yq, func(y yaml.YamlAccess, f file.FileAccess) processor.Processor {
+		return yq.NewYqProcessor(f)
+	}, pathGenerator(yq.NewPathBuilder()), importable)
+	i.Register(library.JsonPatch, jsonpatch.NewJsonPatchProcessor, pathGenerator(jsonpatch.NewPathBuilder()), importable)
+	i.Register(library.MergePatch, mergepatch.NewMergePatchProcessor, nil, Capabilities{})
+	i.Register(library.Yq4, yq4.NewYq4Processor, pathGenerator(yq4.NewPathBuilder()), Capabilities{})
+	i.Register(library.Jq, jq.NewJqProcessor, nil, Capabilities{Vars: true})
+	i.Register(library.GoTemplate, gotemplate.NewGoTemplateProcessor, nil, Capabilities{Rendered: true, Vars: true})
+	i.Register(library.Exec, exec.NewExecProcessor, nil, Capabilities{})
+}
+
+func pathGenerator(builder processor.PathBuilder) GeneratorConstructor {
+	return func(y yaml.YamlAccess) processor.SnippetGenerator {
+		return processor.NewSnippetGenerator(y, builder)
 	}
 }
 
+func (i *processorFactory) Register(t library.Type, newProcessor ProcessorConstructor, newGenerator GeneratorConstructor, capabilities Capabilities) error {
+	if t == "" {
+		return fmt.Errorf("Processor type must not be empty")
+	}
+	if newProcessor == nil {
+		return fmt.Errorf("Processor constructor for library type %v must not be nil", t)
+	}
+	if _, found := i.registrations[t]; found {
+		return fmt.Errorf("Library type %v is already registered", t)
+	}
+	i.types = append(i.types, t)
+	i.registrations[t] = registration{
+		newProcessor: newProcessor,
+		newGenerator: newGenerator,
+		capabilities: capabilities,
+	}
+	return nil
+}
+
+func (i *processorFactory) Types() []library.Type {
+	return append([]library.Type{}, i.types...)
+}
+
+func (i *processorFactory) Capabilities(t library.Type) Capabilities {
+	return i.registrations[t].capabilities
+}
+
+func (i *processorFactory) ValidateOptions(t library.Type, options map[string]interface{}) error {
+	p, err := i.Create(t)
+	if err != nil {
+		return err
+	}
+	err = processor.ValidateOptions(p, options)
+	if err != nil {
+		return fmt.Errorf("%w\n  while validating options for processor type %s", err, t)
+	}
+	return nil
+}
+
 func (i *processorFactory) Create(t library.Type) (processor.Processor, error) {
-	if t == library.OpsFile {
-		return opsfile.NewOpsFileProcessor(i.yaml, i.file), nil
-	} else if t == library.Yq {
-		return yq.NewYqProcessor(i.file), nil
+	r, found := i.registrations[t]
+	if !found {
+		return nil, fmt.Errorf("Unknown library type %v", t)
 	}
-	return nil, fmt.Errorf("Unknown library type %v", t)
+	return r.newProcessor(i.yaml, i.file), nil
 }
 
 func (i *processorFactory) CreateGenerator(t library.Type) (processor.SnippetGenerator, error) {
-	if t == library.OpsFile {
-		return processor.NewSnippetGenerator(i.yaml, opsfile.NewPathBuilder()), nil
-	} else if t == library.Yq {
-		return processor.NewSnippetGenerator(i.yaml, yq.NewPathBuilder()), nil
+	r, found := i.registrations[t]
+	if !found {
+		return nil, fmt.Errorf("Unknown library type %v", t)
+	}
+	if r.newGenerator == nil {
+		return nil, fmt.Errorf("Snippet generation is not supported for library type %v", t)
 	}
-	return nil, fmt.Errorf("Unknown library type %v", t)
+	return r.newGenerator(i.yaml), nil
 }
@@ -0,0 +1,209 @@
+package factory
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegister(t *testing.T) {
+
+	t.Run("custom type", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockProcessor := processor.NewMockProcessor(ctrl)
+		mockGenerator := processor.NewMockSnippetGenerator(ctrl)
+
+		subject := NewProcessorFactory(&yaml.Yaml{}, &file.FileIO{})
+
+		err := subject.Register("custom", func(y yaml.YamlAccess, f file.FileAccess) processor.Processor {
+			return mockProcessor
+		}, func(y yaml.YamlAccess) processor.SnippetGenerator {
+			return mockGenerator
+		}, Capabilities{Import: true})
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		p, err := subject.Create("custom")
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		if p != mockProcessor {
+			t.Errorf("Expected registered processor, got %v", p)
+		}
+
+		g, err := subject.CreateGenerator("custom")
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		if g != mockGenerator {
+			t.Errorf("Expected registered generator, got %v", g)
+		}
+
+		expectedTypes := []library.Type{library.OpsFile, library.Yq, library.JsonPatch, library.MergePatch, library.Yq4, library.Jq, library.GoTemplate, library.Exec, "custom"}
+		if !cmp.Equal(expectedTypes, subject.Types()) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedTypes, subject.Types())
+		}
+
+		expectedCapabilities := Capabilities{Import: true}
+		if !cmp.Equal(expectedCapabilities, subject.Capabilities("custom")) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedCapabilities, subject.Capabilities("custom"))
+		}
+	})
+
+	t.Run("no generator", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockProcessor := processor.NewMockProcessor(ctrl)
+
+		subject := NewProcessorFactory(&yaml.Yaml{}, &file.FileIO{})
+
+		err := subject.Register("custom", func(y yaml.YamlAccess, f file.FileAccess) processor.Processor {
+			return mockProcessor
+		}, nil, Capabilities{})
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		_, err = subject.CreateGenerator("custom")
+
+		expectedError := errors.New("Snippet generation is not supported for library type custom")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+
+	cases := []struct {
+		name         string
+		libType      library.Type
+		newProcessor ProcessorConstructor
+
+		expectedError error
+	}{
+		{
+			name:          "duplicate",
+			libType:       library.OpsFile,
+			newProcessor:  nilProcessor,
+			expectedError: errors.New("Library type opsfile is already registered"),
+		},
+		{
+			name:          "empty type",
+			libType:       "",
+			newProcessor:  nilProcessor,
+			expectedError: errors.New("Processor type must not be empty"),
+		},
+		{
+			name:          "nil constructor",
+			libType:       "custom",
+			expectedError: errors.New("Processor constructor for library type custom must not be nil"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			subject := NewProcessorFactory(&yaml.Yaml{}, &file.FileIO{})
+
+			err := subject.Register(c.libType, c.newProcessor, nil, Capabilities{})
+
+			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+
+	t.Run("unknown type", func(t *testing.T) {
+		subject := NewProcessorFactory(&yaml.Yaml{}, &file.FileIO{})
+
+		_, err := subject.Create("unknown")
+
+		expectedError := errors.New("Unknown library type unknown")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+
+		_, err = subject.CreateGenerator("unknown")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+
+	t.Run("builtin generators", func(t *testing.T) {
+		subject := NewProcessorFactory(&yaml.Yaml{}, &file.FileIO{})
+
+		for _, libType := range []library.Type{library.OpsFile, library.Yq, library.JsonPatch, library.Yq4} {
+			_, err := subject.CreateGenerator(libType)
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+		}
+		for _, libType := range []library.Type{library.MergePatch, library.Jq, library.GoTemplate, library.Exec} {
+			_, err := subject.CreateGenerator(libType)
+			if err == nil {
+				t.Errorf("Expected generation of %s to be unsupported", libType)
+			}
+		}
+	})
+}
+
+func TestValidateOptions(t *testing.T) {
+	cases := []struct {
+		name    string
+		libType library.Type
+		options map[string]interface{}
+
+		expectedError error
+	}{
+		{
+			name:    "valid",
+			libType: library.Yq,
+			options: map[string]interface{}{"command": "merge", "overwrite": true},
+		},
+		{
+			name:          "invalid",
+			libType:       library.Yq,
+			options:       map[string]interface{}{"command": "merge", "append": "yes"},
+			expectedError: errors.New("Option append must be a bool, not string\n  while validating options for processor type yq"),
+		},
+		{
+			name:          "applier options",
+			libType:       library.GoTemplate,
+			options:       map[string]interface{}{"apply": "opsfile", "merge_keys": map[string]interface{}{}},
+			expectedError: errors.New("Unknown option merge_keys (expected path)\n  while validating options for the rendered snippet\n  while validating options for processor type gotemplate"),
+		},
+		{
+			name:          "unknown type",
+			libType:       "unknown",
+			expectedError: errors.New("Unknown library type unknown"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			subject := NewProcessorFactory(&yaml.Yaml{}, &file.FileIO{})
+
+			err := subject.ValidateOptions(c.libType, c.options)
+
+			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
+			}
+		})
+	}
+}
+
+func nilProcessor(y yaml.YamlAccess, f file.FileAccess) processor.Processor {
+	return nil
+}
@@ -0,0 +1,239 @@
+package gotemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	y "gopkg.in/yaml.v3"
+)
+
+// a subset of the sprig helpers
+func funcs() template.FuncMap {
+	return template.FuncMap{
+		"default":    defaultValue,
+		"empty":      empty,
+		"coalesce":   coalesce,
+		"ternary":    ternary,
+		"required":   required,
+		"fail":       fail,
+		"quote":      func(v interface{}) string { return strconv.Quote(toString(v)) },
+		"squote":     func(v interface{}) string { return "'" + strings.ReplaceAll(toString(v), "'", "''") + "'" },
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      strings.Title,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":      split,
+		"join":       join,
+		"list":       func(v ...interface{}) []interface{} { return v },
+		"dict":       dict,
+		"hasKey":     hasKey,
+		"keys":       keys,
+		"add":        func(a, b interface{}) int { return toInt(a) + toInt(b) },
+		"sub":        func(a, b interface{}) int { return toInt(a) - toInt(b) },
+		"mul":        func(a, b interface{}) int { return toInt(a) * toInt(b) },
+		"div":        div,
+		"mod":        mod,
+		"until":      until,
+		"toString":   toString,
+		"int":        toInt,
+		"toYaml":     toYaml,
+		"toJson":     toJson,
+		"indent":     indent,
+		"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+	}
+}
+
+func defaultValue(d interface{}, v ...interface{}) interface{} {
+	if len(v) == 0 || empty(v[0]) {
+		return d
+	}
+	return v[0]
+}
+
+func empty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return r.Len() == 0
+	case reflect.Bool:
+		return !r.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return r.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return r.Float() == 0
+	case reflect.Ptr, reflect.Interface:
+		return r.IsNil()
+	}
+	return false
+}
+
+func coalesce(v ...interface{}) interface{} {
+	for _, e := range v {
+		if !empty(e) {
+			return e
+		}
+	}
+	return nil
+}
+
+func ternary(t interface{}, f interface{}, condition bool) interface{} {
+	if condition {
+		return t
+	}
+	return f
+}
+
+func required(message string, v interface{}) (interface{}, error) {
+	if empty(v) {
+		return nil, fmt.Errorf("%s", message)
+	}
+	return v, nil
+}
+
+func fail(message string) (string, error) {
+	return "", fmt.Errorf("%s", message)
+}
+
+func split(sep string, s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, sep)
+}
+
+func join(sep string, v interface{}) string {
+	parts := []string{}
+	r := reflect.ValueOf(v)
+	if r.Kind() != reflect.Slice && r.Kind() != reflect.Array {
+		return toString(v)
+	}
+	for i := 0; i < r.Len(); i++ {
+		parts = append(parts, toString(r.Index(i).Interface()))
+	}
+	return strings.Join(parts, sep)
+}
+
+func dict(v ...interface{}) (map[string]interface{}, error) {
+	if len(v)%2 != 0 {
+		return nil, fmt.Errorf("dict expects key value pairs")
+	}
+	d := map[string]interface{}{}
+	for i := 0; i < len(v); i += 2 {
+		d[toString(v[i])] = v[i+1]
+	}
+	return d, nil
+}
+
+func hasKey(d map[string]interface{}, key string) bool {
+	_, ok := d[key]
+	return ok
+}
+
+func keys(d map[string]interface{}) []string {
+	k := []string{}
+	for key := range d {
+		k = append(k, key)
+	}
+	sort.Strings(k)
+	return k
+}
+
+func div(a, b interface{}) (int, error) {
+	if toInt(b) == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+	return toInt(a) / toInt(b), nil
+}
+
+func mod(a, b interface{}) (int, error) {
+	if toInt(b) == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+	return toInt(a) % toInt(b), nil
+}
+
+func until(n interface{}) []int {
+	l := []int{}
+	for i := 0; i < toInt(n); i++ {
+		l = append(l, i)
+	}
+	return l
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case fmt.Stringer:
+		return s.String()
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func toInt(v interface{}) int {
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(r.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(r.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int(r.Float())
+	case reflect.String:
+		i, err := strconv.Atoi(strings.TrimSpace(r.String()))
+		if err == nil {
+			return i
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(r.String()), 64)
+		if err == nil {
+			return int(f)
+		}
+	case reflect.Bool:
+		if r.Bool() {
+			return 1
+		}
+	}
+	return 0
+}
+
+func toYaml(v interface{}) (string, error) {
+	b, err := y.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+func toJson(v interface{}) (string, error) {
+	b, err := json.Marshal(yaml.StringKeys(v))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
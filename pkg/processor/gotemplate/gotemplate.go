@@ -0,0 +1,167 @@
+package gotemplate
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/processor/mergepatch"
+	"github.com/cjnosal/manifer/v2/pkg/processor/opsfile"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/jessevdk/go-flags"
+)
+
+func NewGoTemplateProcessor(yaml yaml.YamlAccess, file file.FileAccess) processor.Processor {
+	return &goTemplateProcessor{
+		yaml: yaml,
+		file: file,
+		appliers: map[string]processor.Processor{
+			"merge":   mergepatch.NewMergePatchProcessor(yaml, file),
+			"opsfile": opsfile.NewOpsFileProcessor(yaml, file),
+		},
+	}
+}
+
+type goTemplateProcessor struct {
+	yaml     yaml.YamlAccess
+	file     file.FileAccess
+	appliers map[string]processor.Processor // apply option to the processor for the rendered snippet
+}
+
+// data available to the template
+type context struct {
+	Doc  interface{}
+	Vars map[string]interface{}
+}
+
+type templateFlags struct {
+	TemplatePaths []string `long:"go-template" value-name:"PATH" description:"Render a go template from a file and merge the result into the template"`
+}
+
+func (i *goTemplateProcessor) ValidateSnippet(path string) (processor.SnippetHint, error) {
+	hint := processor.SnippetHint{
+		Valid: false,
+	}
+	content, err := i.file.Read(path)
+	if err != nil {
+		return hint, fmt.Errorf("%w\n  while validating go template %s", err, path)
+	}
+	_, err = template.New(path).Funcs(funcs()).Parse(string(content))
+	if err != nil {
+		return hint, nil
+	}
+	// the rendered snippet can't be known until the vars are
+	hint.Valid = true
+	hint.Element = "/"
+	hint.Action = "render"
+	hint.Paths = []string{"/"}
+	return hint, nil
+}
+
+func (i *goTemplateProcessor) ParsePassthroughFlags(args []string) (*library.ScenarioNode, []string, error) {
+	var node *library.ScenarioNode
+	templateFlags := templateFlags{}
+	remainder, err := flags.NewParser(&templateFlags, flags.IgnoreUnknown).ParseArgs(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w\n  while trying to parse go template args", err)
+	}
+	if len(templateFlags.TemplatePaths) > 0 {
+		snippets := []library.Snippet{}
+		for _, p := range templateFlags.TemplatePaths {
+			snippets = append(snippets, library.Snippet{
+				Path: p,
+				Processor: library.Processor{
+					Type:    library.GoTemplate,
+					Options: map[string]interface{}{},
+				},
+			})
+		}
+		node = &library.ScenarioNode{
+			Name:        "passthrough gotemplate",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets:    snippets,
+		}
+	}
+	return node, remainder, nil
+}
+
+func (i *goTemplateProcessor) Options() processor.OptionSchema {
+	return processor.OptionSchema{
+		{Name: "apply", Type: processor.StringOption, Allowed: []string{"merge", "opsfile"}},
+		{Name: "merge_keys", Type: processor.MapOption},
+		{Name: "path", Type: processor.StringOption},
+	}
+}
+
+// the options other than apply are checked against the schema of the selected applier
+func (i *goTemplateProcessor) ValidateOptions(options map[string]interface{}) error {
+	err := i.Options().Validate(options)
+	if err != nil {
+		return err
+	}
+	applier, applierOptions := i.applier(options)
+	err = applier.Options().Validate(applierOptions)
+	if err != nil {
+		return fmt.Errorf("%w\n  while validating options for the rendered snippet", err)
+	}
+	return nil
+}
+
+func (i *goTemplateProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBytes *file.TaggedBytes, options map[string]interface{}, vars map[string]interface{}) ([]byte, error) {
+	err := i.ValidateOptions(options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while validating processor options", err)
+	}
+	if snippetBytes == nil {
+		return templateBytes.Bytes, nil
+	}
+	applier, applierOptions := i.applier(options)
+
+	t, err := template.New(snippetBytes.Tag).Funcs(funcs()).Parse(string(snippetBytes.Bytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse go template %s", err, snippetBytes.Tag)
+	}
+
+	var doc interface{}
+	err = i.yaml.Unmarshal(templateBytes.Bytes, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse template %s", err, templateBytes.Tag)
+	}
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	data := context{
+		Doc:  yaml.StringKeys(doc),
+		Vars: yaml.StringKeys(vars).(map[string]interface{}),
+	}
+
+	rendered := &bytes.Buffer{}
+	err = t.Execute(rendered, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to render go template %s", err, snippetBytes.Tag)
+	}
+
+	outBytes, err := applier.ProcessTemplate(templateBytes, &file.TaggedBytes{Tag: snippetBytes.Tag, Bytes: rendered.Bytes()}, applierOptions, vars)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to apply rendered go template %s", err, snippetBytes.Tag)
+	}
+	return outBytes, nil
+}
+
+// the apply option selects the processor for the rendered snippet (merge by default), other options are passed on
+func (i *goTemplateProcessor) applier(options map[string]interface{}) (processor.Processor, map[string]interface{}) {
+	apply := "merge"
+	remaining := map[string]interface{}{}
+	for k, v := range options {
+		if k == "apply" && v != nil {
+			apply = v.(string)
+		} else if k != "apply" {
+			remaining[k] = v
+		}
+	}
+	return i.appliers[apply], remaining
+}
@@ -0,0 +1,283 @@
+package gotemplate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePassthroughFlags(t *testing.T) {
+
+	t.Run("templates", func(t *testing.T) {
+		subject := goTemplateProcessor{}
+		flags := []string{"--go-template", "foo", "--go-template=bar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expectedNode := &library.ScenarioNode{
+			Name:        "passthrough gotemplate",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets: []library.Snippet{
+				{
+					Path: "foo",
+					Processor: library.Processor{
+						Type:    library.GoTemplate,
+						Options: map[string]interface{}{},
+					},
+				},
+				{
+					Path: "bar",
+					Processor: library.Processor{
+						Type:    library.GoTemplate,
+						Options: map[string]interface{}{},
+					},
+				},
+			},
+		}
+		if !cmp.Equal(*expectedNode, *node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", *expectedNode, *node)
+		}
+
+		expectedRemainder := []string{}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("ignore other flags", func(t *testing.T) {
+		subject := goTemplateProcessor{}
+		flags := []string{"-ofoo", "-vbar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		var expectedNode *library.ScenarioNode
+		if !cmp.Equal(expectedNode, node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNode, node)
+		}
+
+		expectedRemainder := []string{"-ofoo", "-vbar"}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		subject := goTemplateProcessor{}
+		flags := []string{"--go-template"}
+		_, _, err := subject.ParsePassthroughFlags(flags)
+
+		expectedError := "expected argument for flag `--go-template'\n  while trying to parse go template args"
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name         string
+		content      string
+		expectedHint processor.SnippetHint
+	}{
+		{
+			name:    "template",
+			content: "instance_groups:\n{{- range split \",\" .Vars.groups }}\n- name: {{ . }}\n{{- end }}\n",
+			expectedHint: processor.SnippetHint{
+				Valid:   true,
+				Element: "/",
+				Action:  "render",
+				Paths:   []string{"/"},
+			},
+		},
+		{
+			name:         "unknown function",
+			content:      "foo: {{ bar }}\n",
+			expectedHint: processor.SnippetHint{},
+		},
+		{
+			name:         "syntax error",
+			content:      "foo: {{ .Vars.foo \n",
+			expectedHint: processor.SnippetHint{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFile := file.NewMockFileAccess(ctrl)
+
+			subject := NewGoTemplateProcessor(&yaml.Yaml{}, mockFile)
+
+			mockFile.EXPECT().Read("/foo").Times(1).Return([]byte(c.content), nil)
+
+			hint, err := subject.ValidateSnippet("/foo")
+
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+
+			if !cmp.Equal(c.expectedHint, hint) {
+				t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", c.expectedHint, hint)
+			}
+		})
+	}
+
+	t.Run("file error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+
+		subject := NewGoTemplateProcessor(&yaml.Yaml{}, mockFile)
+
+		mockFile.EXPECT().Read("/foo").Times(1).Return(nil, errors.New("oops"))
+
+		hint, err := subject.ValidateSnippet("/foo")
+
+		expectedError := errors.New("oops\n  while validating go template /foo")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+
+		if hint.Valid {
+			t.Errorf("Expected ValidateSnippet to return false")
+		}
+	})
+}
+
+func TestProcessTemplate(t *testing.T) {
+
+	manifest := `name: deployment
+instance_groups:
+- name: worker
+  instances: 2
+`
+	cases := []struct {
+		name    string
+		in      string
+		snippet string
+		options map[string]interface{}
+		vars    map[string]interface{}
+
+		expectedError error
+		expectedOut   string
+	}{
+		{
+			name:        "no snippet",
+			in:          "foo: bar\n",
+			expectedOut: "foo: bar\n",
+		},
+		{
+			name: "merge loop",
+			in:   manifest,
+			snippet: `instance_groups:
+{{- range $i, $name := split "," .Vars.groups }}
+- name: {{ $name }}
+  instances: {{ add $i 1 }}
+{{- end }}
+`,
+			options:     map[string]interface{}{"merge_keys": map[string]interface{}{"/instance_groups": "name"}},
+			vars:        map[string]interface{}{"groups": "web,worker"},
+			expectedOut: "name: deployment\ninstance_groups:\n  - name: worker\n    instances: 2\n  - name: web\n    instances: 1\n",
+		},
+		{
+			name: "doc and helpers",
+			in:   manifest,
+			snippet: `name: {{ printf "%s-%s" .Doc.name (default "dev" .Vars.env) | quote }}
+tags: {{- dict "owner" (upper .Vars.owner) | toYaml | nindent 2 }}
+`,
+			vars:        map[string]interface{}{"owner": "ops"},
+			expectedOut: "name: deployment-dev\ninstance_groups:\n  - name: worker\n    instances: 2\ntags:\n    owner: OPS\n",
+		},
+		{
+			name: "opsfile",
+			in:   manifest,
+			snippet: `{{- range .Doc.instance_groups }}
+- type: replace
+  path: /instance_groups/name={{ .name }}/vm_type?
+  value: {{ $.Vars.vm_type }}
+{{- end }}
+`,
+			options:     map[string]interface{}{"apply": "opsfile"},
+			vars:        map[string]interface{}{"vm_type": "large"},
+			expectedOut: "instance_groups:\n- instances: 2\n  name: worker\n  vm_type: large\nname: deployment\n",
+		},
+		{
+			name:          "unknown apply",
+			in:            manifest,
+			snippet:       "foo: bar",
+			options:       map[string]interface{}{"apply": "jq"},
+			expectedError: errors.New("Option apply must be one of merge, opsfile, not jq\n  while validating processor options"),
+		},
+		{
+			name:          "path with merge",
+			in:            manifest,
+			snippet:       "foo: bar",
+			options:       map[string]interface{}{"path": "/foo"},
+			expectedError: errors.New("Unknown option path (expected merge_keys)\n  while validating options for the rendered snippet\n  while validating processor options"),
+		},
+		{
+			name:          "merge_keys with opsfile",
+			in:            manifest,
+			snippet:       "[]",
+			options:       map[string]interface{}{"apply": "opsfile", "merge_keys": map[string]interface{}{}},
+			expectedError: errors.New("Unknown option merge_keys (expected path)\n  while validating options for the rendered snippet\n  while validating processor options"),
+		},
+		{
+			name:          "parse error",
+			in:            manifest,
+			snippet:       "foo: {{ .Vars.foo",
+			expectedError: errors.New("template: snippet.tmpl:1: unclosed action\n  while trying to parse go template snippet.tmpl"),
+		},
+		{
+			name:          "required",
+			in:            manifest,
+			snippet:       "foo: {{ required \"foo is required\" .Vars.foo }}",
+			expectedError: errors.New("template: snippet.tmpl:1:8: executing \"snippet.tmpl\" at <required \"foo is required\" .Vars.foo>: error calling required: foo is required\n  while trying to render go template snippet.tmpl"),
+		},
+		{
+			name:          "rendered invalid yaml",
+			in:            manifest,
+			snippet:       "foo: {{ .Vars.foo }}\n  bar",
+			vars:          map[string]interface{}{"foo": "[1"},
+			expectedError: errors.New("yaml: line 2: did not find expected ',' or ']'\n  while unmarshalling yaml\n  while trying to parse merge patch snippet.tmpl\n  while trying to apply rendered go template snippet.tmpl"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			subject := NewGoTemplateProcessor(&yaml.Yaml{}, nil)
+
+			var snippet *file.TaggedBytes
+			if c.snippet != "" {
+				snippet = &file.TaggedBytes{Tag: "snippet.tmpl", Bytes: []byte(c.snippet)}
+			}
+			templateBytes, err := subject.ProcessTemplate(&file.TaggedBytes{Tag: "template.yml", Bytes: []byte(c.in)}, snippet, c.options, c.vars)
+
+			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
+			}
+
+			if err == nil && string(templateBytes) != c.expectedOut {
+				t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedOut, templateBytes)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package jq
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/itchyny/gojq"
+	"github.com/jessevdk/go-flags"
+)
+
+func NewJqProcessor(yaml yaml.YamlAccess, file file.FileAccess) processor.Processor {
+	return &jqProcessor{
+		yaml: yaml,
+		file: file,
+	}
+}
+
+type jqProcessor struct {
+	yaml yaml.YamlAccess
+	file file.FileAccess
+}
+
+type programFlags struct {
+	ProgramPaths []string `long:"jq" value-name:"PATH" description:"Apply a jq program from a file to the template"`
+}
+
+func (i *jqProcessor) ValidateSnippet(path string) (processor.SnippetHint, error) {
+	hint := processor.SnippetHint{
+		Valid: false,
+	}
+	content, err := i.file.Read(path)
+	if err != nil {
+		return hint, fmt.Errorf("%w\n  while validating jq program %s", err, path)
+	}
+	_, err = gojq.Parse(processor.SnippetText(i.yaml, content))
+	if err != nil {
+		return hint, nil
+	}
+	// a program can rewrite any part of the document
+	hint.Valid = true
+	hint.Element = "/"
+	hint.Action = "jq"
+	hint.Paths = []string{"/"}
+	return hint, nil
+}
+
+func (i *jqProcessor) ParsePassthroughFlags(args []string) (*library.ScenarioNode, []string, error) {
+	var node *library.ScenarioNode
+	programFlags := programFlags{}
+	remainder, err := flags.NewParser(&programFlags, flags.IgnoreUnknown).ParseArgs(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w\n  while trying to parse jq args", err)
+	}
+	if len(programFlags.ProgramPaths) > 0 {
+		snippets := []library.Snippet{}
+		for _, p := range programFlags.ProgramPaths {
+			snippets = append(snippets, library.Snippet{
+				Path: p,
+				Processor: library.Processor{
+					Type:    library.Jq,
+					Options: map[string]interface{}{},
+				},
+			})
+		}
+		node = &library.ScenarioNode{
+			Name:        "passthrough jq",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets:    snippets,
+		}
+	}
+	return node, remainder, nil
+}
+
+func (i *jqProcessor) Options() processor.OptionSchema {
+	return processor.OptionSchema{}
+}
+
+func (i *jqProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBytes *file.TaggedBytes, options map[string]interface{}, vars map[string]interface{}) ([]byte, error) {
+	err := i.Options().Validate(options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while validating processor options", err)
+	}
+	if snippetBytes == nil {
+		return templateBytes.Bytes, nil
+	}
+	program := processor.SnippetText(i.yaml, snippetBytes.Bytes)
+	_, err = gojq.Parse(program)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse jq program %s", err, snippetBytes.Tag)
+	}
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	varsBytes, err := json.Marshal(yaml.StringKeys(vars))
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to encode vars for jq program %s", err, snippetBytes.Tag)
+	}
+	// bind $vars around the program (newlines keep a trailing comment from hiding the closing paren)
+	query, err := gojq.Parse(fmt.Sprintf("%s as $vars | (\n%s\n)", varsBytes, program))
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse jq program %s", err, snippetBytes.Tag)
+	}
+
+	var template interface{}
+	err = i.yaml.Unmarshal(templateBytes.Bytes, &template)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse template %s", err, templateBytes.Tag)
+	}
+
+	results := []interface{}{}
+	iter := query.Run(yaml.StringKeys(template))
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := result.(error); ok {
+			return nil, fmt.Errorf("%w\n  while trying to apply jq program %s to template %s", err, snippetBytes.Tag, templateBytes.Tag)
+		}
+		results = append(results, result)
+	}
+	if len(results) != 1 {
+		return nil, fmt.Errorf("Expected jq program %s to produce one document but found %d", snippetBytes.Tag, len(results))
+	}
+
+	outBytes, err := i.yaml.Marshal(results[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to marshal template %s", err, templateBytes.Tag)
+	}
+	return outBytes, nil
+}
@@ -0,0 +1,252 @@
+package jq
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePassthroughFlags(t *testing.T) {
+
+	t.Run("programs", func(t *testing.T) {
+		subject := jqProcessor{}
+		flags := []string{"--jq", "foo", "--jq=bar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expectedNode := &library.ScenarioNode{
+			Name:        "passthrough jq",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets: []library.Snippet{
+				{
+					Path: "foo",
+					Processor: library.Processor{
+						Type:    library.Jq,
+						Options: map[string]interface{}{},
+					},
+				},
+				{
+					Path: "bar",
+					Processor: library.Processor{
+						Type:    library.Jq,
+						Options: map[string]interface{}{},
+					},
+				},
+			},
+		}
+		if !cmp.Equal(*expectedNode, *node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", *expectedNode, *node)
+		}
+
+		expectedRemainder := []string{}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("ignore other flags", func(t *testing.T) {
+		subject := jqProcessor{}
+		flags := []string{"-ofoo", "-vbar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		var expectedNode *library.ScenarioNode
+		if !cmp.Equal(expectedNode, node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNode, node)
+		}
+
+		expectedRemainder := []string{"-ofoo", "-vbar"}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		subject := jqProcessor{}
+		flags := []string{"--jq"}
+		_, _, err := subject.ParsePassthroughFlags(flags)
+
+		expectedError := "expected argument for flag `--jq'\n  while trying to parse jq args"
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name         string
+		content      string
+		expectedHint processor.SnippetHint
+	}{
+		{
+			name:    "program",
+			content: "def total: [.[].instances] | add;\n.instance_groups |= sort_by(.name)\n",
+			expectedHint: processor.SnippetHint{
+				Valid:   true,
+				Element: "/",
+				Action:  "jq",
+				Paths:   []string{"/"},
+			},
+		},
+		{
+			name:         "yaml",
+			content:      "foo: bar\n",
+			expectedHint: processor.SnippetHint{},
+		},
+		{
+			name:         "syntax error",
+			content:      ".foo |= (",
+			expectedHint: processor.SnippetHint{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFile := file.NewMockFileAccess(ctrl)
+
+			subject := NewJqProcessor(&yaml.Yaml{}, mockFile)
+
+			mockFile.EXPECT().Read("/foo").Times(1).Return([]byte(c.content), nil)
+
+			hint, err := subject.ValidateSnippet("/foo")
+
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+
+			if !cmp.Equal(c.expectedHint, hint) {
+				t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", c.expectedHint, hint)
+			}
+		})
+	}
+
+	t.Run("file error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+
+		subject := NewJqProcessor(&yaml.Yaml{}, mockFile)
+
+		mockFile.EXPECT().Read("/foo").Times(1).Return(nil, errors.New("oops"))
+
+		hint, err := subject.ValidateSnippet("/foo")
+
+		expectedError := errors.New("oops\n  while validating jq program /foo")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+
+		if hint.Valid {
+			t.Errorf("Expected ValidateSnippet to return false")
+		}
+	})
+}
+
+func TestProcessTemplate(t *testing.T) {
+
+	manifest := `name: deployment
+instance_groups:
+- name: worker
+  instances: 2
+- name: web
+  instances: 1
+`
+	cases := []struct {
+		name    string
+		in      string
+		snippet string
+		vars    map[string]interface{}
+
+		expectedError error
+		expectedOut   string
+	}{
+		{
+			name:        "no snippet",
+			in:          "foo: bar\n",
+			expectedOut: "foo: bar\n",
+		},
+		{
+			name:        "sort and compute",
+			in:          manifest,
+			snippet:     ".instance_groups |= sort_by(.name)\n| .total_instances = ([.instance_groups[].instances] | add) # sum\n",
+			expectedOut: "instance_groups:\n  - instances: 1\n    name: web\n  - instances: 2\n    name: worker\nname: deployment\ntotal_instances: 3\n",
+		},
+		{
+			name:        "vars",
+			in:          manifest,
+			snippet:     ".name = $vars.name | .tags = $vars.tags",
+			vars:        map[string]interface{}{"name": "other", "tags": map[interface{}]interface{}{"env": "dev"}},
+			expectedOut: "instance_groups:\n  - instances: 2\n    name: worker\n  - instances: 1\n    name: web\nname: other\ntags:\n    env: dev\n",
+		},
+		{
+			name:        "empty template",
+			in:          "",
+			snippet:     "{foo: ($vars.foo // \"default\")}",
+			expectedOut: "foo: default\n",
+		},
+		{
+			name:        "interpolated snippet",
+			in:          "foo: bar\n",
+			snippet:     "'.foo |= ascii_upcase'\n",
+			expectedOut: "foo: BAR\n",
+		},
+		{
+			name:          "multiple outputs",
+			in:            manifest,
+			snippet:       ".instance_groups[]",
+			expectedError: errors.New("Expected jq program snippet.jq to produce one document but found 2"),
+		},
+		{
+			name:          "runtime error",
+			in:            manifest,
+			snippet:       ".name + 1",
+			expectedError: errors.New("cannot add: string (\"deployment\") and number (1)\n  while trying to apply jq program snippet.jq to template template.yml"),
+		},
+		{
+			name:          "parse error",
+			in:            manifest,
+			snippet:       ".name = (",
+			expectedError: errors.New("<source>:1:7: unexpected token \"=\"\n  while trying to parse jq program snippet.jq"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			subject := NewJqProcessor(&yaml.Yaml{}, nil)
+
+			var snippet *file.TaggedBytes
+			if c.snippet != "" {
+				snippet = &file.TaggedBytes{Tag: "snippet.jq", Bytes: []byte(c.snippet)}
+			}
+			templateBytes, err := subject.ProcessTemplate(&file.TaggedBytes{Tag: "template.yml", Bytes: []byte(c.in)}, snippet, nil, c.vars)
+
+			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
+			}
+
+			if err == nil && string(templateBytes) != c.expectedOut {
+				t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedOut, templateBytes)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package jsonpatch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+)
+
+func NewPathBuilder() processor.PathBuilder {
+	return &jsonPatchPathBuilder{}
+}
+
+type jsonPatchPathBuilder struct{}
+
+func (pb *jsonPatchPathBuilder) Root() string {
+	return "/"
+}
+
+func (pb *jsonPatchPathBuilder) Append() string {
+	return "/-"
+}
+
+func (pb *jsonPatchPathBuilder) Index(index string) string {
+	return fmt.Sprintf("/%s", index)
+}
+
+func (pb *jsonPatchPathBuilder) Delimiter() string {
+	return "/"
+}
+
+// json pointers have no optional segments
+func (pb *jsonPatchPathBuilder) Safe() string {
+	return ""
+}
+
+// add creates or replaces a key and appends to arrays, but would insert at an index
+func (pb *jsonPatchPathBuilder) Marshal(path string, value interface{}) interface{} {
+	op := "add"
+	if strings.HasSuffix(path, "_index))") {
+		op = "replace"
+	}
+	return []map[string]interface{}{
+		{
+			"op":    op,
+			"path":  path,
+			"value": value,
+		},
+	}
+}
@@ -0,0 +1,304 @@
+package jsonpatch
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	y "gopkg.in/yaml.v3"
+)
+
+// a yaml node tree patched in place so key order and comments are kept
+type document struct {
+	root *y.Node
+}
+
+func (d *document) apply(op operation) error {
+	if op.Path == nil {
+		return fmt.Errorf("Missing path for %s operation", op.Op)
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add":
+		value, err := valueOf(op)
+		if err != nil {
+			return err
+		}
+		return d.add(path, value)
+	case "remove":
+		_, err = d.remove(path)
+		return err
+	case "replace":
+		value, err := valueOf(op)
+		if err != nil {
+			return err
+		}
+		return d.replace(path, value)
+	case "move":
+		from, err := fromOf(op)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(*op.Path, *op.From+"/") {
+			return fmt.Errorf("Unable to move %s into its own child %s", *op.From, *op.Path)
+		}
+		value, err := d.remove(from)
+		if err != nil {
+			return err
+		}
+		return d.add(path, value)
+	case "copy":
+		from, err := fromOf(op)
+		if err != nil {
+			return err
+		}
+		value, err := d.get(from)
+		if err != nil {
+			return err
+		}
+		return d.add(path, yaml.CopyNode(value))
+	case "test":
+		expected, err := valueOf(op)
+		if err != nil {
+			return err
+		}
+		actual, err := d.get(path)
+		if err != nil {
+			return err
+		}
+		equal, err := equalNodes(expected, actual)
+		if err != nil {
+			return err
+		}
+		if !equal {
+			return fmt.Errorf("Test failed: value at %s does not match", *op.Path)
+		}
+		return nil
+	default:
+		return fmt.Errorf("Unsupported json patch operation '%s'", op.Op)
+	}
+}
+
+func (d *document) get(path []string) (*y.Node, error) {
+	node := d.root
+	for i, token := range path {
+		if node == nil {
+			return nil, fmt.Errorf("Path %s not found", formatPointer(path[:i+1]))
+		}
+		node = yaml.ResolveAlias(node)
+		switch node.Kind {
+		case y.MappingNode:
+			index := yaml.KeyIndex(node, token)
+			if index < 0 {
+				return nil, fmt.Errorf("Path %s not found", formatPointer(path[:i+1]))
+			}
+			node = node.Content[index+1]
+		case y.SequenceNode:
+			index, err := elementIndex(token, len(node.Content)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while resolving %s", err, formatPointer(path[:i+1]))
+			}
+			node = node.Content[index]
+		default:
+			return nil, fmt.Errorf("Path %s not found", formatPointer(path[:i+1]))
+		}
+	}
+	if node == nil {
+		return nil, fmt.Errorf("Document is empty")
+	}
+	return node, nil
+}
+
+func (d *document) add(path []string, value *y.Node) error {
+	if len(path) == 0 {
+		d.root = value
+		return nil
+	}
+	parent, err := d.get(path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	parent = yaml.ResolveAlias(parent)
+	token := path[len(path)-1]
+	switch parent.Kind {
+	case y.MappingNode:
+		index := yaml.KeyIndex(parent, token)
+		if index < 0 {
+			parent.Content = append(parent.Content, &y.Node{Kind: y.ScalarNode, Tag: "!!str", Value: token}, value)
+		} else {
+			parent.Content[index+1] = value
+		}
+	case y.SequenceNode:
+		if token == "-" {
+			parent.Content = append(parent.Content, value)
+			return nil
+		}
+		index, err := elementIndex(token, len(parent.Content))
+		if err != nil {
+			return fmt.Errorf("%w\n  while adding %s", err, formatPointer(path))
+		}
+		parent.Content = append(parent.Content[:index], append([]*y.Node{value}, parent.Content[index:]...)...)
+	default:
+		return fmt.Errorf("Unable to add %s to a scalar", formatPointer(path))
+	}
+	return nil
+}
+
+func (d *document) remove(path []string) (*y.Node, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("Unable to remove the document root")
+	}
+	parent, err := d.get(path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	parent = yaml.ResolveAlias(parent)
+	token := path[len(path)-1]
+	switch parent.Kind {
+	case y.MappingNode:
+		index := yaml.KeyIndex(parent, token)
+		if index < 0 {
+			return nil, fmt.Errorf("Path %s not found", formatPointer(path))
+		}
+		value := parent.Content[index+1]
+		parent.Content = append(parent.Content[:index], parent.Content[index+2:]...)
+		return value, nil
+	case y.SequenceNode:
+		index, err := elementIndex(token, len(parent.Content)-1)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while removing %s", err, formatPointer(path))
+		}
+		value := parent.Content[index]
+		parent.Content = append(parent.Content[:index], parent.Content[index+1:]...)
+		return value, nil
+	}
+	return nil, fmt.Errorf("Path %s not found", formatPointer(path))
+}
+
+func (d *document) replace(path []string, value *y.Node) error {
+	if len(path) == 0 {
+		if d.root == nil {
+			return fmt.Errorf("Document is empty")
+		}
+		d.root = value
+		return nil
+	}
+	parent, err := d.get(path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	parent = yaml.ResolveAlias(parent)
+	token := path[len(path)-1]
+	switch parent.Kind {
+	case y.MappingNode:
+		index := yaml.KeyIndex(parent, token)
+		if index < 0 {
+			return fmt.Errorf("Path %s not found", formatPointer(path))
+		}
+		parent.Content[index+1] = value
+		return nil
+	case y.SequenceNode:
+		index, err := elementIndex(token, len(parent.Content)-1)
+		if err != nil {
+			return fmt.Errorf("%w\n  while replacing %s", err, formatPointer(path))
+		}
+		parent.Content[index] = value
+		return nil
+	}
+	return fmt.Errorf("Path %s not found", formatPointer(path))
+}
+
+func valueOf(op operation) (*y.Node, error) {
+	if op.Value.Kind == 0 {
+		return nil, fmt.Errorf("Missing value for %s operation at %s", op.Op, *op.Path)
+	}
+	return yaml.CopyNode(&op.Value), nil
+}
+
+func fromOf(op operation) ([]string, error) {
+	if op.From == nil {
+		return nil, fmt.Errorf("Missing from for %s operation at %s", op.Op, *op.Path)
+	}
+	return parsePointer(*op.From)
+}
+
+// RFC 6901 pointer tokens with ~1 and ~0 unescaped
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("Invalid pointer '%s': must be empty or start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func formatPointer(tokens []string) string {
+	pointer := ""
+	for _, token := range tokens {
+		pointer = pointer + "/" + strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	}
+	return pointer
+}
+
+// array indices are decimal without leading zeros, and at most max
+func elementIndex(token string, max int) (int, error) {
+	if token == "-" {
+		return 0, fmt.Errorf("Index '-' refers to a nonexistent element")
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("Invalid array index '%s'", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("Array index %d out of bounds", index)
+	}
+	return index, nil
+}
+
+func equalNodes(a *y.Node, b *y.Node) (bool, error) {
+	var aValue, bValue interface{}
+	err := a.Decode(&aValue)
+	if err != nil {
+		return false, err
+	}
+	err = b.Decode(&bValue)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(normalize(aValue), normalize(bValue)), nil
+}
+
+// json does not distinguish integers and floats
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+	case map[interface{}]interface{}:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalize(e)
+		}
+	}
+	return value
+}
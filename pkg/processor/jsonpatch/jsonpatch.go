@@ -0,0 +1,156 @@
+package jsonpatch
+
+import (
+	"fmt"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/jessevdk/go-flags"
+	y "gopkg.in/yaml.v3"
+)
+
+func NewJsonPatchProcessor(yaml yaml.YamlAccess, file file.FileAccess) processor.Processor {
+	return &jsonPatchProcessor{
+		yaml: yaml,
+		file: file,
+	}
+}
+
+type jsonPatchProcessor struct {
+	yaml yaml.YamlAccess
+	file file.FileAccess
+}
+
+// RFC 6902 operation
+type operation struct {
+	Op    string  `yaml:"op"`
+	Path  *string `yaml:"path"`
+	From  *string `yaml:"from,omitempty"`
+	Value y.Node  `yaml:"value,omitempty"`
+}
+
+type patchFlags struct {
+	PatchPaths []string `long:"json-patch" value-name:"PATH" description:"Load RFC 6902 JSON patch operations from a JSON or YAML file"`
+}
+
+func (i *jsonPatchProcessor) ValidateSnippet(path string) (processor.SnippetHint, error) {
+	hint := processor.SnippetHint{
+		Valid: false,
+	}
+	content, err := i.file.Read(path)
+	if err != nil {
+		return hint, fmt.Errorf("%w\n  while validating json patch %s", err, path)
+	}
+	ops := []operation{}
+	err = i.yaml.Unmarshal(content, &ops)
+	if err != nil || len(ops) == 0 {
+		return hint, nil
+	}
+	for _, op := range ops {
+		if !supported(op.Op) || op.Path == nil {
+			return hint, nil
+		}
+	}
+	hint.Valid = true
+	hint.Element = *ops[0].Path
+	hint.Action = ops[0].Op
+	hint.Paths = []string{}
+	for _, op := range ops {
+		hint.Paths = append(hint.Paths, processor.NormalizePointer(*op.Path))
+		if op.Op == "move" && op.From != nil {
+			hint.Paths = append(hint.Paths, processor.NormalizePointer(*op.From))
+		}
+	}
+	return hint, nil
+}
+
+func (i *jsonPatchProcessor) ParsePassthroughFlags(args []string) (*library.ScenarioNode, []string, error) {
+	var node *library.ScenarioNode
+	patchFlags := patchFlags{}
+	remainder, err := flags.NewParser(&patchFlags, flags.IgnoreUnknown).ParseArgs(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w\n  while trying to parse json patch args", err)
+	}
+	if len(patchFlags.PatchPaths) > 0 {
+		snippets := []library.Snippet{}
+		for _, p := range patchFlags.PatchPaths {
+			snippets = append(snippets, library.Snippet{
+				Path: p,
+				Processor: library.Processor{
+					Type:    library.JsonPatch,
+					Options: map[string]interface{}{},
+				},
+			})
+		}
+		node = &library.ScenarioNode{
+			Name:        "passthrough jsonpatch",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets:    snippets,
+		}
+	}
+	return node, remainder, nil
+}
+
+func (i *jsonPatchProcessor) Options() processor.OptionSchema {
+	return processor.OptionSchema{}
+}
+
+func (i *jsonPatchProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBytes *file.TaggedBytes, options map[string]interface{}, vars map[string]interface{}) ([]byte, error) {
+	err := i.Options().Validate(options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while validating processor options", err)
+	}
+	if snippetBytes == nil {
+		return templateBytes.Bytes, nil
+	}
+	ops := []operation{}
+	err = i.yaml.Unmarshal(snippetBytes.Bytes, &ops)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse json patch %s", err, snippetBytes.Tag)
+	}
+	if len(ops) == 0 {
+		return templateBytes.Bytes, nil
+	}
+
+	template := &y.Node{}
+	err = i.yaml.Unmarshal(templateBytes.Bytes, template)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse template %s", err, templateBytes.Tag)
+	}
+	doc := &document{}
+	if len(template.Content) > 0 {
+		doc.root = template.Content[0]
+	}
+
+	for index, op := range ops {
+		err = doc.apply(op)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while trying to apply op %d from %s to template %s", err, index, snippetBytes.Tag, templateBytes.Tag)
+		}
+	}
+
+	if doc.root == nil {
+		return []byte{}, nil
+	}
+	if len(template.Content) > 0 {
+		template.Content[0] = doc.root
+	} else {
+		template = doc.root
+	}
+	outBytes, err := i.yaml.Marshal(template)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to marshal patched template %s", err, templateBytes.Tag)
+	}
+	return outBytes, nil
+}
+
+func supported(op string) bool {
+	switch op {
+	case "add", "remove", "replace", "move", "copy", "test":
+		return true
+	}
+	return false
+}
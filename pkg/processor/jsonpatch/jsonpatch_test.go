@@ -0,0 +1,269 @@
+package jsonpatch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePassthroughFlags(t *testing.T) {
+
+	t.Run("json patches", func(t *testing.T) {
+		subject := jsonPatchProcessor{}
+		flags := []string{"--json-patch", "foo", "--json-patch=bar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expectedNode := &library.ScenarioNode{
+			Name:        "passthrough jsonpatch",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets: []library.Snippet{
+				{
+					Path: "foo",
+					Processor: library.Processor{
+						Type:    library.JsonPatch,
+						Options: map[string]interface{}{},
+					},
+				},
+				{
+					Path: "bar",
+					Processor: library.Processor{
+						Type:    library.JsonPatch,
+						Options: map[string]interface{}{},
+					},
+				},
+			},
+		}
+		if !cmp.Equal(*expectedNode, *node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", *expectedNode, *node)
+		}
+
+		expectedRemainder := []string{}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("ignore other flags", func(t *testing.T) {
+		subject := jsonPatchProcessor{}
+		flags := []string{"-ofoo", "-vbar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		var expectedNode *library.ScenarioNode
+		if !cmp.Equal(expectedNode, node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNode, node)
+		}
+
+		expectedRemainder := []string{"-ofoo", "-vbar"}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		subject := jsonPatchProcessor{}
+		flags := []string{"--json-patch"}
+		_, _, err := subject.ParsePassthroughFlags(flags)
+
+		expectedError := "expected argument for flag `--json-patch'\n  while trying to parse json patch args"
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name         string
+		content      string
+		expectedHint processor.SnippetHint
+	}{
+		{
+			name:    "valid json patch",
+			content: `[{"op": "add", "path": "/bar", "value": 1}, {"op": "move", "from": "/baz/0", "path": "/qux/-"}]`,
+			expectedHint: processor.SnippetHint{
+				Valid:   true,
+				Element: "/bar",
+				Action:  "add",
+				Paths:   []string{"/bar", "/qux/-", "/baz/0"},
+			},
+		},
+		{
+			name:         "opsfile",
+			content:      "- type: replace\n  path: /bar\n  value: 1\n",
+			expectedHint: processor.SnippetHint{},
+		},
+		{
+			name:         "unsupported op",
+			content:      "- op: merge\n  path: /bar\n",
+			expectedHint: processor.SnippetHint{},
+		},
+		{
+			name:         "not a list",
+			content:      "bar: 1\n",
+			expectedHint: processor.SnippetHint{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFile := file.NewMockFileAccess(ctrl)
+
+			subject := NewJsonPatchProcessor(&yaml.Yaml{}, mockFile)
+
+			mockFile.EXPECT().Read("/foo").Times(1).Return([]byte(c.content), nil)
+
+			hint, err := subject.ValidateSnippet("/foo")
+
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+
+			if !cmp.Equal(c.expectedHint, hint) {
+				t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", c.expectedHint, hint)
+			}
+		})
+	}
+
+	t.Run("file error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+
+		subject := NewJsonPatchProcessor(&yaml.Yaml{}, mockFile)
+
+		mockFile.EXPECT().Read("/foo").Times(1).Return(nil, errors.New("oops"))
+
+		hint, err := subject.ValidateSnippet("/foo")
+
+		expectedError := errors.New("oops\n  while validating json patch /foo")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+
+		if hint.Valid {
+			t.Errorf("Expected ValidateSnippet to return false")
+		}
+	})
+}
+
+func TestProcessTemplate(t *testing.T) {
+
+	validTemplate := "foo: bar\nlist:\n- a\n- b\n"
+	cases := []struct {
+		name    string
+		in      *file.TaggedBytes
+		snippet *file.TaggedBytes
+
+		expectedError error
+		expectedOut   []byte
+	}{
+		{
+			name:        "no snippet",
+			in:          &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(validTemplate)},
+			expectedOut: []byte(validTemplate),
+		},
+		{
+			name:        "add key",
+			in:          &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(validTemplate)},
+			snippet:     &file.TaggedBytes{Tag: "patch.json", Bytes: []byte(`[{"op": "add", "path": "/bizz", "value": {"a~b": [1, 2]}}]`)},
+			expectedOut: []byte("foo: bar\nlist:\n  - a\n  - b\nbizz:\n    a~b:\n      - 1\n      - 2\n"),
+		},
+		{
+			name:        "add array elements",
+			in:          &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(validTemplate)},
+			snippet:     &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("- op: add\n  path: /list/-\n  value: c\n- op: add\n  path: /list/0\n  value: z\n")},
+			expectedOut: []byte("foo: bar\nlist:\n  - z\n  - a\n  - b\n  - c\n"),
+		},
+		{
+			name:        "remove and replace",
+			in:          &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(validTemplate)},
+			snippet:     &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("- op: remove\n  path: /list/0\n- op: replace\n  path: /foo\n  value: baz\n")},
+			expectedOut: []byte("foo: baz\nlist:\n  - b\n"),
+		},
+		{
+			name:        "move and copy",
+			in:          &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(validTemplate)},
+			snippet:     &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("- op: copy\n  from: /list\n  path: /copied\n- op: move\n  from: /foo\n  path: /copied/1\n")},
+			expectedOut: []byte("list:\n  - a\n  - b\ncopied:\n  - a\n  - bar\n  - b\n"),
+		},
+		{
+			name:        "escaped pointer",
+			in:          &file.TaggedBytes{Tag: "template.yml", Bytes: []byte("a/b:\n  c~d: 1\n")},
+			snippet:     &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("- op: replace\n  path: /a~1b/c~0d\n  value: 2\n")},
+			expectedOut: []byte("a/b:\n    c~d: 2\n"),
+		},
+		{
+			name:        "passing test",
+			in:          &file.TaggedBytes{Tag: "template.yml", Bytes: []byte("count: 1\n")},
+			snippet:     &file.TaggedBytes{Tag: "patch.json", Bytes: []byte(`[{"op": "test", "path": "/count", "value": 1.0}]`)},
+			expectedOut: []byte("count: 1\n"),
+		},
+		{
+			name:          "failing test",
+			in:            &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(validTemplate)},
+			snippet:       &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("- op: test\n  path: /foo\n  value: baz\n")},
+			expectedError: errors.New("Test failed: value at /foo does not match\n  while trying to apply op 0 from patch.yml to template template.yml"),
+		},
+		{
+			name:          "missing path",
+			in:            &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(validTemplate)},
+			snippet:       &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("- op: add\n  path: /a/b\n  value: c\n")},
+			expectedError: errors.New("Path /a not found\n  while trying to apply op 0 from patch.yml to template template.yml"),
+		},
+		{
+			name:          "index out of bounds",
+			in:            &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(validTemplate)},
+			snippet:       &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("- op: replace\n  path: /list/2\n  value: c\n")},
+			expectedError: errors.New("Array index 2 out of bounds\n  while replacing /list/2\n  while trying to apply op 0 from patch.yml to template template.yml"),
+		},
+		{
+			name:          "move into child",
+			in:            &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(validTemplate)},
+			snippet:       &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("- op: move\n  from: /list\n  path: /list/0\n")},
+			expectedError: errors.New("Unable to move /list into its own child /list/0\n  while trying to apply op 0 from patch.yml to template template.yml"),
+		},
+		{
+			name:          "parse snippet error",
+			in:            &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(validTemplate)},
+			snippet:       &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("foo: bar")},
+			expectedError: errors.New("yaml: unmarshal errors:\n  line 1: cannot unmarshal !!map into []jsonpatch.operation\n  while unmarshalling yaml\n  while trying to parse json patch patch.yml"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			subject := NewJsonPatchProcessor(&yaml.Yaml{}, nil)
+
+			templateBytes, err := subject.ProcessTemplate(c.in, c.snippet, nil, nil)
+
+			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
+			}
+
+			if err == nil && !cmp.Equal(templateBytes, c.expectedOut) {
+				t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedOut, templateBytes)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package mergepatch
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	y "gopkg.in/yaml.v3"
+)
+
+// elements of a keyed list with '$patch: delete' are removed instead of merged
+const directive = "$patch"
+
+type merger struct {
+	mergeKeys map[string]string
+	patterns  []string
+}
+
+// when several patterns match a list the most specific one (a key instead of * at the first difference) is used
+func newMerger(mergeKeys map[string]string) *merger {
+	patterns := []string{}
+	for pattern := range mergeKeys {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return moreSpecific(patterns[i], patterns[j])
+	})
+	return &merger{mergeKeys: mergeKeys, patterns: patterns}
+}
+
+func moreSpecific(a string, b string) bool {
+	aTokens := strings.Split(a, "/")
+	bTokens := strings.Split(b, "/")
+	for i := 0; i < len(aTokens) && i < len(bTokens); i++ {
+		if aTokens[i] != "*" && bTokens[i] == "*" {
+			return true
+		}
+		if aTokens[i] == "*" && bTokens[i] != "*" {
+			return false
+		}
+	}
+	return a < b
+}
+
+// RFC 7396: mappings are merged recursively, null removes a key, anything else replaces the target
+func (m *merger) merge(target *y.Node, patch *y.Node, path []string) (*y.Node, error) {
+	patch = yaml.ResolveAlias(patch)
+	if target != nil {
+		target = yaml.ResolveAlias(target)
+	}
+	switch patch.Kind {
+	case y.MappingNode:
+		if target == nil || target.Kind != y.MappingNode {
+			target = &y.Node{Kind: y.MappingNode, Tag: "!!map"}
+		}
+		for i := 0; i+1 < len(patch.Content); i += 2 {
+			key := patch.Content[i]
+			value := patch.Content[i+1]
+			index := yaml.KeyIndex(target, key.Value)
+			if isNull(value) {
+				if index >= 0 {
+					target.Content = append(target.Content[:index], target.Content[index+2:]...)
+				}
+				continue
+			}
+			var existing *y.Node
+			if index >= 0 {
+				existing = target.Content[index+1]
+			}
+			merged, err := m.merge(existing, value, append(path, key.Value))
+			if err != nil {
+				return nil, err
+			}
+			if index >= 0 {
+				target.Content[index+1] = merged
+			} else {
+				target.Content = append(target.Content, yaml.CopyNode(key), merged)
+			}
+		}
+		return target, nil
+	case y.SequenceNode:
+		field, keyed := m.mergeKey(path)
+		if keyed && target != nil && target.Kind == y.SequenceNode {
+			return m.mergeList(target, patch, field, path)
+		}
+	}
+	return yaml.CopyNode(patch), nil
+}
+
+// patch elements replace or merge into the target element with the same field value, or are appended
+func (m *merger) mergeList(target *y.Node, patch *y.Node, field string, path []string) (*y.Node, error) {
+	for _, element := range patch.Content {
+		element = yaml.ResolveAlias(element)
+		id, identified := fieldValue(element, field)
+		if !identified {
+			target.Content = append(target.Content, yaml.CopyNode(element))
+			continue
+		}
+		action, _ := fieldValue(element, directive)
+		element = withoutField(element, directive)
+
+		index := -1
+		for i, candidate := range target.Content {
+			if value, ok := fieldValue(yaml.ResolveAlias(candidate), field); ok && value == id {
+				index = i
+				break
+			}
+		}
+		if action == "delete" {
+			if index >= 0 {
+				target.Content = append(target.Content[:index], target.Content[index+1:]...)
+			}
+			continue
+		}
+		if index < 0 {
+			index = len(target.Content)
+			target.Content = append(target.Content, nil)
+		}
+		merged, err := m.merge(target.Content[index], element, append(path, strconv.Itoa(index)))
+		if err != nil {
+			return nil, err
+		}
+		target.Content[index] = merged
+	}
+	return target, nil
+}
+
+func (m *merger) mergeKey(path []string) (string, bool) {
+	for _, pattern := range m.patterns {
+		if matches(pattern, path) {
+			return m.mergeKeys[pattern], true
+		}
+	}
+	return "", false
+}
+
+func matches(pattern string, path []string) bool {
+	tokens := []string{}
+	if pattern != "/" {
+		tokens = strings.Split(pattern[1:], "/")
+	}
+	if len(tokens) != len(path) {
+		return false
+	}
+	for i, token := range tokens {
+		if token != "*" && token != yaml.EscapeToken(path[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldValue(n *y.Node, field string) (string, bool) {
+	if n.Kind != y.MappingNode {
+		return "", false
+	}
+	index := yaml.KeyIndex(n, field)
+	if index < 0 || n.Content[index+1].Kind != y.ScalarNode {
+		return "", false
+	}
+	return n.Content[index+1].Value, true
+}
+
+func withoutField(n *y.Node, field string) *y.Node {
+	index := yaml.KeyIndex(n, field)
+	if index < 0 {
+		return n
+	}
+	c := *n
+	c.Content = append(append([]*y.Node{}, n.Content[:index]...), n.Content[index+2:]...)
+	return &c
+}
+
+func isNull(n *y.Node) bool {
+	return n.Kind == y.ScalarNode && n.Tag == "!!null"
+}
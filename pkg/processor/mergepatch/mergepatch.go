@@ -0,0 +1,178 @@
+package mergepatch
+
+import (
+	"fmt"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/jessevdk/go-flags"
+	y "gopkg.in/yaml.v3"
+)
+
+func NewMergePatchProcessor(yaml yaml.YamlAccess, file file.FileAccess) processor.Processor {
+	return &mergePatchProcessor{
+		yaml: yaml,
+		file: file,
+	}
+}
+
+type mergePatchProcessor struct {
+	yaml yaml.YamlAccess
+	file file.FileAccess
+}
+
+type patchFlags struct {
+	PatchPaths []string `long:"merge-patch" value-name:"PATH" description:"Merge a RFC 7396 merge patch from a JSON or YAML file"`
+}
+
+func (i *mergePatchProcessor) ValidateSnippet(path string) (processor.SnippetHint, error) {
+	hint := processor.SnippetHint{
+		Valid: false,
+	}
+	content, err := i.file.Read(path)
+	if err != nil {
+		return hint, fmt.Errorf("%w\n  while validating merge patch %s", err, path)
+	}
+	patch := &y.Node{}
+	err = i.yaml.Unmarshal(content, patch)
+	if err != nil || len(patch.Content) == 0 {
+		return hint, nil
+	}
+	root := patch.Content[0]
+	if root.Kind != y.MappingNode || len(root.Content) == 0 {
+		return hint, nil
+	}
+	hint.Valid = true
+	hint.Element = root.Content[0].Value
+	hint.Action = "merge"
+	hint.Paths = []string{}
+	collectPaths(root, "", &hint.Paths)
+	return hint, nil
+}
+
+// a merge patch only descends into mappings, anything else replaces the value at its path
+func collectPaths(n *y.Node, path string, paths *[]string) {
+	if n.Kind != y.MappingNode || len(n.Content) == 0 {
+		*paths = append(*paths, processor.NormalizePointer(path))
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		collectPaths(n.Content[i+1], fmt.Sprintf("%s/%s", path, yaml.EscapeToken(n.Content[i].Value)), paths)
+	}
+}
+
+func (i *mergePatchProcessor) ParsePassthroughFlags(args []string) (*library.ScenarioNode, []string, error) {
+	var node *library.ScenarioNode
+	patchFlags := patchFlags{}
+	remainder, err := flags.NewParser(&patchFlags, flags.IgnoreUnknown).ParseArgs(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w\n  while trying to parse merge patch args", err)
+	}
+	if len(patchFlags.PatchPaths) > 0 {
+		snippets := []library.Snippet{}
+		for _, p := range patchFlags.PatchPaths {
+			snippets = append(snippets, library.Snippet{
+				Path: p,
+				Processor: library.Processor{
+					Type:    library.MergePatch,
+					Options: map[string]interface{}{},
+				},
+			})
+		}
+		node = &library.ScenarioNode{
+			Name:        "passthrough mergepatch",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets:    snippets,
+		}
+	}
+	return node, remainder, nil
+}
+
+func (i *mergePatchProcessor) Options() processor.OptionSchema {
+	return processor.OptionSchema{
+		{Name: "merge_keys", Type: processor.MapOption},
+	}
+}
+
+func (i *mergePatchProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBytes *file.TaggedBytes, options map[string]interface{}, vars map[string]interface{}) ([]byte, error) {
+	err := i.Options().Validate(options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while validating processor options", err)
+	}
+	if snippetBytes == nil {
+		return templateBytes.Bytes, nil
+	}
+	mergeKeys, err := parseMergeKeys(options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse options for %s", err, snippetBytes.Tag)
+	}
+
+	patch := &y.Node{}
+	err = i.yaml.Unmarshal(snippetBytes.Bytes, patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse merge patch %s", err, snippetBytes.Tag)
+	}
+	if len(patch.Content) == 0 {
+		return templateBytes.Bytes, nil
+	}
+
+	template := &y.Node{}
+	err = i.yaml.Unmarshal(templateBytes.Bytes, template)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse template %s", err, templateBytes.Tag)
+	}
+	var target *y.Node
+	if len(template.Content) > 0 {
+		target = template.Content[0]
+	}
+
+	merger := newMerger(mergeKeys)
+	merged, err := merger.merge(target, patch.Content[0], []string{})
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to merge %s into template %s", err, snippetBytes.Tag, templateBytes.Tag)
+	}
+
+	if len(template.Content) > 0 {
+		template.Content[0] = merged
+	} else {
+		template = merged
+	}
+	outBytes, err := i.yaml.Marshal(template)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to marshal merged template %s", err, templateBytes.Tag)
+	}
+	return outBytes, nil
+}
+
+// merge_keys maps pointers of lists (* matches any key or index) to the field identifying their elements
+func parseMergeKeys(options map[string]interface{}) (map[string]string, error) {
+	mergeKeys := map[string]string{}
+	if options == nil || options["merge_keys"] == nil {
+		return mergeKeys, nil
+	}
+	switch keys := options["merge_keys"].(type) {
+	case map[string]interface{}:
+		for path, field := range keys {
+			f, ok := field.(string)
+			if !ok {
+				return nil, fmt.Errorf("merge_keys field for %s must be a string", path)
+			}
+			mergeKeys[processor.NormalizePointer(path)] = f
+		}
+	case map[interface{}]interface{}:
+		for path, field := range keys {
+			p, pOk := path.(string)
+			f, fOk := field.(string)
+			if !pOk || !fOk {
+				return nil, fmt.Errorf("merge_keys must map list paths to field names")
+			}
+			mergeKeys[processor.NormalizePointer(p)] = f
+		}
+	default:
+		return nil, fmt.Errorf("merge_keys must map list paths to field names")
+	}
+	return mergeKeys, nil
+}
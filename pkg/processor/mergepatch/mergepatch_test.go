@@ -0,0 +1,304 @@
+package mergepatch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePassthroughFlags(t *testing.T) {
+
+	t.Run("merge patches", func(t *testing.T) {
+		subject := mergePatchProcessor{}
+		flags := []string{"--merge-patch", "foo", "--merge-patch=bar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expectedNode := &library.ScenarioNode{
+			Name:        "passthrough mergepatch",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets: []library.Snippet{
+				{
+					Path: "foo",
+					Processor: library.Processor{
+						Type:    library.MergePatch,
+						Options: map[string]interface{}{},
+					},
+				},
+				{
+					Path: "bar",
+					Processor: library.Processor{
+						Type:    library.MergePatch,
+						Options: map[string]interface{}{},
+					},
+				},
+			},
+		}
+		if !cmp.Equal(*expectedNode, *node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", *expectedNode, *node)
+		}
+
+		expectedRemainder := []string{}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("ignore other flags", func(t *testing.T) {
+		subject := mergePatchProcessor{}
+		flags := []string{"-ofoo", "-vbar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		var expectedNode *library.ScenarioNode
+		if !cmp.Equal(expectedNode, node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNode, node)
+		}
+
+		expectedRemainder := []string{"-ofoo", "-vbar"}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		subject := mergePatchProcessor{}
+		flags := []string{"--merge-patch"}
+		_, _, err := subject.ParsePassthroughFlags(flags)
+
+		expectedError := "expected argument for flag `--merge-patch'\n  while trying to parse merge patch args"
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name         string
+		content      string
+		expectedHint processor.SnippetHint
+	}{
+		{
+			name:    "valid merge patch",
+			content: "foo:\n  bar: 1\n  baz: {}\nlist: [a]\n",
+			expectedHint: processor.SnippetHint{
+				Valid:   true,
+				Element: "foo",
+				Action:  "merge",
+				Paths:   []string{"/foo/bar", "/foo/baz", "/list"},
+			},
+		},
+		{
+			name:         "list",
+			content:      "- type: replace\n  path: /bar\n",
+			expectedHint: processor.SnippetHint{},
+		},
+		{
+			name:         "empty",
+			content:      "",
+			expectedHint: processor.SnippetHint{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFile := file.NewMockFileAccess(ctrl)
+
+			subject := NewMergePatchProcessor(&yaml.Yaml{}, mockFile)
+
+			mockFile.EXPECT().Read("/foo").Times(1).Return([]byte(c.content), nil)
+
+			hint, err := subject.ValidateSnippet("/foo")
+
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+
+			if !cmp.Equal(c.expectedHint, hint) {
+				t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", c.expectedHint, hint)
+			}
+		})
+	}
+
+	t.Run("file error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+
+		subject := NewMergePatchProcessor(&yaml.Yaml{}, mockFile)
+
+		mockFile.EXPECT().Read("/foo").Times(1).Return(nil, errors.New("oops"))
+
+		hint, err := subject.ValidateSnippet("/foo")
+
+		expectedError := errors.New("oops\n  while validating merge patch /foo")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+
+		if hint.Valid {
+			t.Errorf("Expected ValidateSnippet to return false")
+		}
+	})
+}
+
+func TestProcessTemplate(t *testing.T) {
+
+	manifest := `name: deployment
+instance_groups:
+- name: web
+  instances: 1
+  jobs:
+  - name: nginx
+    properties:
+      port: 80
+  - name: metrics
+- name: worker
+  instances: 2
+`
+	instanceGroups := map[string]interface{}{
+		"merge_keys": map[string]interface{}{
+			"/instance_groups":        "name",
+			"/instance_groups/*/jobs": "name",
+		},
+	}
+	cases := []struct {
+		name    string
+		in      *file.TaggedBytes
+		snippet *file.TaggedBytes
+		options map[string]interface{}
+
+		expectedError error
+		expectedOut   []byte
+	}{
+		{
+			name:        "no snippet",
+			in:          &file.TaggedBytes{Tag: "template.yml", Bytes: []byte("foo: bar\n")},
+			expectedOut: []byte("foo: bar\n"),
+		},
+		{
+			name:        "merge and remove keys",
+			in:          &file.TaggedBytes{Tag: "template.yml", Bytes: []byte("foo: bar\nnested:\n  a: 1\n  b: 2\n")},
+			snippet:     &file.TaggedBytes{Tag: "patch.json", Bytes: []byte(`{"foo": null, "nested": {"b": 3, "c": {"d": 4, "e": null}}}`)},
+			expectedOut: []byte("nested:\n    a: 1\n    b: 3\n    c:\n        d: 4\n"),
+		},
+		{
+			name:        "replace lists",
+			in:          &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(manifest)},
+			snippet:     &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("instance_groups:\n- name: web\n  instances: 3\n")},
+			expectedOut: []byte("name: deployment\ninstance_groups:\n  - name: web\n    instances: 3\n"),
+		},
+		{
+			name:    "merge lists by key",
+			in:      &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(manifest)},
+			snippet: &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("instance_groups:\n- name: web\n  instances: 3\n  jobs:\n  - name: nginx\n    properties:\n      tls: true\n  - name: logs\n- name: db\n")},
+			options: instanceGroups,
+			expectedOut: []byte(`name: deployment
+instance_groups:
+  - name: web
+    instances: 3
+    jobs:
+      - name: nginx
+        properties:
+            port: 80
+            tls: true
+      - name: metrics
+      - name: logs
+  - name: worker
+    instances: 2
+  - name: db
+`),
+		},
+		{
+			name:    "most specific merge key",
+			in:      &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(manifest)},
+			snippet: &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("instance_groups:\n- name: web\n  jobs:\n  - name: nginx\n    release: web\n")},
+			options: map[string]interface{}{
+				"merge_keys": map[string]interface{}{
+					"/instance_groups":        "name",
+					"/instance_groups/*/jobs": "name",
+					"/instance_groups/0/jobs": "release",
+					"/*/*/jobs":               "name",
+				},
+			},
+			expectedOut: []byte(`name: deployment
+instance_groups:
+  - name: web
+    instances: 1
+    jobs:
+      - name: nginx
+        properties:
+            port: 80
+      - name: metrics
+      - name: nginx
+        release: web
+  - name: worker
+    instances: 2
+`),
+		},
+		{
+			name:    "delete list elements",
+			in:      &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(manifest)},
+			snippet: &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("instance_groups:\n- name: web\n  jobs:\n  - name: metrics\n    $patch: delete\n- name: worker\n  $patch: delete\n")},
+			options: instanceGroups,
+			expectedOut: []byte(`name: deployment
+instance_groups:
+  - name: web
+    instances: 1
+    jobs:
+      - name: nginx
+        properties:
+            port: 80
+`),
+		},
+		{
+			name:          "invalid merge keys",
+			in:            &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(manifest)},
+			snippet:       &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("name: other\n")},
+			options:       map[string]interface{}{"merge_keys": "name"},
+			expectedError: errors.New("Option merge_keys must be a map, not string\n  while validating processor options"),
+		},
+		{
+			name:          "parse snippet error",
+			in:            &file.TaggedBytes{Tag: "template.yml", Bytes: []byte(manifest)},
+			snippet:       &file.TaggedBytes{Tag: "patch.yml", Bytes: []byte("foo: [")},
+			expectedError: errors.New("yaml: line 1: did not find expected node content\n  while unmarshalling yaml\n  while trying to parse merge patch patch.yml"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			subject := NewMergePatchProcessor(&yaml.Yaml{}, nil)
+
+			templateBytes, err := subject.ProcessTemplate(c.in, c.snippet, c.options, nil)
+
+			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
+			}
+
+			if err == nil && !cmp.Equal(templateBytes, c.expectedOut) {
+				t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedOut, templateBytes)
+			}
+		})
+	}
+}
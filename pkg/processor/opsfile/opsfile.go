@@ -42,10 +42,17 @@ func (i *opFileProcessor) ValidateSnippet(path string) (processor.SnippetHint, e
 	}
 	opDefs := []patch.OpDefinition{}
 	err = i.yaml.Unmarshal(content, &opDefs)
-	hint.Valid = err == nil && len(opDefs) > 0 && opDefs[0].Path != nil
+	// json patches also have paths, but their op key leaves the type empty
+	hint.Valid = err == nil && len(opDefs) > 0 && opDefs[0].Path != nil && opDefs[0].Type != ""
 	if hint.Valid {
 		hint.Element = *opDefs[0].Path
 		hint.Action = opDefs[0].Type
+		hint.Paths = []string{}
+		for _, opDef := range opDefs {
+			if opDef.Path != nil {
+				hint.Paths = append(hint.Paths, processor.NormalizePointer(*opDef.Path))
+			}
+		}
 	}
 	return hint, nil
 }
@@ -88,7 +95,17 @@ func (i *opFileProcessor) ParsePassthroughFlags(args []string) (*library.Scenari
 	return node, remainder, nil
 }
 
-func (i *opFileProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBytes *file.TaggedBytes, options map[string]interface{}) ([]byte, error) {
+func (i *opFileProcessor) Options() processor.OptionSchema {
+	return processor.OptionSchema{
+		{Name: "path", Type: processor.StringOption},
+	}
+}
+
+func (i *opFileProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBytes *file.TaggedBytes, options map[string]interface{}, vars map[string]interface{}) ([]byte, error) {
+	err := i.Options().Validate(options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while validating processor options", err)
+	}
 
 	ops := patch.Ops{}
 	if snippetBytes != nil {
@@ -104,13 +121,7 @@ func (i *opFileProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snipp
 		}
 	}
 
-	var findPath string
-	if options != nil {
-		pathInterface := options["path"]
-		if pathInterface != nil {
-			findPath = pathInterface.(string)
-		}
-	}
+	findPath, _ := options["path"].(string)
 
 	if len(ops) == 0 && findPath == "" {
 		return templateBytes.Bytes, nil
@@ -118,7 +129,6 @@ func (i *opFileProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snipp
 
 	template := boshtpl.NewTemplate(templateBytes.Bytes)
 	var outBytes []byte
-	var err error
 	for i, op := range ops {
 		outBytes, err = template.Evaluate(boshtpl.StaticVariables{}, op, boshtpl.EvaluateOpts{})
 		if err != nil {
@@ -187,11 +187,16 @@ func TestValidate(t *testing.T) {
 		subject := NewOpsFileProcessor(mockYaml, mockFile)
 
 		element := "/bar"
+		optional := "/baz?/name=qux/-"
 		opDefs := []patch.OpDefinition{
 			patch.OpDefinition{
 				Path: &element,
 				Type: "replace",
 			},
+			patch.OpDefinition{
+				Path: &optional,
+				Type: "replace",
+			},
 		}
 
 		mockFile.EXPECT().Read("/foo").Times(1).Return([]byte{1}, nil)
@@ -209,6 +214,7 @@ func TestValidate(t *testing.T) {
 			Valid:   true,
 			Element: "/bar",
 			Action:  "replace",
+			Paths:   []string{"/bar", "/baz/name=qux/-"},
 		}
 
 		if !cmp.Equal(expectedHint, hint) {
@@ -239,6 +245,38 @@ func TestValidate(t *testing.T) {
 		}
 	})
 
+	t.Run("missing type", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockYaml := yaml.NewMockYamlAccess(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+
+		subject := NewOpsFileProcessor(mockYaml, mockFile)
+
+		element := "/bar"
+		opDefs := []patch.OpDefinition{
+			patch.OpDefinition{
+				Path: &element,
+			},
+		}
+
+		mockFile.EXPECT().Read("/foo").Times(1).Return([]byte{1}, nil)
+		mockYaml.EXPECT().Unmarshal([]byte{1}, &[]patch.OpDefinition{}).Times(1).Return(nil).Do(func(bytes []byte, o *[]patch.OpDefinition) {
+			*o = opDefs
+		})
+
+		hint, err := subject.ValidateSnippet("/foo")
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		if hint.Valid {
+			t.Errorf("Expected ValidateSnippet to return false")
+		}
+	})
+
 	t.Run("file error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -360,6 +398,14 @@ func TestProcessTemplate(t *testing.T) {
 			processorOptions: map[string]interface{}{"path": "/nothing/there"},
 			expectedError:    errors.New("Expected to find a map key 'nothing' for path '/nothing' (found map keys: 'foo')\n  while trying to find path /nothing/there in template ../../../test/data/v2/template.yml"),
 		},
+		{
+			name:             "find path type",
+			in:               &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte(validTemplate)},
+			snippet:          nil,
+			opDefinitions:    []patch.OpDefinition{},
+			processorOptions: map[string]interface{}{"path": 1},
+			expectedError:    errors.New("Option path must be a string, not int\n  while validating processor options"),
+		},
 	}
 
 	for _, c := range cases {
@@ -379,7 +425,7 @@ func TestProcessTemplate(t *testing.T) {
 				})
 			}
 
-			templateBytes, err := subject.ProcessTemplate(c.in, c.snippet, c.processorOptions)
+			templateBytes, err := subject.ProcessTemplate(c.in, c.snippet, c.processorOptions, nil)
 
 			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
 				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type OptionType string
+
+const (
+	StringOption OptionType = "string"
+	BoolOption   OptionType = "bool"
+	IntOption    OptionType = "int"
+	ListOption   OptionType = "list"
+	MapOption    OptionType = "map"
+)
+
+type Option struct {
+	Name     string
+	Type     OptionType
+	Allowed  []string // values a string option is limited to, any if empty
+	Required bool
+}
+
+// the options a processor accepts
+type OptionSchema []Option
+
+// implemented by processors whose options can't be checked by their schema alone
+type OptionValidator interface {
+	ValidateOptions(options map[string]interface{}) error
+}
+
+// check options with the processor's own validation if it has any, or against its schema
+func ValidateOptions(p Processor, options map[string]interface{}) error {
+	if v, ok := p.(OptionValidator); ok {
+		return v.ValidateOptions(options)
+	}
+	return p.Options().Validate(options)
+}
+
+// unset (or null) options are only rejected if required
+func (s OptionSchema) Validate(options map[string]interface{}) error {
+	names := []string{}
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		option, found := s.find(name)
+		if !found {
+			return fmt.Errorf("Unknown option %s (expected %s)", name, s.names())
+		}
+		value := options[name]
+		if value == nil {
+			continue
+		}
+		if kind := kindOf(value); kind != option.Type {
+			return fmt.Errorf("Option %s must be a %s, not %s", name, option.Type, kind)
+		}
+		if len(option.Allowed) > 0 && !allowed(option.Allowed, value.(string)) {
+			return fmt.Errorf("Option %s must be one of %s, not %s", name, strings.Join(option.Allowed, ", "), value)
+		}
+	}
+	for _, option := range s {
+		if option.Required && options[option.Name] == nil {
+			return fmt.Errorf("Option %s is required", option.Name)
+		}
+	}
+	return nil
+}
+
+func (s OptionSchema) find(name string) (Option, bool) {
+	for _, option := range s {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return Option{}, false
+}
+
+func (s OptionSchema) names() string {
+	if len(s) == 0 {
+		return "no options"
+	}
+	names := []string{}
+	for _, option := range s {
+		names = append(names, option.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func allowed(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func kindOf(value interface{}) OptionType {
+	switch value.(type) {
+	case string:
+		return StringOption
+	case bool:
+		return BoolOption
+	case int, int64, uint64:
+		return IntOption
+	case []interface{}:
+		return ListOption
+	case map[string]interface{}, map[interface{}]interface{}:
+		return MapOption
+	case float64:
+		return "float"
+	}
+	return OptionType(fmt.Sprintf("%T", value))
+}
@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"testing"
+)
+
+func TestValidateOptions(t *testing.T) {
+	schema := OptionSchema{
+		{Name: "command", Type: StringOption, Allowed: []string{"read", "write"}},
+		{Name: "path", Type: StringOption, Required: true},
+		{Name: "append", Type: BoolOption},
+		{Name: "depth", Type: IntOption},
+		{Name: "args", Type: ListOption},
+		{Name: "keys", Type: MapOption},
+	}
+	cases := []struct {
+		name     string
+		options  map[string]interface{}
+		expected string
+	}{
+		{
+			name:    "valid",
+			options: map[string]interface{}{"command": "read", "path": "/foo", "append": true, "depth": 2, "args": []interface{}{"a"}, "keys": map[string]interface{}{"/list": "name"}},
+		},
+		{
+			name:    "yaml v2 map",
+			options: map[string]interface{}{"path": "/foo", "keys": map[interface{}]interface{}{"/list": "name"}},
+		},
+		{
+			name:    "null",
+			options: map[string]interface{}{"path": "/foo", "command": nil},
+		},
+		{
+			name:     "unknown",
+			options:  map[string]interface{}{"path": "/foo", "overwite": true},
+			expected: "Unknown option overwite (expected command, path, append, depth, args, keys)",
+		},
+		{
+			name:     "wrong type",
+			options:  map[string]interface{}{"path": "/foo", "append": "yes"},
+			expected: "Option append must be a bool, not string",
+		},
+		{
+			name:     "float",
+			options:  map[string]interface{}{"path": "/foo", "depth": 1.5},
+			expected: "Option depth must be a int, not float",
+		},
+		{
+			name:     "not allowed",
+			options:  map[string]interface{}{"path": "/foo", "command": "delete"},
+			expected: "Option command must be one of read, write, not delete",
+		},
+		{
+			name:     "missing",
+			options:  map[string]interface{}{},
+			expected: "Option path is required",
+		},
+		{
+			name:     "null required",
+			options:  map[string]interface{}{"path": nil},
+			expected: "Option path is required",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := schema.Validate(c.options)
+			actual := ""
+			if err != nil {
+				actual = err.Error()
+			}
+			if actual != c.expected {
+				t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", c.expected, actual)
+			}
+		})
+	}
+
+	t.Run("no options", func(t *testing.T) {
+		err := OptionSchema{}.Validate(map[string]interface{}{"path": "/foo"})
+		expected := "Unknown option path (expected no options)"
+		if err == nil || err.Error() != expected {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expected, err)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// go-patch style pointer without optional markers (e.g. /instance_groups/name=router/jobs)
+func NormalizePointer(pointer string) string {
+	tokens := []string{}
+	for _, token := range strings.Split(strings.Trim(pointer, "/"), "/") {
+		if token != "" {
+			tokens = append(tokens, strings.TrimSuffix(token, "?"))
+		}
+	}
+	return "/" + strings.Join(tokens, "/")
+}
+
+var varPattern = regexp.MustCompile(`\(\(([^()]+)\)\)`)
+
+// replace variables with known string values, leaving others unresolved
+func SubstituteVars(pointer string, vars map[string]interface{}) string {
+	substituted := varPattern.ReplaceAllStringFunc(pointer, func(v string) string {
+		if value, ok := vars[varPattern.FindStringSubmatch(v)[1]].(string); ok {
+			return value
+		}
+		return v
+	})
+	return NormalizePointer(substituted)
+}
+
+// true if modifying one pointer could modify the value at the other (i.e. one may contain the other)
+func PointersOverlap(a string, b string) bool {
+	aTokens := tokens(NormalizePointer(a))
+	bTokens := tokens(NormalizePointer(b))
+	for i := 0; i < len(aTokens) && i < len(bTokens); i++ {
+		if !tokensOverlap(aTokens[i], bTokens[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func tokens(pointer string) []string {
+	if pointer == "/" {
+		return []string{}
+	}
+	return strings.Split(pointer[1:], "/")
+}
+
+func tokensOverlap(a string, b string) bool {
+	// each append adds its own element
+	if a == "-" && b == "-" {
+		return false
+	}
+	if a == b || wildcard(a) || wildcard(b) {
+		return true
+	}
+	if strings.HasSuffix(a, "*") && strings.HasPrefix(b, strings.TrimSuffix(a, "*")) {
+		return true
+	}
+	if strings.HasSuffix(b, "*") && strings.HasPrefix(a, strings.TrimSuffix(b, "*")) {
+		return true
+	}
+	// an index and a key=value selector may refer to the same element
+	return element(a) && element(b) && (index(a) != index(b))
+}
+
+// unresolved variables and appended elements could be anything
+func wildcard(token string) bool {
+	return token == "*" || token == "-" || strings.Contains(token, "((")
+}
+
+func element(token string) bool {
+	return index(token) || strings.Contains(token, "=")
+}
+
+func index(token string) bool {
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
@@ -0,0 +1,72 @@
+package processor
+
+import (
+	"testing"
+)
+
+func TestNormalizePointer(t *testing.T) {
+	cases := map[string]string{
+		"":                   "/",
+		"/":                  "/",
+		"/foo?/bar":          "/foo/bar",
+		"foo/name=bar?/-":    "/foo/name=bar/-",
+		"/foo/((path))?/baz": "/foo/((path))/baz",
+	}
+	for input, expected := range cases {
+		actual := NormalizePointer(input)
+		if actual != expected {
+			t.Errorf("Expected %s for %s but was %s", expected, input, actual)
+		}
+	}
+}
+
+func TestPointersOverlap(t *testing.T) {
+	cases := []struct {
+		a        string
+		b        string
+		expected bool
+	}{
+		{"/foo", "/foo", true},
+		{"/foo", "/foo/bar", true},
+		{"/foo/bar", "/foo", true},
+		{"/foo/bar", "/foo/baz", false},
+		{"/", "/foo", true},
+		{"/foo?/bar", "/foo/bar", true},
+		{"/list/-", "/list/name=x/value", true},
+		{"/list/-", "/list/-", false},
+		{"/list/-/name", "/list/-", false},
+		{"/list/-", "/list", true},
+		{"/list/0", "/list/name=x", true},
+		{"/list/0", "/list/1", false},
+		{"/list/name=x", "/list/name=y", false},
+		{"/list/*/value", "/list/3/value", true},
+		{"/ba*", "/bar", true},
+		{"/ba*", "/foo", false},
+		{"/((path))", "/foo/bar", true},
+	}
+	for _, c := range cases {
+		actual := PointersOverlap(c.a, c.b)
+		if actual != c.expected {
+			t.Errorf("Expected %t for %s and %s", c.expected, c.a, c.b)
+		}
+	}
+}
+
+func TestSubstituteVars(t *testing.T) {
+	vars := map[string]interface{}{
+		"path":  "/foo?/bar",
+		"name":  "router",
+		"count": 1,
+	}
+	cases := map[string]string{
+		"/((path))":                  "/foo/bar",
+		"/groups/name=((name))/jobs": "/groups/name=router/jobs",
+		"/((count))/((missing))":     "/((count))/((missing))",
+	}
+	for input, expected := range cases {
+		actual := SubstituteVars(input, vars)
+		if actual != expected {
+			t.Errorf("Expected %s for %s but was %s", expected, input, actual)
+		}
+	}
+}
@@ -9,7 +9,10 @@ import (
 type Processor interface {
 	ValidateSnippet(path string) (SnippetHint, error)
 	ParsePassthroughFlags(args []string) (*library.ScenarioNode, []string, error)
-	ProcessTemplate(template *file.TaggedBytes, snippet *file.TaggedBytes, options map[string]interface{}) ([]byte, error)
+	// options accepted by ProcessTemplate
+	Options() OptionSchema
+	// vars are the interpolator values for the step by name
+	ProcessTemplate(template *file.TaggedBytes, snippet *file.TaggedBytes, options map[string]interface{}, vars map[string]interface{}) ([]byte, error)
 }
 
 type SnippetGenerator interface {
@@ -20,4 +23,5 @@ type SnippetHint struct {
 	Valid   bool
 	Element string
 	Action  string
+	Paths   []string // every path the snippet may modify, as normalized pointers
 }
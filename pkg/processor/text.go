@@ -0,0 +1,16 @@
+package processor
+
+import (
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	y "gopkg.in/yaml.v3"
+)
+
+// snippets are interpolated as yaml, so a plain text snippet (e.g. an expression) may arrive as a quoted string
+func SnippetText(yamlAccess yaml.YamlAccess, content []byte) string {
+	n := &y.Node{}
+	err := yamlAccess.Unmarshal(content, n)
+	if err == nil && len(n.Content) == 1 && n.Content[0].Kind == y.ScalarNode && n.Content[0].ShortTag() == "!!str" {
+		return n.Content[0].Value
+	}
+	return string(content)
+}
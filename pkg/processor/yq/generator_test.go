@@ -58,7 +58,7 @@ func TestGenerate(t *testing.T) {
 
 		serializedOps, err := subject.GenerateSnippets(schema)
 		if err != nil {
-			t.Errorf("Unexpected error %w", err)
+			t.Errorf("Unexpected error %v", err)
 		}
 		builder := strings.Builder{}
 
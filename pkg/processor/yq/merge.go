@@ -0,0 +1,185 @@
+package yq
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	y2 "github.com/mikefarah/yaml/v2"
+	"github.com/mikefarah/yq/v2/pkg/yqlib"
+)
+
+// merge a value into every subtree matching path, after nesting the value under prefix
+func mergeAt(yql yqlib.YqLib, template interface{}, value interface{}, path string, prefix string, overwriteFlag bool, appendFlag bool) (interface{}, error) {
+	if prefix != "" {
+		value = yql.PrefixPath(value, prefix)
+	}
+	segments := []string{}
+	if path != "" {
+		segments = yqlib.NewPathParser().ParsePath(path)
+	}
+	return mergeSegments(yql, template, segments, value, overwriteFlag, appendFlag)
+}
+
+func mergeSegments(yql yqlib.YqLib, node interface{}, segments []string, value interface{}, overwriteFlag bool, appendFlag bool) (interface{}, error) {
+	if len(segments) == 0 {
+		return mergeNode(yql, node, value, overwriteFlag, appendFlag)
+	}
+	head := segments[0]
+	tail := segments[1:]
+	if node == nil {
+		// missing subtrees are created, like yq write
+		if head == "+" || isIndex(head) || isSelector(head) {
+			node = []interface{}{}
+		} else {
+			node = y2.MapSlice{}
+		}
+	}
+	switch n := node.(type) {
+	case y2.MapSlice:
+		matched := false
+		for i := range n {
+			if matchesKey(head, n[i].Key) {
+				child, err := mergeSegments(yql, n[i].Value, tail, value, overwriteFlag, appendFlag)
+				if err != nil {
+					return nil, fmt.Errorf("%w\n  under %s", err, head)
+				}
+				n[i].Value = child
+				matched = true
+			}
+		}
+		if !matched && !strings.HasSuffix(head, "*") {
+			child, err := mergeSegments(yql, nil, tail, value, overwriteFlag, appendFlag)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  under %s", err, head)
+			}
+			n = append(n, y2.MapItem{Key: head, Value: child})
+		}
+		return n, nil
+	case []interface{}:
+		if head == "+" {
+			child, err := mergeSegments(yql, nil, tail, value, overwriteFlag, appendFlag)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  under [+]", err)
+			}
+			return append(n, child), nil
+		}
+		indexes, err := matchingIndexes(n, head)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range indexes {
+			child, err := mergeSegments(yql, n[i], tail, value, overwriteFlag, appendFlag)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  under [%s]", err, head)
+			}
+			n[i] = child
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("Cannot merge under %s of a %T", head, node)
+}
+
+// elements of a list matching an index, * or a key==value selector
+func matchingIndexes(list []interface{}, segment string) ([]int, error) {
+	indexes := []int{}
+	if segment == "*" {
+		for i := range list {
+			indexes = append(indexes, i)
+		}
+		return indexes, nil
+	}
+	if isIndex(segment) {
+		i, _ := strconv.Atoi(segment)
+		if i >= len(list) {
+			return nil, fmt.Errorf("Index %d out of range for a list of %d elements", i, len(list))
+		}
+		return []int{i}, nil
+	}
+	if isSelector(segment) {
+		parts := strings.SplitN(segment, "==", 2)
+		for i, element := range list {
+			entries, ok := element.(y2.MapSlice)
+			if !ok {
+				continue
+			}
+			for _, entry := range entries {
+				if fmt.Sprintf("%v", entry.Key) == parts[0] && fmt.Sprintf("%v", entry.Value) == parts[1] {
+					indexes = append(indexes, i)
+					break
+				}
+			}
+		}
+		if len(indexes) == 0 {
+			return nil, fmt.Errorf("No elements match [%s]", segment)
+		}
+		return indexes, nil
+	}
+	return nil, fmt.Errorf("Cannot merge under %s of a list", segment)
+}
+
+// the pointers a merge may modify: the prefix under path, or the top level keys of the value under path
+func mergePaths(path string, prefix string, value interface{}) []string {
+	base := "/"
+	if path != "" {
+		base = pointerFromPath(path)
+	}
+	if prefix != "" {
+		return []string{processor.NormalizePointer(base + pointerFromPath(prefix))}
+	}
+	entries, ok := value.(y2.MapSlice)
+	if !ok || len(entries) == 0 {
+		return []string{base}
+	}
+	paths := []string{}
+	for _, entry := range entries {
+		paths = append(paths, processor.NormalizePointer(base+"/"+yaml.EscapeToken(fmt.Sprintf("%v", entry.Key))))
+	}
+	return paths
+}
+
+// merge a value into a single node, converting to plain maps for the merge library
+func mergeNode(yql yqlib.YqLib, node interface{}, value interface{}, overwriteFlag bool, appendFlag bool) (interface{}, error) {
+	var err error
+	templateWrapper := map[interface{}]interface{}{}
+	templateWrapper["root"], err = remarshal(node, reflect.TypeOf(map[interface{}]interface{}{}))
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  converting template for merge", err)
+	}
+	snippetWrapper := map[interface{}]interface{}{}
+	snippetWrapper["root"], err = remarshal(value, reflect.TypeOf(map[interface{}]interface{}{}))
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  converting value for merge", err)
+	}
+	err = yql.Merge(&templateWrapper, snippetWrapper, overwriteFlag, appendFlag)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  merging value", err)
+	}
+	merged, err := remarshal(templateWrapper["root"], reflect.TypeOf(y2.MapSlice{}))
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  converting merged template", err)
+	}
+	return merged, nil
+}
+
+// same matching as yq: exact keys or a prefix ending in *
+func matchesKey(segment string, key interface{}) bool {
+	keyString := fmt.Sprintf("%v", key)
+	prefix := strings.TrimSuffix(segment, "*")
+	if prefix != segment {
+		return strings.HasPrefix(keyString, prefix)
+	}
+	return keyString == segment
+}
+
+func isIndex(segment string) bool {
+	i, err := strconv.Atoi(segment)
+	return err == nil && i >= 0
+}
+
+func isSelector(segment string) bool {
+	return strings.Contains(segment, "==")
+}
@@ -0,0 +1,130 @@
+package yq
+
+import (
+	"fmt"
+	"reflect"
+
+	y2 "github.com/mikefarah/yaml/v2"
+	"github.com/mikefarah/yq/v2/pkg/yqlib"
+)
+
+// one step of a yq script written as a list of operations
+type operation struct {
+	Command   string      `yaml:"command"`
+	Path      string      `yaml:"path,omitempty"`
+	Value     interface{} `yaml:"value,omitempty"`
+	Prefix    string      `yaml:"prefix,omitempty"`
+	Overwrite bool        `yaml:"overwrite,omitempty"`
+	Append    bool        `yaml:"append,omitempty"`
+}
+
+// a yq snippet is an operation list if it is a yaml sequence, otherwise it is a map of paths to write
+func isOperationList(content []byte) bool {
+	var parsed interface{}
+	err := y2.Unmarshal(content, &parsed)
+	if err != nil {
+		return false
+	}
+	_, ok := parsed.([]interface{})
+	return ok
+}
+
+func parseOperations(content []byte) ([]operation, error) {
+	y2.DefaultMapType = reflect.TypeOf(y2.MapSlice{})
+	ops := []operation{}
+	err := y2.UnmarshalStrict(content, &ops)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		err = op.validate()
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  in operation %d", err, i+1)
+		}
+	}
+	return ops, nil
+}
+
+func (op operation) validate() error {
+	switch op.Command {
+	case "write", "read", "delete":
+		if op.Path == "" {
+			return fmt.Errorf("%s operation requires a path", op.Command)
+		}
+	case "merge":
+		if op.Value == nil {
+			return fmt.Errorf("merge operation requires a value")
+		}
+	case "prefix":
+		if op.Prefix == "" {
+			return fmt.Errorf("prefix operation requires a prefix")
+		}
+	case "":
+		return fmt.Errorf("operation requires a command")
+	default:
+		return fmt.Errorf("Unsupported yq command %s", op.Command)
+	}
+	return nil
+}
+
+// the pointers an operation may modify
+func (op operation) paths() []string {
+	switch op.Command {
+	case "write", "delete":
+		return []string{pointerFromPath(op.Path)}
+	case "merge":
+		return mergePaths(op.Path, op.Prefix, op.Value)
+	}
+	// read and prefix replace the whole document
+	return []string{"/"}
+}
+
+func (op operation) element() string {
+	if op.Command == "prefix" {
+		return op.Prefix
+	}
+	return op.Path
+}
+
+func applyOperation(yql yqlib.YqLib, template interface{}, op operation) (interface{}, error) {
+	var err error
+	switch op.Command {
+	case "write":
+		template = yql.WritePath(template, op.Path, op.Value)
+	case "read":
+		template, err = yql.ReadPath(template, op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  reading path %s", err, op.Path)
+		}
+	case "delete":
+		template, err = yql.DeletePath(template, op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  deleting path %s", err, op.Path)
+		}
+	case "merge":
+		template, err = mergeAt(yql, template, op.Value, op.Path, op.Prefix, op.Overwrite, op.Append)
+		if err != nil {
+			return nil, err
+		}
+	case "prefix":
+		template = yql.PrefixPath(template, op.Prefix)
+	}
+	return template, nil
+}
+
+// round trip a value to change the type its maps are decoded as
+func remarshal(value interface{}, mapType reflect.Type) (interface{}, error) {
+	bytes, err := y2.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	previous := y2.DefaultMapType
+	y2.DefaultMapType = mapType
+	defer func() { y2.DefaultMapType = previous }()
+	var out interface{}
+	err = y2.Unmarshal(bytes, &out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/cjnosal/manifer/v2/pkg/file"
 	"github.com/cjnosal/manifer/v2/pkg/library"
 	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
 
 	"github.com/jessevdk/go-flags"
 	y2 "github.com/mikefarah/yaml/v2" // for MapSlice - replaced by Node in yaml v3
@@ -13,6 +14,7 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 type yqProcessor struct {
@@ -38,6 +40,9 @@ func (y *yqProcessor) ValidateSnippet(path string) (processor.SnippetHint, error
 	if err != nil {
 		return hint, fmt.Errorf("%w\n  while validating yq script %s", err, path)
 	}
+	if isOperationList(content) {
+		return operationsHint(content), nil
+	}
 	var rawCommands y2.MapSlice
 	err = y2.Unmarshal(content, &rawCommands)
 	if err != nil {
@@ -55,9 +60,46 @@ func (y *yqProcessor) ValidateSnippet(path string) (processor.SnippetHint, error
 	hint.Valid = true
 	hint.Element = rawCommands[0].Key.(string)
 	hint.Action = "write" // yq uses subcommands - generate makes write scripts, import doesn't know
+	hint.Paths = []string{}
+	for _, command := range rawCommands {
+		hint.Paths = append(hint.Paths, pointerFromPath(command.Key.(string)))
+	}
 	return hint, nil
 }
 
+// operation lists are valid if every operation has the settings its command needs
+func operationsHint(content []byte) processor.SnippetHint {
+	hint := processor.SnippetHint{
+		Valid: false,
+	}
+	ops, err := parseOperations(content)
+	if err != nil || len(ops) == 0 {
+		return hint
+	}
+	hint.Valid = true
+	hint.Element = ops[0].element()
+	hint.Action = ops[0].Command
+	hint.Paths = []string{}
+	for _, op := range ops {
+		hint.Paths = append(hint.Paths, op.paths()...)
+	}
+	return hint
+}
+
+// convert a yq path (a.b[0]."c.d"[+]) to a pointer (/a/b/0/c.d/-)
+func pointerFromPath(path string) string {
+	pointer := ""
+	for _, element := range yqlib.NewPathParser().ParsePath(path) {
+		if element == "+" {
+			element = "-"
+		}
+		// go-patch selectors use a single =
+		element = strings.Replace(element, "==", "=", 1)
+		pointer = pointer + "/" + yaml.EscapeToken(element)
+	}
+	return processor.NormalizePointer(pointer)
+}
+
 type scriptFlags struct {
 	// flag string copied from yq
 	ScriptPaths []string `long:"script" short:"s" value-name:"PATH" description:"yaml write script for updating yaml"`
@@ -93,7 +135,21 @@ func (i *yqProcessor) ParsePassthroughFlags(args []string) (*library.ScenarioNod
 	return node, remainder, nil
 }
 
-func (y *yqProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBytes *file.TaggedBytes, options map[string]interface{}) ([]byte, error) {
+func (y *yqProcessor) Options() processor.OptionSchema {
+	return processor.OptionSchema{
+		{Name: "command", Type: processor.StringOption, Allowed: []string{"write", "read", "delete", "merge", "prefix"}},
+		{Name: "path", Type: processor.StringOption},
+		{Name: "overwrite", Type: processor.BoolOption},
+		{Name: "append", Type: processor.BoolOption},
+		{Name: "prefix", Type: processor.StringOption},
+	}
+}
+
+func (y *yqProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBytes *file.TaggedBytes, options map[string]interface{}, vars map[string]interface{}) ([]byte, error) {
+	err := y.Options().Validate(options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while validating processor options", err)
+	}
 	yql := yqlib.NewYqLib(logging.MustGetLogger("yq"))
 	logging.SetLevel(logging.ERROR, "yq")
 	y2.DefaultMapType = reflect.TypeOf(y2.MapSlice{})
@@ -104,12 +160,24 @@ func (y *yqProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBy
 	}
 
 	var template interface{}
-	err := y2.Unmarshal(templateBytes.Bytes, &template)
+	err = y2.Unmarshal(templateBytes.Bytes, &template)
 	if err != nil {
 		return nil, fmt.Errorf("%w\n  unmarshaling template", err)
 	}
 
-	if command == "write" {
+	if command == "write" && snippetBytes != nil && isOperationList(snippetBytes.Bytes) {
+		ops, err := parseOperations(snippetBytes.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  parsing operations %s", err, snippetBytes.Tag)
+		}
+		for index, op := range ops {
+			template, err = applyOperation(yql, template, op)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  applying operation %d (%s) of %s", err, index+1, op.Command, snippetBytes.Tag)
+			}
+		}
+
+	} else if command == "write" {
 		if snippetBytes == nil {
 			return nil, fmt.Errorf("snippet required for write command")
 		}
@@ -155,18 +223,11 @@ func (y *yqProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBy
 		if snippetBytes == nil {
 			return nil, fmt.Errorf("snippet required for write command")
 		}
-		y2.DefaultMapType = reflect.TypeOf(map[interface{}]interface{}{})
-		err = y2.Unmarshal(templateBytes.Bytes, &template)
-		if err != nil {
-			return nil, fmt.Errorf("%w\n  re-unmarshaling template", err)
-		}
-
 		overwriteFlag := getOptionBool(options, "overwrite")
 		appendFlag := getOptionBool(options, "append")
-
-		// lib assumes top level map?
-		templateWrapper := map[interface{}]interface{}{}
-		templateWrapper["root"] = template
+		// merge under path (default root), optionally nesting the snippet under prefix first
+		path, _ := getOptionString(options, "path")
+		prefix, _ := getOptionString(options, "prefix")
 
 		var parsedSnippet interface{}
 		err := y2.Unmarshal(snippetBytes.Bytes, &parsedSnippet)
@@ -174,14 +235,10 @@ func (y *yqProcessor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBy
 			return nil, fmt.Errorf("%w\n  unmarshaling snippet", err)
 		}
 
-		snippetWrapper := map[interface{}]interface{}{}
-		snippetWrapper["root"] = parsedSnippet
-
-		err = yql.Merge(&templateWrapper, snippetWrapper, overwriteFlag, appendFlag)
+		template, err = mergeAt(yql, template, parsedSnippet, path, prefix, overwriteFlag, appendFlag)
 		if err != nil {
 			return nil, fmt.Errorf("%w\n  merging snippet %s", err, snippetBytes.Tag)
 		}
-		template = templateWrapper["root"]
 
 	} else if command == "prefix" {
 		prefix, prefixFound := getOptionString(options, "prefix")
@@ -216,26 +273,13 @@ func (s *byteWriter) Bytes() []byte {
 	return s.buffer.Bytes()
 }
 
+// options are checked against the schema before they are read
 func getOptionString(options map[string]interface{}, opt string) (string, bool) {
-	var optString string
-	if options != nil {
-		optInterface := options[opt]
-		if optInterface != nil {
-			optString = optInterface.(string)
-			return optString, true
-		}
-	}
-
-	return "", false
+	optString, found := options[opt].(string)
+	return optString, found
 }
 
 func getOptionBool(options map[string]interface{}, opt string) bool {
-	if options != nil {
-		optInterface := options[opt]
-		if optInterface != nil {
-			return optInterface.(bool)
-		}
-	}
-
-	return false
-}
\ No newline at end of file
+	optBool, _ := options[opt].(bool)
+	return optBool
+}
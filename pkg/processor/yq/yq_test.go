@@ -161,6 +161,10 @@ func TestValidate(t *testing.T) {
 				Key:   "bar",
 				Value: "asdf",
 			},
+			y2.MapItem{
+				Key:   "a.\"b.c\".e[0].d[+]",
+				Value: "asdf",
+			},
 		}
 		bytes, _ := y2.Marshal(commands)
 
@@ -176,6 +180,54 @@ func TestValidate(t *testing.T) {
 			Valid:   true,
 			Element: "bar",
 			Action:  "write",
+			Paths:   []string{"/bar", "/a/b.c/e/0/d/-"},
+		}
+
+		if !cmp.Equal(expectedHint, hint) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedHint, hint)
+		}
+	})
+
+	t.Run("valid operation list", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+
+		subject := NewYqProcessor(mockFile)
+
+		script := `
+- command: delete
+  path: a.b
+- command: write
+  path: c[+]
+  value: d
+- command: merge
+  value:
+    e: f
+    g: h
+- command: prefix
+  prefix: nest
+- command: merge
+  path: instance_groups[name==web]
+  prefix: properties
+  value:
+    port: 80
+`
+
+		mockFile.EXPECT().Read("/foo").Times(1).Return([]byte(script), nil)
+
+		hint, err := subject.ValidateSnippet("/foo")
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expectedHint := processor.SnippetHint{
+			Valid:   true,
+			Element: "a.b",
+			Action:  "delete",
+			Paths:   []string{"/a/b", "/c/-", "/e", "/g", "/", "/instance_groups/name=web/properties"},
 		}
 
 		if !cmp.Equal(expectedHint, hint) {
@@ -183,6 +235,27 @@ func TestValidate(t *testing.T) {
 		}
 	})
 
+	t.Run("invalid operation list", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+
+		subject := NewYqProcessor(mockFile)
+
+		mockFile.EXPECT().Read("/foo").Times(1).Return([]byte("- command: write\n  value: a\n"), nil)
+
+		hint, err := subject.ValidateSnippet("/foo")
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		if hint.Valid {
+			t.Errorf("Expected ValidateSnippet to return false")
+		}
+	})
+
 	t.Run("invalid yq script", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -310,6 +383,118 @@ func TestProcessTemplate(t *testing.T) {
 			snippet:       &file.TaggedBytes{Tag: "/originalsnippet", Bytes: []byte(invalidTemplate)},
 			expectedError: errors.New("yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `:::not ...` into yaml.MapSlice\n  unmarshaling snippet"),
 		},
+		{
+			name:    "misspelled option",
+			in:      &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte(validTemplate)},
+			snippet: &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("bizz: bazz")},
+			processorOptions: map[string]interface{}{
+				"command":  "merge",
+				"overwite": true,
+			},
+			expectedError: errors.New("Unknown option overwite (expected command, path, overwrite, append, prefix)\n  while validating processor options"),
+		},
+		{
+			name:    "option type",
+			in:      &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte(validTemplate)},
+			snippet: &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("bizz: bazz")},
+			processorOptions: map[string]interface{}{
+				"command": "merge",
+				"append":  "yes",
+			},
+			expectedError: errors.New("Option append must be a bool, not string\n  while validating processor options"),
+		},
+		{
+			name:    "merge under path",
+			in:      &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte("foo:\n  bar: baz\nbizz: buzz\n")},
+			snippet: &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("bar: new\nqux: quux")},
+			processorOptions: map[string]interface{}{
+				"command": "merge",
+				"path":    "foo",
+			},
+			expectedOut: []byte("foo:\n  bar: baz\n  qux: quux\nbizz: buzz\n"),
+		},
+		{
+			name:    "merge under selector",
+			in:      &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte("name: deployment\ninstance_groups:\n- name: web\n  jobs:\n  - name: nginx\n- name: worker\n  jobs: []\n")},
+			snippet: &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("- name: metrics")},
+			processorOptions: map[string]interface{}{
+				"command": "merge",
+				"path":    "instance_groups[name==web].jobs",
+				"append":  true,
+			},
+			expectedOut: []byte("name: deployment\ninstance_groups:\n- name: web\n  jobs:\n  - name: nginx\n  - name: metrics\n- name: worker\n  jobs: []\n"),
+		},
+		{
+			name:    "merge with prefix",
+			in:      &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte("name: deployment\ninstance_groups:\n- name: web\n  jobs:\n  - name: nginx\n- name: worker\n  jobs: []\n")},
+			snippet: &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("port: 80")},
+			processorOptions: map[string]interface{}{
+				"command": "merge",
+				"path":    "instance_groups[name==web].jobs[0]",
+				"prefix":  "properties.nginx",
+			},
+			expectedOut: []byte("name: deployment\ninstance_groups:\n- name: web\n  jobs:\n  - name: nginx\n    properties:\n      nginx:\n        port: 80\n- name: worker\n  jobs: []\n"),
+		},
+		{
+			name:    "merge creates path",
+			in:      &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte(validTemplate)},
+			snippet: &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("cc: true")},
+			processorOptions: map[string]interface{}{
+				"command": "merge",
+				"path":    "properties",
+			},
+			expectedOut: []byte("foo: bar\nproperties:\n  cc: true\n"),
+		},
+		{
+			name:    "merge selector without match",
+			in:      &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte("name: deployment\ninstance_groups:\n- name: web\n  jobs:\n  - name: nginx\n- name: worker\n  jobs: []\n")},
+			snippet: &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("- name: metrics")},
+			processorOptions: map[string]interface{}{
+				"command": "merge",
+				"path":    "instance_groups[name==db].jobs",
+			},
+			expectedError: errors.New("No elements match [name==db]\n  under instance_groups\n  merging snippet yq.yml"),
+		},
+		{
+			name:    "merge under scalar",
+			in:      &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte(validTemplate)},
+			snippet: &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("a: b")},
+			processorOptions: map[string]interface{}{
+				"command": "merge",
+				"path":    "foo.bar",
+			},
+			expectedError: errors.New("Cannot merge under bar of a string\n  under foo\n  merging snippet yq.yml"),
+		},
+		{
+			name:        "operation merge under path",
+			in:          &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte("name: deployment\ninstance_groups:\n- name: web\n  jobs:\n  - name: nginx\n- name: worker\n  jobs: []\n")},
+			snippet:     &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("- command: merge\n  path: instance_groups[name==worker]\n  prefix: properties\n  value:\n    queue: jobs\n")},
+			expectedOut: []byte("name: deployment\ninstance_groups:\n- name: web\n  jobs:\n  - name: nginx\n- jobs: []\n  name: worker\n  properties:\n    queue: jobs\n"),
+		},
+		{
+			name:        "operation list",
+			in:          &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte("foo: bar\nlist:\n- a\n")},
+			snippet:     &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("- command: write\n  path: list[+]\n  value: b\n- command: delete\n  path: foo\n- command: merge\n  value:\n    bizz: bazz\n    list: [c]\n  append: true\n- command: prefix\n  prefix: nest\n")},
+			expectedOut: []byte("nest:\n  bizz: bazz\n  list:\n  - a\n  - b\n  - c\n"),
+		},
+		{
+			name:        "operation list read",
+			in:          &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte("foo:\n  bar: baz\n")},
+			snippet:     &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("- command: read\n  path: foo\n- command: write\n  path: bizz\n  value: bazz\n")},
+			expectedOut: []byte("bar: baz\nbizz: bazz\n"),
+		},
+		{
+			name:          "operation missing path",
+			in:            &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte(validTemplate)},
+			snippet:       &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("- command: delete\n")},
+			expectedError: errors.New("delete operation requires a path\n  in operation 1\n  parsing operations yq.yml"),
+		},
+		{
+			name:          "operation unknown field",
+			in:            &file.TaggedBytes{Tag: "../../../test/data/v2/template.yml", Bytes: []byte(validTemplate)},
+			snippet:       &file.TaggedBytes{Tag: "yq.yml", Bytes: []byte("- command: write\n  path: a\n  valeu: b\n")},
+			expectedError: errors.New("yaml: unmarshal errors:\n  line 3: field valeu not found in type yq.operation\n  parsing operations yq.yml"),
+		},
 	}
 
 	for _, c := range cases {
@@ -322,7 +507,7 @@ func TestProcessTemplate(t *testing.T) {
 
 			subject := NewYqProcessor(mockFile)
 
-			templateBytes, err := subject.ProcessTemplate(c.in, c.snippet, c.processorOptions)
+			templateBytes, err := subject.ProcessTemplate(c.in, c.snippet, c.processorOptions, nil)
 
 			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
 				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
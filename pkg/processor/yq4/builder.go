@@ -0,0 +1,52 @@
+package yq4
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+)
+
+func NewPathBuilder() processor.PathBuilder {
+	return &yq4PathBuilder{}
+}
+
+type yq4PathBuilder struct{}
+
+func (pb *yq4PathBuilder) Root() string {
+	return "."
+}
+
+func (pb *yq4PathBuilder) Append() string {
+	return "[+]"
+}
+
+func (pb *yq4PathBuilder) Index(index string) string {
+	return fmt.Sprintf("[%s]", index)
+}
+
+func (pb *yq4PathBuilder) Delimiter() string {
+	return "."
+}
+
+func (pb *yq4PathBuilder) Safe() string {
+	return ""
+}
+
+// '[+]' marks an append, which becomes '+=' on the parent array
+func (pb *yq4PathBuilder) Marshal(path string, value interface{}) interface{} {
+	bytes, _ := json.Marshal(yaml.StringKeys(value))
+	if strings.HasSuffix(path, "[+]") {
+		return fmt.Sprintf("%s += [%s]", expressionPath(strings.TrimSuffix(path, "[+]")), bytes)
+	}
+	return fmt.Sprintf("%s = %s", expressionPath(path), bytes)
+}
+
+func expressionPath(path string) string {
+	if !strings.HasPrefix(path, ".") {
+		return "." + path
+	}
+	return path
+}
@@ -0,0 +1,89 @@
+package yq4
+
+import (
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/mikefarah/yq/v4/pkg/yqlib"
+)
+
+// pointers to everything the expression may modify. Paths that can't be followed
+// statically (e.g. '..' or variables) are reported as the root.
+func modifiedPaths(e *yqlib.ExpressionNode) []string {
+	paths := []string{}
+	seen := map[string]bool{}
+	for _, tokens := range modified(e, []string{}) {
+		p := "/"
+		if tokens != nil {
+			p = processor.NormalizePointer("/" + strings.Join(tokens, "/"))
+		}
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// base is the path of the input, or nil if unknown
+func modified(e *yqlib.ExpressionNode, base []string) [][]string {
+	if e == nil {
+		return [][]string{}
+	}
+	switch e.Operation.OperationType.Type {
+	case "ASSIGN", "ADD_ASSIGN", "ASSIGN_ATTRIBUTES", "ASSIGN_STYLE", "ASSIGN_TAG", "ASSIGN_COMMENT", "ASSIGN_ANCHOR", "ASSIGN_ALIAS":
+		target := appendTokens(base, pathOf(e.Lhs))
+		if e.Operation.UpdateAssign {
+			return append([][]string{target}, modified(e.Rhs, target)...)
+		}
+		return append([][]string{target}, modified(e.Rhs, base)...)
+	case "DELETE":
+		return [][]string{appendTokens(base, pathOf(e.Rhs))}
+	case "PIPE", "SHORT_PIPE":
+		return append(modified(e.Lhs, base), modified(e.Rhs, appendTokens(base, pathOf(e.Lhs)))...)
+	case "SELECT", "UNION", "ALTERNATIVE", "AND", "OR":
+		return append(modified(e.Lhs, base), modified(e.Rhs, base)...)
+	}
+	// values built from the input are copies, but nested expressions may modify anything
+	return append(modified(e.Lhs, nil), modified(e.Rhs, nil)...)
+}
+
+// the pointer tokens selected by a simple path expression, or nil
+func pathOf(e *yqlib.ExpressionNode) []string {
+	if e == nil {
+		return nil
+	}
+	switch e.Operation.OperationType.Type {
+	case "SELF", "SELECT", "ASSIGN", "ADD_ASSIGN", "DELETE":
+		return []string{}
+	case "TRAVERSE_PATH":
+		return []string{yaml.EscapeToken(e.Operation.StringValue)}
+	case "TRAVERSE_ARRAY":
+		return appendTokens(pathOf(e.Lhs), indexOf(e.Rhs))
+	case "PIPE", "SHORT_PIPE":
+		return appendTokens(pathOf(e.Lhs), pathOf(e.Rhs))
+	}
+	return nil
+}
+
+// '[]' iterates every element, '[key]' selects one
+func indexOf(e *yqlib.ExpressionNode) []string {
+	if e == nil || e.Lhs == nil {
+		return nil
+	}
+	switch e.Lhs.Operation.OperationType.Type {
+	case "EMPTY":
+		return []string{"*"}
+	case "VALUE":
+		return []string{yaml.EscapeToken(e.Lhs.Operation.StringValue)}
+	}
+	return nil
+}
+
+func appendTokens(base []string, tokens []string) []string {
+	if base == nil || tokens == nil {
+		return nil
+	}
+	return append(append([]string{}, base...), tokens...)
+}
@@ -0,0 +1,125 @@
+package yq4
+
+import (
+	"container/list"
+	"fmt"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/jessevdk/go-flags"
+	"github.com/mikefarah/yq/v4/pkg/yqlib"
+	logging "gopkg.in/op/go-logging.v1"
+	y "gopkg.in/yaml.v3"
+)
+
+func NewYq4Processor(yaml yaml.YamlAccess, file file.FileAccess) processor.Processor {
+	logging.SetLevel(logging.ERROR, "yq-lib")
+	return &yq4Processor{
+		yaml: yaml,
+		file: file,
+	}
+}
+
+type yq4Processor struct {
+	yaml yaml.YamlAccess
+	file file.FileAccess
+}
+
+type expressionFlags struct {
+	ExpressionPaths []string `long:"yq-expression" value-name:"PATH" description:"Evaluate a yq v4 expression from a file against the template"`
+}
+
+func (i *yq4Processor) ValidateSnippet(path string) (processor.SnippetHint, error) {
+	hint := processor.SnippetHint{
+		Valid: false,
+	}
+	content, err := i.file.Read(path)
+	if err != nil {
+		return hint, fmt.Errorf("%w\n  while validating yq expression %s", err, path)
+	}
+	e, err := yqlib.NewExpressionParser().ParseExpression(processor.SnippetText(i.yaml, content))
+	if err != nil {
+		return hint, nil
+	}
+	hint.Valid = true
+	hint.Action = "eval"
+	hint.Paths = modifiedPaths(e)
+	if len(hint.Paths) > 0 {
+		hint.Element = hint.Paths[0]
+	}
+	return hint, nil
+}
+
+func (i *yq4Processor) ParsePassthroughFlags(args []string) (*library.ScenarioNode, []string, error) {
+	var node *library.ScenarioNode
+	expressionFlags := expressionFlags{}
+	remainder, err := flags.NewParser(&expressionFlags, flags.IgnoreUnknown).ParseArgs(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w\n  while trying to parse yq4 args", err)
+	}
+	if len(expressionFlags.ExpressionPaths) > 0 {
+		snippets := []library.Snippet{}
+		for _, p := range expressionFlags.ExpressionPaths {
+			snippets = append(snippets, library.Snippet{
+				Path: p,
+				Processor: library.Processor{
+					Type:    library.Yq4,
+					Options: map[string]interface{}{},
+				},
+			})
+		}
+		node = &library.ScenarioNode{
+			Name:        "passthrough yq4",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets:    snippets,
+		}
+	}
+	return node, remainder, nil
+}
+
+func (i *yq4Processor) Options() processor.OptionSchema {
+	return processor.OptionSchema{}
+}
+
+func (i *yq4Processor) ProcessTemplate(templateBytes *file.TaggedBytes, snippetBytes *file.TaggedBytes, options map[string]interface{}, vars map[string]interface{}) ([]byte, error) {
+	err := i.Options().Validate(options)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while validating processor options", err)
+	}
+	if snippetBytes == nil {
+		return templateBytes.Bytes, nil
+	}
+	e, err := yqlib.NewExpressionParser().ParseExpression(processor.SnippetText(i.yaml, snippetBytes.Bytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse yq expression %s", err, snippetBytes.Tag)
+	}
+
+	template := &y.Node{}
+	err = i.yaml.Unmarshal(templateBytes.Bytes, template)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to parse template %s", err, templateBytes.Tag)
+	}
+	if len(template.Content) == 0 {
+		template = &y.Node{Kind: y.DocumentNode, Content: []*y.Node{{Kind: y.ScalarNode, Tag: "!!null"}}}
+	}
+
+	inputs := list.New()
+	inputs.PushBack(&yqlib.CandidateNode{Node: template})
+	results, err := yqlib.NewDataTreeNavigator().GetMatchingNodes(yqlib.Context{MatchingNodes: inputs}, e)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to evaluate %s against template %s", err, snippetBytes.Tag, templateBytes.Tag)
+	}
+	if results.MatchingNodes.Len() != 1 {
+		return nil, fmt.Errorf("Expected expression %s to produce one document but found %d", snippetBytes.Tag, results.MatchingNodes.Len())
+	}
+
+	out := results.MatchingNodes.Front().Value.(*yqlib.CandidateNode).Node
+	outBytes, err := i.yaml.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to marshal template %s", err, templateBytes.Tag)
+	}
+	return outBytes, nil
+}
@@ -0,0 +1,362 @@
+package yq4
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePassthroughFlags(t *testing.T) {
+
+	t.Run("expressions", func(t *testing.T) {
+		subject := yq4Processor{}
+		flags := []string{"--yq-expression", "foo", "--yq-expression=bar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expectedNode := &library.ScenarioNode{
+			Name:        "passthrough yq4",
+			Description: "args passed after --",
+			LibraryPath: "<cli>",
+			Snippets: []library.Snippet{
+				{
+					Path: "foo",
+					Processor: library.Processor{
+						Type:    library.Yq4,
+						Options: map[string]interface{}{},
+					},
+				},
+				{
+					Path: "bar",
+					Processor: library.Processor{
+						Type:    library.Yq4,
+						Options: map[string]interface{}{},
+					},
+				},
+			},
+		}
+		if !cmp.Equal(*expectedNode, *node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", *expectedNode, *node)
+		}
+
+		expectedRemainder := []string{}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("ignore other flags", func(t *testing.T) {
+		subject := yq4Processor{}
+		flags := []string{"-ofoo", "-vbar"}
+		node, remainder, err := subject.ParsePassthroughFlags(flags)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		var expectedNode *library.ScenarioNode
+		if !cmp.Equal(expectedNode, node) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedNode, node)
+		}
+
+		expectedRemainder := []string{"-ofoo", "-vbar"}
+		if err == nil && !cmp.Equal(remainder, expectedRemainder) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedRemainder, remainder)
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		subject := yq4Processor{}
+		flags := []string{"--yq-expression"}
+		_, _, err := subject.ParsePassthroughFlags(flags)
+
+		expectedError := "expected argument for flag `--yq-expression'\n  while trying to parse yq4 args"
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name         string
+		content      string
+		expectedHint processor.SnippetHint
+	}{
+		{
+			name:    "assignments and deletes",
+			content: ".foo.bar = \"x\" | del(.baz)\n",
+			expectedHint: processor.SnippetHint{
+				Valid:   true,
+				Element: "/foo/bar",
+				Action:  "eval",
+				Paths:   []string{"/foo/bar", "/baz"},
+			},
+		},
+		{
+			name:    "updates through select",
+			content: "(.jobs[] | select(.name == \"web\") | .instances) += 1",
+			expectedHint: processor.SnippetHint{
+				Valid:   true,
+				Element: "/jobs/*/instances",
+				Action:  "eval",
+				Paths:   []string{"/jobs/*/instances"},
+			},
+		},
+		{
+			name:    "nested updates",
+			content: "'.a[0] |= (.b.c = 1)'\n",
+			expectedHint: processor.SnippetHint{
+				Valid:   true,
+				Element: "/a/0",
+				Action:  "eval",
+				Paths:   []string{"/a/0", "/a/0/b/c"},
+			},
+		},
+		{
+			name:    "dynamic paths",
+			content: "(.. | select(tag == \"!!str\")) |= \"x\"",
+			expectedHint: processor.SnippetHint{
+				Valid:   true,
+				Element: "/",
+				Action:  "eval",
+				Paths:   []string{"/"},
+			},
+		},
+		{
+			name:    "read only",
+			content: ".foo | length",
+			expectedHint: processor.SnippetHint{
+				Valid:  true,
+				Action: "eval",
+				Paths:  []string{},
+			},
+		},
+		{
+			name:         "yaml",
+			content:      "foo: bar\n",
+			expectedHint: processor.SnippetHint{},
+		},
+		{
+			name:         "opsfile",
+			content:      "- type: replace\n  path: /bar\n",
+			expectedHint: processor.SnippetHint{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFile := file.NewMockFileAccess(ctrl)
+
+			subject := NewYq4Processor(&yaml.Yaml{}, mockFile)
+
+			mockFile.EXPECT().Read("/foo").Times(1).Return([]byte(c.content), nil)
+
+			hint, err := subject.ValidateSnippet("/foo")
+
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+
+			if !cmp.Equal(c.expectedHint, hint) {
+				t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", c.expectedHint, hint)
+			}
+		})
+	}
+
+	t.Run("file error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFile := file.NewMockFileAccess(ctrl)
+
+		subject := NewYq4Processor(&yaml.Yaml{}, mockFile)
+
+		mockFile.EXPECT().Read("/foo").Times(1).Return(nil, errors.New("oops"))
+
+		hint, err := subject.ValidateSnippet("/foo")
+
+		expectedError := errors.New("oops\n  while validating yq expression /foo")
+		if !cmp.Equal(&expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+
+		if hint.Valid {
+			t.Errorf("Expected ValidateSnippet to return false")
+		}
+	})
+}
+
+func TestProcessTemplate(t *testing.T) {
+
+	manifest := `# deployment
+name: deployment # the name
+instance_groups:
+- name: web
+  instances: 1
+  jobs:
+  - name: nginx
+    properties:
+      port: 80
+- name: worker
+  instances: 2
+  jobs: []
+`
+	cases := []struct {
+		name    string
+		in      string
+		snippet string
+
+		expectedError error
+		expectedOut   string
+	}{
+		{
+			name:        "no snippet",
+			in:          "foo: bar\n",
+			expectedOut: "foo: bar\n",
+		},
+		{
+			name:        "set and delete",
+			in:          "foo:\n  bar: 1\nbaz: 1\n",
+			snippet:     ".foo.bar = \"x\" | del(.baz)",
+			expectedOut: "foo:\n    bar: x\n",
+		},
+		{
+			name:        "create missing paths",
+			in:          "",
+			snippet:     ".a.b[1] = 2 | .c += [\"d\"]",
+			expectedOut: "a:\n    b:\n      - null\n      - 2\nc:\n  - d\n",
+		},
+		{
+			name:    "select",
+			in:      manifest,
+			snippet: "(.instance_groups[] | select(.name == \"web\") | .instances) = 3",
+			expectedOut: `# deployment
+name: deployment # the name
+instance_groups:
+  - name: web
+    instances: 3
+    jobs:
+      - name: nginx
+        properties:
+            port: 80
+  - name: worker
+    instances: 2
+    jobs: []
+`,
+		},
+		{
+			name:        "variables and reduce",
+			in:          manifest,
+			snippet:     ".instance_groups[].instances as $i ireduce (0; . + $i)",
+			expectedOut: "3\n",
+		},
+		{
+			name:        "construct",
+			in:          manifest,
+			snippet:     "{\"names\": [.instance_groups[].name], \"first\": .instance_groups[0].name}",
+			expectedOut: "names:\n  - web\n  - worker\nfirst: web\n",
+		},
+		{
+			name:    "comments",
+			in:      manifest,
+			snippet: ".instance_groups headComment=(.name | lineComment) | .name lineComment=\"renamed\" | .name = \"other\"",
+			expectedOut: `# deployment
+name: other # renamed
+instance_groups:
+  # the name
+  - name: web
+    instances: 1
+    jobs:
+      - name: nginx
+        properties:
+            port: 80
+  - name: worker
+    instances: 2
+    jobs: []
+`,
+		},
+		{
+			name:        "merge",
+			in:          "a:\n  b: 1\n  c: [1]\n",
+			snippet:     ".a = .a * {\"c\": [2], \"d\": {\"e\": true}} | .a.c += [3]",
+			expectedOut: "a:\n    b: 1\n    c: [2, 3]\n    d:\n        e: true\n",
+		},
+		{
+			name:        "alternative and sort",
+			in:          "list: [3, 1, 2]\nmap: {b: 1, a: 2}\n",
+			snippet:     ".missing = (.missing // \"default\") | sortKeys(.map) | .count = (.list | length)",
+			expectedOut: "list: [3, 1, 2]\nmap: {a: 2, b: 1}\nmissing: default\ncount: 3\n",
+		},
+		{
+			name:        "select subtree",
+			in:          manifest,
+			snippet:     ".instance_groups[0].jobs[0].properties",
+			expectedOut: "port: 80\n",
+		},
+		{
+			name:        "interpolated snippet",
+			in:          "foo: bar\n",
+			snippet:     "'.foo = \"it''s\"'\n",
+			expectedOut: "foo: it's\n",
+		},
+		{
+			name:          "multiple outputs",
+			in:            manifest,
+			snippet:       ".instance_groups[]",
+			expectedError: errors.New("Expected expression snippet.yq to produce one document but found 2"),
+		},
+		{
+			name:          "evaluation error",
+			in:            manifest,
+			snippet:       ".instance_groups[0] + 1",
+			expectedError: errors.New("Maps not yet supported for addition\n  while trying to evaluate snippet.yq against template template.yml"),
+		},
+		{
+			name:          "parse error",
+			in:            manifest,
+			snippet:       ".name = (\"x\"",
+			expectedError: errors.New("Bad expression, please check expression syntax\n  while trying to parse yq expression snippet.yq"),
+		},
+		{
+			name:          "unsupported function",
+			in:            manifest,
+			snippet:       ".name | ascii_upcase",
+			expectedError: errors.New("Parsing expression: Lexer error: could not match text starting at 1:11 failing at 1:13.\n\tunmatched text: \"ci\"\n  while trying to parse yq expression snippet.yq"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			subject := NewYq4Processor(&yaml.Yaml{}, nil)
+
+			var snippet *file.TaggedBytes
+			if c.snippet != "" {
+				snippet = &file.TaggedBytes{Tag: "snippet.yq", Bytes: []byte(c.snippet)}
+			}
+			templateBytes, err := subject.ProcessTemplate(&file.TaggedBytes{Tag: "template.yml", Bytes: []byte(c.in)}, snippet, nil, nil)
+
+			if !cmp.Equal(&c.expectedError, &err, cmp.Comparer(test.EqualMessage)) {
+				t.Errorf("Expected error:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedError, err)
+			}
+
+			if err == nil && string(templateBytes) != c.expectedOut {
+				t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", c.expectedOut, templateBytes)
+			}
+		})
+	}
+}
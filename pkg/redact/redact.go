@@ -0,0 +1,213 @@
+package redact
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/interpolator"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+)
+
+const Mask = "***"
+
+// shorter values (e.g. admin, 1, true) would mask unrelated text, so they are only masked by variable name
+const MinLength = 6
+
+// variable names whose values are masked regardless of their source
+var DefaultPatterns = []string{"*password*", "*secret*", "*token*", "*_key", "*private_key*"}
+
+type Redactor interface {
+	// remember secret values supplied by the params or saved in their vars store
+	Track(params library.InterpolatorParams) error
+
+	// true if the value should never be shown
+	IsSecret(value interpolator.VarValue) bool
+
+	// replace tracked secret values of at least MinLength characters with Mask
+	Redact(text string) string
+
+	// copy of a decoded yaml value with tracked secret values replaced
+	Value(value interface{}) interface{}
+
+	// redact the messages of err and errors wrapping it
+	Error(err error) error
+
+	// copy of the params with secret vars and raw args masked
+	Params(params library.InterpolatorParams) library.InterpolatorParams
+}
+
+// masks values from var files, env vars, and vars stores, and values of variables matching Patterns
+type SecretRedactor struct {
+	Interpolator interpolator.Interpolator
+	Patterns     []string // glob patterns matched against lowercase variable names
+	secrets      []string // longest first
+}
+
+func NewSecretRedactor(i interpolator.Interpolator) *SecretRedactor {
+	return &SecretRedactor{
+		Interpolator: i,
+		Patterns:     DefaultPatterns,
+	}
+}
+
+func (r *SecretRedactor) Track(params library.InterpolatorParams) error {
+	values, err := r.Interpolator.Values(params)
+	if err != nil {
+		return fmt.Errorf("%w\n  while listing variables", err)
+	}
+	stored, err := r.Interpolator.Stored(params)
+	if err != nil {
+		return fmt.Errorf("%w\n  while listing stored variables", err)
+	}
+	for _, v := range append(values, stored...) {
+		if r.IsSecret(v) {
+			r.track(v.Value)
+		}
+	}
+	return nil
+}
+
+func (r *SecretRedactor) IsSecret(value interpolator.VarValue) bool {
+	switch value.Via {
+	case interpolator.ViaVarFiles, interpolator.ViaVarsEnv, interpolator.ViaVarsStore:
+		return true
+	}
+	return r.matches(value.Name)
+}
+
+func (r *SecretRedactor) Redact(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.Replace(text, secret, Mask, -1)
+	}
+	return text
+}
+
+func (r *SecretRedactor) Value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.Redact(v)
+	case map[interface{}]interface{}:
+		m := map[interface{}]interface{}{}
+		for key, child := range v {
+			m[key] = r.Value(child)
+		}
+		return m
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for key, child := range v {
+			m[key] = r.Value(child)
+		}
+		return m
+	case []interface{}:
+		l := []interface{}{}
+		for _, child := range v {
+			l = append(l, r.Value(child))
+		}
+		return l
+	}
+	// numbers and booleans are masked when they match a secret exactly
+	if r.tracked(fmt.Sprintf("%v", value)) {
+		return Mask
+	}
+	return value
+}
+
+func (r *SecretRedactor) Error(err error) error {
+	if err == nil || len(r.secrets) == 0 {
+		return err
+	}
+	return &redactedError{err: err, message: r.Redact(err.Error())}
+}
+
+func (r *SecretRedactor) Params(params library.InterpolatorParams) library.InterpolatorParams {
+	masked := params
+	if params.Vars != nil {
+		masked.Vars = map[string]interface{}{}
+		for k, v := range params.Vars {
+			if r.matches(k) {
+				v = Mask
+			}
+			masked.Vars[k] = v
+		}
+	}
+	if params.RawArgs != nil {
+		masked.RawArgs = []string{}
+		for _, arg := range params.RawArgs {
+			masked.RawArgs = append(masked.RawArgs, r.Redact(arg))
+		}
+	}
+	return masked
+}
+
+func (r *SecretRedactor) matches(name string) bool {
+	lower := strings.ToLower(name)
+	for _, pattern := range r.Patterns {
+		if matched, _ := filepath.Match(strings.ToLower(pattern), lower); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// scalar leaves of the value, and each line of multi-line strings as they appear in yaml block scalars
+func (r *SecretRedactor) track(value interface{}) {
+	switch v := value.(type) {
+	case nil:
+	case string:
+		r.insert(v)
+		if strings.Contains(v, "\n") {
+			for _, line := range strings.Split(v, "\n") {
+				r.insert(strings.TrimSpace(line))
+			}
+		}
+	case map[interface{}]interface{}:
+		for _, child := range v {
+			r.track(child)
+		}
+	case map[string]interface{}:
+		for _, child := range v {
+			r.track(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			r.track(child)
+		}
+	default:
+		r.insert(fmt.Sprintf("%v", v))
+	}
+}
+
+func (r *SecretRedactor) insert(secret string) {
+	if len(secret) < MinLength || r.tracked(secret) {
+		return
+	}
+	r.secrets = append(r.secrets, secret)
+	// replace longer secrets before their substrings
+	sort.SliceStable(r.secrets, func(a, b int) bool { return len(r.secrets[a]) > len(r.secrets[b]) })
+}
+
+func (r *SecretRedactor) tracked(value string) bool {
+	for _, s := range r.secrets {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
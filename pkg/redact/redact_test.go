@@ -0,0 +1,197 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/cjnosal/manifer/v2/pkg/interpolator"
+	"github.com/cjnosal/manifer/v2/pkg/interpolator/bosh"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRedact(t *testing.T) {
+
+	t.Run("secret sources and names", func(t *testing.T) {
+		os.Setenv("REDACT_TEST_env", "fromenv")
+		defer os.Unsetenv("REDACT_TEST_env")
+		subject := NewSecretRedactor(bosh.NewBoshInterpolator())
+
+		err := subject.Track(library.InterpolatorParams{
+			Vars:     map[string]interface{}{"plain": "visible", "admin_password": "hunter2"},
+			VarFiles: map[string]string{"file": "../../test/data/v2/vars.yml"},
+			VarsEnv:  []string{"REDACT_TEST"},
+			RawArgs:  []string{"-vapi_token=abc123"},
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		text := "visible hunter2 fromenv abc123\n  foo: yay\n  bar: fromvarsfile\n"
+		expected := "visible *** *** ***\n  ***\n  ***\n"
+		redacted := subject.Redact(text)
+		if redacted != expected {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expected, redacted)
+		}
+	})
+
+	t.Run("vars store", func(t *testing.T) {
+		subject := NewSecretRedactor(bosh.NewBoshInterpolator())
+
+		err := subject.Track(library.InterpolatorParams{
+			VarsStore: "../../test/data/v2/vars.yml",
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		redacted := subject.Redact("yay catchall")
+		if redacted != "yay ***" {
+			t.Errorf("Expected:\n'''yay ***'''\nActual:\n'''%s'''\n", redacted)
+		}
+	})
+
+	t.Run("custom patterns", func(t *testing.T) {
+		subject := NewSecretRedactor(bosh.NewBoshInterpolator())
+		subject.Patterns = []string{"CERT*"}
+
+		secret := subject.IsSecret(interpolator.VarValue{Name: "cert_pem", Via: interpolator.ViaVars})
+		if !secret {
+			t.Errorf("Expected cert_pem to match CERT*")
+		}
+		secret = subject.IsSecret(interpolator.VarValue{Name: "admin_password", Via: interpolator.ViaVars})
+		if secret {
+			t.Errorf("Expected default patterns to be replaced")
+		}
+	})
+
+	t.Run("longer secrets first", func(t *testing.T) {
+		subject := NewSecretRedactor(bosh.NewBoshInterpolator())
+
+		err := subject.Track(library.InterpolatorParams{
+			Vars: map[string]interface{}{"short_secret": "abcdef", "long_secret": "abcdefghi"},
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		redacted := subject.Redact("abcdefghi abcdef")
+		if redacted != "*** ***" {
+			t.Errorf("Expected:\n'''*** ***'''\nActual:\n'''%s'''\n", redacted)
+		}
+	})
+
+	t.Run("short and non-string secrets", func(t *testing.T) {
+		subject := NewSecretRedactor(bosh.NewBoshInterpolator())
+
+		err := subject.Track(library.InterpolatorParams{
+			Vars: map[string]interface{}{"admin_password": "admin", "secret_flag": true, "secret_pin": 12345678},
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		redacted := subject.Redact("administrator true 12345678")
+		expected := "administrator true ***"
+		if redacted != expected {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expected, redacted)
+		}
+
+		value := []interface{}{12345678, 1, true, "admin", nil}
+		expectedValue := []interface{}{"***", 1, true, "admin", nil}
+		redactedValue := subject.Value(value)
+		if !cmp.Equal(redactedValue, expectedValue) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedValue, redactedValue)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		subject := NewSecretRedactor(bosh.NewBoshInterpolator())
+		err := subject.Track(library.InterpolatorParams{
+			Vars: map[string]interface{}{"secret": "hunter2"},
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		cause := errors.New("bad value hunter2")
+		redacted := subject.Error(fmt.Errorf("%w\n  while testing", cause))
+
+		expected := "bad value ***\n  while testing"
+		if redacted.Error() != expected {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expected, redacted)
+		}
+		if !errors.Is(redacted, cause) {
+			t.Errorf("Expected redacted error to wrap %v", cause)
+		}
+		if subject.Error(nil) != nil {
+			t.Errorf("Expected nil error")
+		}
+	})
+
+	t.Run("values", func(t *testing.T) {
+		subject := NewSecretRedactor(bosh.NewBoshInterpolator())
+		err := subject.Track(library.InterpolatorParams{
+			Vars: map[string]interface{}{"secret": "hunter2"},
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		value := map[string]interface{}{"list": []interface{}{"hunter2", 1}, "plain": "visible"}
+		expected := map[string]interface{}{"list": []interface{}{"***", 1}, "plain": "visible"}
+		redacted := subject.Value(value)
+		if !cmp.Equal(redacted, expected) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expected, redacted)
+		}
+		if value["list"].([]interface{})[0] != "hunter2" {
+			t.Errorf("Expected value to be unchanged")
+		}
+	})
+
+	t.Run("params", func(t *testing.T) {
+		subject := NewSecretRedactor(bosh.NewBoshInterpolator())
+		params := library.InterpolatorParams{
+			Vars:    map[string]interface{}{"plain": "visible", "db_password": "hunter2"},
+			RawArgs: []string{"-vdb_password=hunter3"},
+		}
+		err := subject.Track(params)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expected := library.InterpolatorParams{
+			Vars:    map[string]interface{}{"plain": "visible", "db_password": "***"},
+			RawArgs: []string{"-vdb_password=***"},
+		}
+		masked := subject.Params(params)
+		if !cmp.Equal(masked, expected) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expected, masked)
+		}
+		if params.Vars["db_password"] != "hunter2" {
+			t.Errorf("Expected params to be unchanged")
+		}
+	})
+
+	t.Run("invalid args", func(t *testing.T) {
+		subject := NewSecretRedactor(bosh.NewBoshInterpolator())
+
+		err := subject.Track(library.InterpolatorParams{
+			RawArgs: []string{"--oops"},
+		})
+
+		expectedError := "unknown flag `oops'\n  while trying to parse vars\n  while listing variables"
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/interpolator"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+)
+
+const (
+	ScopeSnippet  = "snippet"
+	ScopeScenario = "scenario"
+	ScopeRef      = "ref"
+	ScopeGlobal   = "global"
+	ScopeCli      = "cli"
+)
+
+type VarsLister interface {
+	// variables referenced by the snippets of resolved scenario trees (see composer.ScenarioResolver)
+	ListVars(nodes library.ScenarioNodes) ([]VarEntry, error)
+}
+
+// statically scans snippets for placeholders and matches them with the params of each scope
+type VarsScanner struct {
+	File         file.FileAccess
+	Interpolator interpolator.Interpolator
+}
+
+type VarEntry struct {
+	Name         string      `yaml:"name"`
+	ReferencedBy []string    `yaml:"referenced_by"`        // snippets
+	Sources      []VarSource `yaml:"sources,omitempty"`    // highest precedence first
+	MissingIn    []string    `yaml:"missing_in,omitempty"` // snippets without a value
+}
+
+type VarSource struct {
+	Scope    string `yaml:"scope"`
+	Via      string `yaml:"via"`
+	Scenario string `yaml:"scenario,omitempty"`
+	Snippet  string `yaml:"snippet,omitempty"`
+	Library  string `yaml:"library,omitempty"`
+}
+
+type varScope struct {
+	source VarSource
+	params library.InterpolatorParams
+	values []interpolator.VarValue // listed on first use
+}
+
+type varStep struct {
+	snippet string
+	scopes  []*varScope // highest precedence first
+}
+
+func (v *VarsScanner) ListVars(nodes library.ScenarioNodes) ([]VarEntry, error) {
+	steps := []varStep{}
+	globals := []*varScope{}
+	for _, node := range nodes {
+		collectScopes(node, []*varScope{}, &steps, &globals)
+	}
+	// later globals override earlier globals
+	for i, j := 0, len(globals)-1; i < j; i, j = i+1, j-1 {
+		globals[i], globals[j] = globals[j], globals[i]
+	}
+
+	entries := map[string]*VarEntry{}
+	for _, step := range steps {
+		snippet, err := v.File.ReadAndTag(step.snippet)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  while trying to load snippet %s", err, step.snippet)
+		}
+		scopes := append(append([]*varScope{}, globals...), step.scopes...)
+		for _, name := range rootNames(v.Interpolator.Referenced(snippet)) {
+			entry, ok := entries[name]
+			if !ok {
+				entry = &VarEntry{Name: name, ReferencedBy: []string{}}
+				entries[name] = entry
+			}
+			entry.ReferencedBy = insertString(entry.ReferencedBy, step.snippet)
+
+			sources, err := v.sources(name, scopes)
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  while finding sources of %s for snippet %s", err, name, step.snippet)
+			}
+			if len(sources) == 0 {
+				entry.MissingIn = insertString(entry.MissingIn, step.snippet)
+			}
+			for _, s := range sources {
+				entry.Sources = insertSource(entry.Sources, s)
+			}
+		}
+	}
+
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []VarEntry{}
+	for _, name := range names {
+		result = append(result, *entries[name])
+	}
+	return result, nil
+}
+
+// scopes supplying a variable in the order bosh would consult them
+func (v *VarsScanner) sources(name string, scopes []*varScope) ([]VarSource, error) {
+	scoped := [][]interpolator.VarValue{}
+	for _, scope := range scopes {
+		values, err := v.values(scope)
+		if err != nil {
+			return nil, err
+		}
+		scoped = append(scoped, values)
+	}
+	sources := []VarSource{}
+	for _, ranked := range interpolator.Rank(scoped) {
+		if ranked.Name == name {
+			source := scopes[ranked.Scope].source
+			source.Via = ranked.Source()
+			sources = insertSource(sources, source)
+		}
+	}
+	// a vars store may generate values for variables without a source
+	for _, scope := range scopes {
+		if scope.params.VarsStore != "" || scope.params.VarsStoreSeed != "" {
+			source := scope.source
+			source.Via = interpolator.ViaVarsStore
+			sources = append(sources, source)
+		}
+	}
+	return sources, nil
+}
+
+func (v *VarsScanner) values(scope *varScope) ([]interpolator.VarValue, error) {
+	if scope.values != nil {
+		return scope.values, nil
+	}
+	values, err := v.Interpolator.Values(scope.params)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while listing vars of %s %s", err, scope.source.Scope, scope.source.Scenario)
+	}
+	scope.values = values
+	return values, nil
+}
+
+// mirrors plan.FromScenarioTree with ref params kept apart from the scenario's own params
+func collectScopes(node *library.ScenarioNode, inherited []*varScope, steps *[]varStep, globals *[]*varScope) {
+	chain := append([]*varScope{}, inherited...)
+	if !node.RefInterpolator.IsZero() {
+		chain = append(chain, newScope(ScopeRef, node, node.RefInterpolator))
+	}
+	if !node.Interpolator.IsZero() {
+		chain = append(chain, newScope(ScopeScenario, node, node.Interpolator))
+	}
+	for _, dep := range node.Dependencies {
+		collectScopes(dep, chain, steps, globals)
+	}
+	if !node.GlobalInterpolator.IsZero() {
+		scope := ScopeGlobal
+		if node.LibraryPath == "<cli>" {
+			scope = ScopeCli
+		}
+		*globals = append(*globals, newScope(scope, node, node.GlobalInterpolator))
+	}
+	for _, snippet := range node.Snippets {
+		if snippet.Path == "" {
+			continue
+		}
+		scopes := append([]*varScope{}, chain...)
+		if !snippet.Interpolator.IsZero() {
+			scopes = append(scopes, &varScope{
+				source: VarSource{
+					Scope:    ScopeSnippet,
+					Scenario: node.Name,
+					Snippet:  snippet.Path,
+					Library:  node.LibraryPath,
+				},
+				params: snippet.Interpolator,
+			})
+		}
+		*steps = append(*steps, varStep{snippet: snippet.Path, scopes: scopes})
+	}
+}
+
+func newScope(scope string, node *library.ScenarioNode, params library.InterpolatorParams) *varScope {
+	source := VarSource{Scope: scope, Library: node.LibraryPath}
+	if scope != ScopeCli {
+		source.Scenario = node.Name
+	}
+	return &varScope{source: source, params: params}
+}
+
+// ((creds.password)) is supplied by the creds variable
+func rootNames(names []string) []string {
+	roots := []string{}
+	for _, name := range names {
+		roots = insertString(roots, strings.Split(name, ".")[0])
+	}
+	return roots
+}
+
+func insertString(collection []string, value string) []string {
+	if containsString(collection, value) {
+		return collection
+	}
+	return append(collection, value)
+}
+
+func insertSource(collection []VarSource, value VarSource) []VarSource {
+	for _, s := range collection {
+		if s == value {
+			return collection
+		}
+	}
+	return append(collection, value)
+}
+
+func containsString(collection []string, value string) bool {
+	for _, c := range collection {
+		if c == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package scenario
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/interpolator"
+	"github.com/cjnosal/manifer/v2/pkg/interpolator/bosh"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/test"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestListVars(t *testing.T) {
+
+	nodes := library.ScenarioNodes{
+		{
+			Name:        "main",
+			LibraryPath: "/lib.yml",
+			Interpolator: library.InterpolatorParams{
+				Vars: map[string]interface{}{"shared": "main"},
+			},
+			GlobalInterpolator: library.InterpolatorParams{
+				VarsFiles: []string{"../../test/data/v2/vars.yml"},
+			},
+			Dependencies: library.ScenarioNodes{
+				{
+					Name:        "dep",
+					LibraryPath: "/lib.yml",
+					RefInterpolator: library.InterpolatorParams{
+						Vars: map[string]interface{}{"shared": "ref", "foo": "ref"},
+					},
+					Interpolator: library.InterpolatorParams{
+						Vars: map[string]interface{}{"shared": "dep"},
+					},
+					Snippets: []library.Snippet{
+						{
+							Path: "/dep.yml",
+							Interpolator: library.InterpolatorParams{
+								Vars: map[string]interface{}{"shared": "snippet"},
+							},
+						},
+					},
+				},
+			},
+			Snippets: []library.Snippet{
+				{
+					Path: "/main.yml",
+				},
+			},
+		},
+		{
+			Name:        "passthrough variables",
+			LibraryPath: "<cli>",
+			GlobalInterpolator: library.InterpolatorParams{
+				RawArgs: []string{"-vfoo=cli"},
+			},
+		},
+	}
+
+	t.Run("sources and missing vars", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := &VarsScanner{
+			File:         mockFile,
+			Interpolator: bosh.NewBoshInterpolator(),
+		}
+
+		mockFile.EXPECT().ReadAndTag("/dep.yml").Return(&file.TaggedBytes{Tag: "/dep.yml", Bytes: []byte("- path: /((shared))\n  value: ((foo))\n")}, nil)
+		mockFile.EXPECT().ReadAndTag("/main.yml").Return(&file.TaggedBytes{Tag: "/main.yml", Bytes: []byte("- path: /((missing.key))\n  value: ((shared))\n")}, nil)
+
+		entries, err := subject.ListVars(nodes)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		expected := []VarEntry{
+			{
+				Name:         "foo",
+				ReferencedBy: []string{"/dep.yml"},
+				Sources: []VarSource{
+					{Scope: ScopeCli, Via: interpolator.ViaRawArgs, Library: "<cli>"},
+					{Scope: ScopeRef, Via: interpolator.ViaVars, Scenario: "dep", Library: "/lib.yml"},
+					{Scope: ScopeGlobal, Via: interpolator.ViaVarsFiles, Scenario: "main", Library: "/lib.yml"},
+				},
+			},
+			{
+				Name:         "missing",
+				ReferencedBy: []string{"/main.yml"},
+				MissingIn:    []string{"/main.yml"},
+			},
+			{
+				Name:         "shared",
+				ReferencedBy: []string{"/dep.yml", "/main.yml"},
+				Sources: []VarSource{
+					{Scope: ScopeScenario, Via: interpolator.ViaVars, Scenario: "main", Library: "/lib.yml"},
+					{Scope: ScopeRef, Via: interpolator.ViaVars, Scenario: "dep", Library: "/lib.yml"},
+					{Scope: ScopeScenario, Via: interpolator.ViaVars, Scenario: "dep", Library: "/lib.yml"},
+					{Scope: ScopeSnippet, Via: interpolator.ViaVars, Scenario: "dep", Snippet: "/dep.yml", Library: "/lib.yml"},
+				},
+			},
+		}
+		if err == nil && !cmp.Equal(entries, expected) {
+			t.Errorf("Expected:\n'''%+v'''\nActual:\n'''%+v'''\nDiff:\n%s", expected, entries, cmp.Diff(expected, entries))
+		}
+	})
+
+	t.Run("missing snippet", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := &VarsScanner{
+			File:         mockFile,
+			Interpolator: bosh.NewBoshInterpolator(),
+		}
+
+		mockFile.EXPECT().ReadAndTag("/dep.yml").Return(nil, errors.New("test"))
+
+		_, err := subject.ListVars(nodes)
+
+		expectedError := errors.New("test\n  while trying to load snippet /dep.yml")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
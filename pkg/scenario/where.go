@@ -0,0 +1,111 @@
+package scenario
+
+import (
+	"fmt"
+
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/processor/factory"
+)
+
+type PathFinder interface {
+	Where(libraries []string, path string) ([]WhereEntry, error)
+}
+
+// statically analyzes snippets to find scenarios that could modify a path
+type Finder struct {
+	Loader           library.LibraryLoader
+	ProcessorFactory factory.ProcessorFactory
+}
+
+type WhereEntry struct {
+	Scenario string   `yaml:"scenario"`
+	Library  string   `yaml:"library"`
+	Snippet  string   `yaml:"snippet"`
+	Paths    []string `yaml:"paths"` // paths in the snippet overlapping the query
+}
+
+func (f *Finder) Where(libraryPaths []string, path string) ([]WhereEntry, error) {
+	entries := []WhereEntry{}
+	loadedLibrary, err := f.Loader.Load(libraryPaths)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  loading libraries", err)
+	}
+
+	hints := map[string]processor.SnippetHint{}
+	for _, lib := range loadedLibrary.TopLibraries {
+		err = f.searchLib("", lib, path, hints, &entries, loadedLibrary)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+func (f *Finder) searchLib(prefix string, lib *library.Library, path string, hints map[string]processor.SnippetHint, entries *[]WhereEntry, loadedLibrary *library.LoadedLibrary) error {
+	libPath := loadedLibrary.GetPath(lib)
+	for _, s := range lib.Scenarios {
+		for _, snippet := range s.Snippets {
+			if snippet.Path == "" {
+				continue
+			}
+			libType := snippet.Processor.Type
+			if libType == "" {
+				libType = lib.Type
+			}
+			hint, err := f.hint(libType, snippet.Path, hints)
+			if err != nil {
+				return fmt.Errorf("%w\n  while analyzing scenario %s%s", err, prefix, s.Name)
+			}
+			vars := map[string]interface{}{}
+			for k, v := range s.Interpolator.Vars {
+				vars[k] = v
+			}
+			for k, v := range snippet.Interpolator.Vars {
+				vars[k] = v
+			}
+			matches := []string{}
+			for _, p := range hint.Paths {
+				p = processor.SubstituteVars(p, vars)
+				if processor.PointersOverlap(p, path) {
+					matches = append(matches, p)
+				}
+			}
+			if len(matches) > 0 {
+				*entries = append(*entries, WhereEntry{
+					Scenario: prefix + s.Name,
+					Library:  libPath,
+					Snippet:  snippet.Path,
+					Paths:    matches,
+				})
+			}
+		}
+	}
+
+	for _, ref := range lib.Libraries {
+		prefix := prefix + ref.Alias + "."
+		err := f.searchLib(prefix, loadedLibrary.GetAliasedLibrary(lib, ref.Alias), path, hints, entries, loadedLibrary)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Finder) hint(libType library.Type, path string, hints map[string]processor.SnippetHint) (processor.SnippetHint, error) {
+	key := fmt.Sprintf("%s:%s", libType, path)
+	if hint, found := hints[key]; found {
+		return hint, nil
+	}
+	p, err := f.ProcessorFactory.Create(libType)
+	if err != nil {
+		return processor.SnippetHint{}, fmt.Errorf("%w\n  while initializing processor of type %s", err, libType)
+	}
+	hint, err := p.ValidateSnippet(path)
+	if err != nil {
+		return processor.SnippetHint{}, fmt.Errorf("%w\n  while parsing snippet %s", err, path)
+	}
+	hints[key] = hint
+	return hint, nil
+}
@@ -0,0 +1,139 @@
+package scenario
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/processor"
+	"github.com/cjnosal/manifer/v2/pkg/processor/factory"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWhere(t *testing.T) {
+
+	referencedLibrary := &library.Library{
+		Type: library.Yq,
+		Scenarios: []library.Scenario{
+			{
+				Name: "dependency",
+				Snippets: []library.Snippet{
+					{
+						Path: "/wd/lib/script.yml",
+					},
+				},
+			},
+		},
+	}
+
+	referencingLibrary := &library.Library{
+		Type: library.OpsFile,
+		Libraries: []library.LibraryRef{
+			{
+				Alias: "ref",
+				Path:  "/wd/lib/library2.yml",
+			},
+		},
+		Scenarios: []library.Scenario{
+			{
+				Name: "main",
+				Interpolator: library.InterpolatorParams{
+					Vars: map[string]interface{}{"group": "router"},
+				},
+				Snippets: []library.Snippet{
+					{
+						Path: "/wd/lib/ops.yml",
+					},
+				},
+			},
+			{
+				Name: "other",
+				Snippets: []library.Snippet{
+					{
+						Path: "/wd/lib/ops.yml",
+						Interpolator: library.InterpolatorParams{
+							Vars: map[string]interface{}{"group": "web"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	loaded := &library.LoadedLibrary{
+		TopLibraries: []*library.Library{referencingLibrary},
+		Libraries: map[string]*library.Library{
+			"/wd/lib/library.yml":  referencingLibrary,
+			"/wd/lib/library2.yml": referencedLibrary,
+		},
+	}
+
+	t.Run("find overlapping paths", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		loader := library.NewMockLibraryLoader(ctrl)
+		processorFactory := factory.NewMockProcessorFactory(ctrl)
+		opsProcessor := processor.NewMockProcessor(ctrl)
+		yqProcessor := processor.NewMockProcessor(ctrl)
+		subject := &Finder{
+			Loader:           loader,
+			ProcessorFactory: processorFactory,
+		}
+
+		loader.EXPECT().Load([]string{"./lib/library.yml"}).Times(1).Return(loaded, nil)
+		processorFactory.EXPECT().Create(library.OpsFile).Times(1).Return(opsProcessor, nil)
+		processorFactory.EXPECT().Create(library.Yq).Times(1).Return(yqProcessor, nil)
+		opsProcessor.EXPECT().ValidateSnippet("/wd/lib/ops.yml").Times(1).Return(processor.SnippetHint{
+			Valid: true,
+			Paths: []string{"/instance_groups/name=((group))/jobs/-", "/name"},
+		}, nil)
+		yqProcessor.EXPECT().ValidateSnippet("/wd/lib/script.yml").Times(1).Return(processor.SnippetHint{
+			Valid: true,
+			Paths: []string{"/instance_groups"},
+		}, nil)
+
+		entries, err := subject.Where([]string{"./lib/library.yml"}, "/instance_groups/name=router/jobs")
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expected := []WhereEntry{
+			{
+				Scenario: "main",
+				Library:  "/wd/lib/library.yml",
+				Snippet:  "/wd/lib/ops.yml",
+				Paths:    []string{"/instance_groups/name=router/jobs/-"},
+			},
+			{
+				Scenario: "ref.dependency",
+				Library:  "/wd/lib/library2.yml",
+				Snippet:  "/wd/lib/script.yml",
+				Paths:    []string{"/instance_groups"},
+			},
+		}
+
+		if !cmp.Equal(expected, entries) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n'''%v'''\n", expected, entries, cmp.Diff(expected, entries))
+		}
+	})
+
+	t.Run("load error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		loader := library.NewMockLibraryLoader(ctrl)
+		subject := &Finder{
+			Loader: loader,
+		}
+
+		loader.EXPECT().Load([]string{"lib1"}).Times(1).Return(nil, errors.New("test"))
+
+		_, err := subject.Where([]string{"lib1"}, "/foo")
+
+		if err == nil || err.Error() != "test\n  loading libraries" {
+			t.Errorf("Loader error not reported")
+		}
+	})
+}
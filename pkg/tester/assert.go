@@ -0,0 +1,110 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	y "gopkg.in/yaml.v3"
+)
+
+var assertionRegex = regexp.MustCompile(`^\s*(\S+)\s+(==|!=|<=|>=|<|>)\s+(.*?)\s*$`)
+
+// nil if the '<path> <operator> <value>' assertion holds for the document.
+// The value is parsed as yaml, and <, <=, >, >= compare numbers.
+func Check(document *y.Node, assertion string) error {
+	match := assertionRegex.FindStringSubmatch(assertion)
+	if match == nil {
+		return fmt.Errorf("invalid assertion '%s', expected '<path> <operator> <value>'", assertion)
+	}
+	path, operator := match[1], match[2]
+
+	var expected interface{}
+	err := y.Unmarshal([]byte(match[3]), &expected)
+	if err != nil {
+		return fmt.Errorf("%w\n  while parsing value of assertion '%s'", err, assertion)
+	}
+
+	node, found := yaml.Lookup(document, path)
+	if !found {
+		return fmt.Errorf("%s (%s not found)", assertion, path)
+	}
+	var actual interface{}
+	err = node.Decode(&actual)
+	if err != nil {
+		return fmt.Errorf("%w\n  while decoding %s", err, path)
+	}
+
+	holds, err := compare(actual, operator, expected)
+	if err != nil {
+		return fmt.Errorf("%s (%v, actual: %s)", assertion, err, format(actual))
+	}
+	if !holds {
+		return fmt.Errorf("%s (actual: %s)", assertion, format(actual))
+	}
+	return nil
+}
+
+func compare(actual interface{}, operator string, expected interface{}) (bool, error) {
+	switch operator {
+	case "==":
+		return equal(actual, expected), nil
+	case "!=":
+		return !equal(actual, expected), nil
+	}
+	a, aNumber := number(actual)
+	e, eNumber := number(expected)
+	if !aNumber || !eNumber {
+		return false, fmt.Errorf("%s compares numbers", operator)
+	}
+	switch operator {
+	case "<":
+		return a < e, nil
+	case "<=":
+		return a <= e, nil
+	case ">":
+		return a > e, nil
+	default:
+		return a >= e, nil
+	}
+}
+
+func equal(a interface{}, b interface{}) bool {
+	aNumber, aOk := number(a)
+	bNumber, bOk := number(b)
+	if aOk && bOk {
+		return aNumber == bNumber
+	}
+	return reflect.DeepEqual(yaml.StringKeys(a), yaml.StringKeys(b))
+}
+
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func format(value interface{}) string {
+	switch v := yaml.StringKeys(value).(type) {
+	case map[string]interface{}, []interface{}:
+		bytes, err := json.Marshal(v)
+		if err == nil {
+			return string(bytes)
+		}
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("'%s'", v)
+	}
+	return fmt.Sprintf("%v", value)
+}
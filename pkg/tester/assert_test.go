@@ -0,0 +1,79 @@
+package tester
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	y "gopkg.in/yaml.v3"
+)
+
+func TestCheck(t *testing.T) {
+
+	document := &y.Node{}
+	err := y.Unmarshal([]byte(`---
+foo:
+  bar: trendy
+instance_groups:
+- name: web
+  instances: 2
+  networks: [a, b]
+ratio: 0.5
+`), document)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	t.Run("holds", func(t *testing.T) {
+		assertions := []string{
+			"/foo/bar == trendy",
+			"/foo/bar != boring",
+			"/instance_groups/0/instances > 1",
+			"/instance_groups/name=web/instances >= 2",
+			"/instance_groups/0/instances == 2.0",
+			"/instance_groups/0/networks == [a, b]",
+			"/foo == {bar: trendy}",
+			"/ratio < 1",
+			"/ratio <= 0.5",
+		}
+		for _, assertion := range assertions {
+			err := Check(document, assertion)
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+		}
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		assertions := []string{
+			"/foo/bar == boring",
+			"/instance_groups/0/instances > 2",
+			"/instance_groups/0/networks == [a]",
+			"/foo/baz == trendy",
+			"/foo/bar > 1",
+			"/foo/bar",
+			"/foo/bar == [",
+		}
+		failures := []string{}
+		for _, assertion := range assertions {
+			err := Check(document, assertion)
+			if err == nil {
+				t.Errorf("Expected %s to fail", assertion)
+				continue
+			}
+			failures = append(failures, err.Error())
+		}
+
+		expectedFailures := []string{
+			"/foo/bar == boring (actual: 'trendy')",
+			"/instance_groups/0/instances > 2 (actual: 2)",
+			`/instance_groups/0/networks == [a] (actual: ["a","b"])`,
+			"/foo/baz == trendy (/foo/baz not found)",
+			"/foo/bar > 1 (> compares numbers, actual: 'trendy')",
+			"invalid assertion '/foo/bar', expected '<path> <operator> <value>'",
+			"yaml: line 1: did not find expected node content\n  while parsing value of assertion '/foo/bar == ['",
+		}
+		if !cmp.Equal(expectedFailures, failures) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\nDiff:\n%s", expectedFailures, failures, cmp.Diff(expectedFailures, failures))
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package tester
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cjnosal/manifer/v2/pkg/diff"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+)
+
+const (
+	SnapshotMatched = "matched"
+	SnapshotChanged = "changed"
+	SnapshotMissing = "missing"
+	SnapshotUpdated = "updated"
+)
+
+// characters replaced in snapshot file names
+var unsafeNameRegex = regexp.MustCompile(`[^\w.-]+`)
+
+type SnapshotResult struct {
+	Library string        `yaml:"library"`
+	Name    string        `yaml:"name"`
+	Path    string        `yaml:"path"`
+	Status  string        `yaml:"status,omitempty"`  // one of the Snapshot constants, empty if Error is set
+	Error   string        `yaml:"error,omitempty"`   // the test could not be composed
+	Changes []diff.Change `yaml:"changes,omitempty"` // differences from the previous snapshot
+}
+
+func (r *Runner) Snapshot(libraryPaths []string, names []string, dir string, update bool) ([]SnapshotResult, error) {
+	tests, err := r.tests(libraryPaths, names)
+	if err != nil {
+		return nil, err
+	}
+	// snapshot directories are named after library files, so libraries with the same file name would overwrite each other
+	owners := map[string]string{}
+	for _, t := range tests {
+		snapshotDir := filepath.Dir(SnapshotPath(dir, t.libraryPath, t.test.Name))
+		if owner, ok := owners[snapshotDir]; ok && owner != t.libraryPath {
+			return nil, fmt.Errorf("Libraries %s and %s would share snapshot directory %s", owner, t.libraryPath, snapshotDir)
+		}
+		owners[snapshotDir] = t.libraryPath
+	}
+	results := []SnapshotResult{}
+	for _, t := range tests {
+		results = append(results, r.snapshot(t.libraryPath, t.test, dir, update))
+	}
+	return results, nil
+}
+
+func (r *Runner) snapshot(libraryPath string, test library.Test, dir string, update bool) SnapshotResult {
+	result := SnapshotResult{
+		Library: libraryPath,
+		Name:    test.Name,
+		Path:    SnapshotPath(dir, libraryPath, test.Name),
+	}
+	out, err := r.compose(libraryPath, test)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	previous, err := r.File.Read(result.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		result.Status = SnapshotMissing
+	} else if err != nil {
+		result.Error = fmt.Errorf("%w\n  while reading snapshot %s", err, result.Path).Error()
+		return result
+	} else if bytes.Equal(previous, out) {
+		result.Status = SnapshotMatched
+		return result
+	} else {
+		result.Status = SnapshotChanged
+		result.Changes, err = r.Diff.Compare(previous, out, diff.CompareOptions{})
+		if err != nil {
+			result.Error = fmt.Errorf("%w\n  while comparing output to %s", err, result.Path).Error()
+			return result
+		}
+	}
+
+	if update {
+		err = r.File.MkDir(filepath.Dir(result.Path))
+		if err != nil {
+			result.Error = fmt.Errorf("%w\n  while creating directory %s", err, filepath.Dir(result.Path)).Error()
+			return result
+		}
+		err = r.File.Write(result.Path, out, 0644)
+		if err != nil {
+			result.Error = fmt.Errorf("%w\n  while writing snapshot %s", err, result.Path).Error()
+			return result
+		}
+		result.Status = SnapshotUpdated
+	}
+	return result
+}
+
+// <dir>/<library file name>/<test name>.yml
+func SnapshotPath(dir string, libraryPath string, name string) string {
+	base := filepath.Base(libraryPath)
+	libraryName := strings.TrimSuffix(base, filepath.Ext(base))
+	return filepath.Join(dir, libraryName, unsafeNameRegex.ReplaceAllString(name, "_")+".yml")
+}
@@ -0,0 +1,173 @@
+package tester
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/cjnosal/manifer/v2/pkg/composer"
+	"github.com/cjnosal/manifer/v2/pkg/diff"
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/plan"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+)
+
+func TestSnapshot(t *testing.T) {
+
+	lib := &library.Library{
+		Tests: []library.Test{
+			{Name: "same", Template: "/lib/template.yml", Scenarios: []string{"a"}},
+			{Name: "different/output", Template: "/lib/template.yml", Scenarios: []string{"b"}},
+			{Name: "new", Template: "/lib/template.yml", Scenarios: []string{"c"}},
+		},
+	}
+	loaded := &library.LoadedLibrary{
+		TopLibraries: []*library.Library{lib},
+		Libraries:    map[string]*library.Library{"/lib/library.yml": lib},
+	}
+	template := &file.TaggedBytes{Tag: "/lib/template.yml", Bytes: []byte("template")}
+	notFound := &os.PathError{Op: "open", Path: "/snap/library/new.yml", Err: os.ErrNotExist}
+
+	setup := func(ctrl *gomock.Controller) (*Runner, *file.MockFileAccess) {
+		mockLoader := library.NewMockLibraryLoader(ctrl)
+		mockResolver := composer.NewMockScenarioResolver(ctrl)
+		mockComposer := composer.NewMockComposer(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		planA := &plan.Plan{Steps: []*plan.Step{{Snippet: "a"}}}
+		planB := &plan.Plan{Steps: []*plan.Step{{Snippet: "b"}}}
+		planC := &plan.Plan{Steps: []*plan.Step{{Snippet: "c"}}}
+
+		mockLoader.EXPECT().Load([]string{"library.yml"}).Times(1).Return(loaded, nil)
+		mockFile.EXPECT().ReadAndTag("/lib/template.yml").Times(3).Return(template, nil)
+		mockResolver.EXPECT().Resolve([]string{"/lib/library.yml"}, []string{"a"}, []string{}).Times(1).Return(planA, nil)
+		mockResolver.EXPECT().Resolve([]string{"/lib/library.yml"}, []string{"b"}, []string{}).Times(1).Return(planB, nil)
+		mockResolver.EXPECT().Resolve([]string{"/lib/library.yml"}, []string{"c"}, []string{}).Times(1).Return(planC, nil)
+		mockComposer.EXPECT().ComposePlan(template, planA, composer.Options{}).Times(1).Return([]byte("foo: bar\n"), nil)
+		mockComposer.EXPECT().ComposePlan(template, planB, composer.Options{}).Times(1).Return([]byte("foo: baz\n"), nil)
+		mockComposer.EXPECT().ComposePlan(template, planC, composer.Options{}).Times(1).Return([]byte("foo: new\n"), nil)
+		mockFile.EXPECT().Read("/snap/library/same.yml").Times(1).Return([]byte("foo: bar\n"), nil)
+		mockFile.EXPECT().Read("/snap/library/different_output.yml").Times(1).Return([]byte("foo: bar\n"), nil)
+		mockFile.EXPECT().Read("/snap/library/new.yml").Times(1).Return(nil, notFound)
+
+		return &Runner{
+			Loader:   mockLoader,
+			Resolver: mockResolver,
+			Composer: mockComposer,
+			Diff:     &diff.TreeDiff{Yaml: &yaml.Yaml{}},
+			File:     mockFile,
+		}, mockFile
+	}
+
+	t.Run("compare", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		subject, _ := setup(ctrl)
+
+		results, err := subject.Snapshot([]string{"library.yml"}, nil, "/snap", false)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedResults := []SnapshotResult{
+			{Library: "/lib/library.yml", Name: "same", Path: "/snap/library/same.yml", Status: SnapshotMatched},
+			{
+				Library: "/lib/library.yml",
+				Name:    "different/output",
+				Path:    "/snap/library/different_output.yml",
+				Status:  SnapshotChanged,
+				Changes: []diff.Change{{Path: "/foo", Type: diff.Changed, Old: "bar", New: "baz"}},
+			},
+			{Library: "/lib/library.yml", Name: "new", Path: "/snap/library/new.yml", Status: SnapshotMissing},
+		}
+		if !cmp.Equal(expectedResults, results) {
+			t.Errorf("Expected:\n'''%+v'''\nActual:\n'''%+v'''\nDiff:\n%s", expectedResults, results, cmp.Diff(expectedResults, results))
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		subject, mockFile := setup(ctrl)
+
+		mockFile.EXPECT().MkDir("/snap/library").Times(2).Return(nil)
+		mockFile.EXPECT().Write("/snap/library/different_output.yml", []byte("foo: baz\n"), os.FileMode(0644)).Times(1).Return(nil)
+		mockFile.EXPECT().Write("/snap/library/new.yml", []byte("foo: new\n"), os.FileMode(0644)).Times(1).Return(nil)
+
+		results, err := subject.Snapshot([]string{"library.yml"}, nil, "/snap", true)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedResults := []SnapshotResult{
+			{Library: "/lib/library.yml", Name: "same", Path: "/snap/library/same.yml", Status: SnapshotMatched},
+			{
+				Library: "/lib/library.yml",
+				Name:    "different/output",
+				Path:    "/snap/library/different_output.yml",
+				Status:  SnapshotUpdated,
+				Changes: []diff.Change{{Path: "/foo", Type: diff.Changed, Old: "bar", New: "baz"}},
+			},
+			{Library: "/lib/library.yml", Name: "new", Path: "/snap/library/new.yml", Status: SnapshotUpdated},
+		}
+		if !cmp.Equal(expectedResults, results) {
+			t.Errorf("Expected:\n'''%+v'''\nActual:\n'''%+v'''\nDiff:\n%s", expectedResults, results, cmp.Diff(expectedResults, results))
+		}
+	})
+
+	t.Run("compose error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLoader := library.NewMockLibraryLoader(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := &Runner{
+			Loader: mockLoader,
+			File:   mockFile,
+		}
+
+		mockLoader.EXPECT().Load([]string{"library.yml"}).Times(1).Return(loaded, nil)
+		mockFile.EXPECT().ReadAndTag("/lib/template.yml").Times(1).Return(nil, errors.New("test"))
+
+		results, err := subject.Snapshot([]string{"library.yml"}, []string{"same"}, "/snap", true)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedResults := []SnapshotResult{
+			{Library: "/lib/library.yml", Name: "same", Path: "/snap/library/same.yml", Error: "test\n  while trying to load template /lib/template.yml"},
+		}
+		if !cmp.Equal(expectedResults, results) {
+			t.Errorf("Expected:\n'''%+v'''\nActual:\n'''%+v'''\n", expectedResults, results)
+		}
+	})
+
+	t.Run("shared snapshot directory", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLoader := library.NewMockLibraryLoader(ctrl)
+		subject := &Runner{
+			Loader: mockLoader,
+		}
+		other := &library.Library{
+			Tests: []library.Test{{Name: "other", Template: "/other/template.yml"}},
+		}
+		shared := &library.LoadedLibrary{
+			TopLibraries: []*library.Library{lib, other},
+			Libraries:    map[string]*library.Library{"/lib/library.yml": lib, "/other/library.yml": other},
+		}
+
+		mockLoader.EXPECT().Load([]string{"library.yml", "other/library.yml"}).Times(1).Return(shared, nil)
+
+		_, err := subject.Snapshot([]string{"library.yml", "other/library.yml"}, []string{}, "/snap", true)
+
+		expectedError := "Libraries /lib/library.yml and /other/library.yml would share snapshot directory /snap/library"
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%v'''\n", expectedError, err)
+		}
+	})
+}
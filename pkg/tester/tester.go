@@ -0,0 +1,143 @@
+package tester
+
+import (
+	"fmt"
+
+	"github.com/cjnosal/manifer/v2/pkg/composer"
+	"github.com/cjnosal/manifer/v2/pkg/diff"
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	y "gopkg.in/yaml.v3"
+)
+
+type LibraryTester interface {
+	// run the tests of the top level libraries, or only the tests with the given names
+	Run(libraryPaths []string, names []string) ([]Result, error)
+
+	// compare the output of each test to its snapshot in dir, or write the snapshots that differ when update is set
+	Snapshot(libraryPaths []string, names []string, dir string, update bool) ([]SnapshotResult, error)
+}
+
+type Runner struct {
+	Loader   library.LibraryLoader
+	Resolver composer.ScenarioResolver
+	Composer composer.Composer
+	Diff     diff.StructuralDiff
+	File     file.FileAccess
+	Yaml     yaml.YamlAccess
+}
+
+type Result struct {
+	Library  string        `yaml:"library"`
+	Name     string        `yaml:"name"`
+	Passed   bool          `yaml:"passed"`
+	Error    string        `yaml:"error,omitempty"`    // the test could not be composed
+	Changes  []diff.Change `yaml:"changes,omitempty"`  // differences from the expected output
+	Failures []string      `yaml:"failures,omitempty"` // assertions that do not hold
+}
+
+type libraryTest struct {
+	libraryPath string
+	test        library.Test
+}
+
+func (r *Runner) Run(libraryPaths []string, names []string) ([]Result, error) {
+	tests, err := r.tests(libraryPaths, names)
+	if err != nil {
+		return nil, err
+	}
+	results := []Result{}
+	for _, t := range tests {
+		result := r.run(t.libraryPath, t.test)
+		result.Passed = result.Error == "" && len(result.Changes) == 0 && len(result.Failures) == 0
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// tests of the top level libraries in order, or only the tests with the given names
+func (r *Runner) tests(libraryPaths []string, names []string) ([]libraryTest, error) {
+	loaded, err := r.Loader.Load(libraryPaths)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while loading libraries", err)
+	}
+	tests := []libraryTest{}
+	for _, lib := range loaded.TopLibraries {
+		libraryPath := loaded.GetPath(lib)
+		for _, test := range lib.Tests {
+			if len(names) > 0 && !contains(names, test.Name) {
+				continue
+			}
+			tests = append(tests, libraryTest{libraryPath: libraryPath, test: test})
+		}
+	}
+	return tests, nil
+}
+
+func (r *Runner) run(libraryPath string, test library.Test) Result {
+	result := Result{
+		Library: libraryPath,
+		Name:    test.Name,
+	}
+	out, err := r.compose(libraryPath, test)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if test.Expected != "" {
+		expected, err := r.File.Read(test.Expected)
+		if err != nil {
+			result.Error = fmt.Errorf("%w\n  while reading expected output %s", err, test.Expected).Error()
+			return result
+		}
+		result.Changes, err = r.Diff.Compare(expected, out, diff.CompareOptions{})
+		if err != nil {
+			result.Error = fmt.Errorf("%w\n  while comparing output to %s", err, test.Expected).Error()
+			return result
+		}
+	}
+
+	if len(test.Assertions) > 0 {
+		node := &y.Node{}
+		err = r.Yaml.Unmarshal(out, node)
+		if err != nil {
+			result.Error = fmt.Errorf("%w\n  while parsing output", err).Error()
+			return result
+		}
+		for _, assertion := range test.Assertions {
+			err = Check(node, assertion)
+			if err != nil {
+				result.Failures = append(result.Failures, err.Error())
+			}
+		}
+	}
+	return result
+}
+
+func (r *Runner) compose(libraryPath string, test library.Test) ([]byte, error) {
+	template, err := r.File.ReadAndTag(test.Template)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while trying to load template %s", err, test.Template)
+	}
+	executionPlan, err := r.Resolver.Resolve([]string{libraryPath}, test.Scenarios, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while resolving scenarios %v", err, test.Scenarios)
+	}
+	executionPlan.Global = executionPlan.Global.Merge(test.Interpolator)
+	out, err := r.Composer.ComposePlan(template, executionPlan, composer.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  while composing template %s", err, test.Template)
+	}
+	return out, nil
+}
+
+func contains(collection []string, value string) bool {
+	for _, c := range collection {
+		if c == value {
+			return true
+		}
+	}
+	return false
+}
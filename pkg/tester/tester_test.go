@@ -0,0 +1,160 @@
+package tester
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/cjnosal/manifer/v2/pkg/composer"
+	"github.com/cjnosal/manifer/v2/pkg/diff"
+	"github.com/cjnosal/manifer/v2/pkg/file"
+	"github.com/cjnosal/manifer/v2/pkg/library"
+	"github.com/cjnosal/manifer/v2/pkg/plan"
+	"github.com/cjnosal/manifer/v2/pkg/yaml"
+	"github.com/cjnosal/manifer/v2/test"
+)
+
+func TestRun(t *testing.T) {
+
+	lib := &library.Library{
+		Tests: []library.Test{
+			{
+				Name:       "matches",
+				Template:   "/lib/template.yml",
+				Scenarios:  []string{"a"},
+				Expected:   "/lib/expected.yml",
+				Assertions: []string{"/foo == bar"},
+			},
+			{
+				Name:      "differs",
+				Template:  "/lib/template.yml",
+				Scenarios: []string{"b"},
+				Interpolator: library.InterpolatorParams{
+					Vars: map[string]interface{}{"v": "test"},
+				},
+				Expected:   "/lib/expected.yml",
+				Assertions: []string{"/foo == bar", "/count > 1"},
+			},
+			{
+				Name:     "broken",
+				Template: "/lib/missing.yml",
+			},
+		},
+	}
+	loaded := &library.LoadedLibrary{
+		TopLibraries: []*library.Library{lib},
+		Libraries:    map[string]*library.Library{"/lib/library.yml": lib},
+	}
+	template := &file.TaggedBytes{Tag: "/lib/template.yml", Bytes: []byte("template")}
+
+	newPlan := func() *plan.Plan {
+		return &plan.Plan{
+			Global: library.InterpolatorParams{
+				Vars:     map[string]interface{}{},
+				VarFiles: map[string]string{},
+			},
+		}
+	}
+
+	t.Run("results", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLoader := library.NewMockLibraryLoader(ctrl)
+		mockResolver := composer.NewMockScenarioResolver(ctrl)
+		mockComposer := composer.NewMockComposer(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := &Runner{
+			Loader:   mockLoader,
+			Resolver: mockResolver,
+			Composer: mockComposer,
+			Diff:     &diff.TreeDiff{Yaml: &yaml.Yaml{}},
+			File:     mockFile,
+			Yaml:     &yaml.Yaml{},
+		}
+
+		planA := newPlan()
+		planB := newPlan()
+		expectedPlanB := newPlan()
+		expectedPlanB.Global.Vars["v"] = "test"
+
+		mockLoader.EXPECT().Load([]string{"library.yml"}).Times(1).Return(loaded, nil)
+		mockFile.EXPECT().ReadAndTag("/lib/template.yml").Times(2).Return(template, nil)
+		mockFile.EXPECT().ReadAndTag("/lib/missing.yml").Times(1).Return(nil, errors.New("test"))
+		mockFile.EXPECT().Read("/lib/expected.yml").Times(2).Return([]byte("foo: bar\ncount: 1\n"), nil)
+		mockResolver.EXPECT().Resolve([]string{"/lib/library.yml"}, []string{"a"}, []string{}).Times(1).Return(planA, nil)
+		mockResolver.EXPECT().Resolve([]string{"/lib/library.yml"}, []string{"b"}, []string{}).Times(1).Return(planB, nil)
+		mockComposer.EXPECT().ComposePlan(template, planA, composer.Options{}).Times(1).Return([]byte("foo: bar\ncount: 1\n"), nil)
+		mockComposer.EXPECT().ComposePlan(template, expectedPlanB, composer.Options{}).Times(1).Return([]byte("foo: baz\ncount: 1\n"), nil)
+
+		results, err := subject.Run([]string{"library.yml"}, nil)
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedResults := []Result{
+			{Library: "/lib/library.yml", Name: "matches", Passed: true, Changes: []diff.Change{}},
+			{
+				Library: "/lib/library.yml",
+				Name:    "differs",
+				Changes: []diff.Change{{Path: "/foo", Type: diff.Changed, Old: "bar", New: "baz"}},
+				Failures: []string{
+					"/foo == bar (actual: 'baz')",
+					"/count > 1 (actual: 1)",
+				},
+			},
+			{Library: "/lib/library.yml", Name: "broken", Error: "test\n  while trying to load template /lib/missing.yml"},
+		}
+		if !cmp.Equal(expectedResults, results) {
+			t.Errorf("Expected:\n'''%+v'''\nActual:\n'''%+v'''\nDiff:\n%s", expectedResults, results, cmp.Diff(expectedResults, results))
+		}
+	})
+
+	t.Run("selected tests", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLoader := library.NewMockLibraryLoader(ctrl)
+		mockFile := file.NewMockFileAccess(ctrl)
+		subject := &Runner{
+			Loader: mockLoader,
+			File:   mockFile,
+		}
+
+		mockLoader.EXPECT().Load([]string{"library.yml"}).Times(1).Return(loaded, nil)
+		mockFile.EXPECT().ReadAndTag("/lib/missing.yml").Times(1).Return(nil, errors.New("test"))
+
+		results, err := subject.Run([]string{"library.yml"}, []string{"broken"})
+
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expectedResults := []Result{
+			{Library: "/lib/library.yml", Name: "broken", Error: "test\n  while trying to load template /lib/missing.yml"},
+		}
+		if !cmp.Equal(expectedResults, results) {
+			t.Errorf("Expected:\n'''%+v'''\nActual:\n'''%+v'''\n", expectedResults, results)
+		}
+	})
+
+	t.Run("load error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLoader := library.NewMockLibraryLoader(ctrl)
+		subject := &Runner{
+			Loader: mockLoader,
+		}
+
+		mockLoader.EXPECT().Load([]string{"library.yml"}).Times(1).Return(nil, errors.New("test"))
+
+		_, err := subject.Run([]string{"library.yml"}, nil)
+
+		expectedError := errors.New("test\n  while loading libraries")
+		if !cmp.Equal(&err, &expectedError, cmp.Comparer(test.EqualMessage)) {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expectedError, err)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Leaf struct {
+	Path  string
+	Value string
+	Node  *yaml.Node
+}
+
+// list scalars and empty collections in document order, identified by go-patch style pointers.
+// Sequence elements with a unique scalar 'name' key are identified by name=value instead of an index.
+func Leaves(n *yaml.Node) []Leaf {
+	leaves := []Leaf{}
+	collectLeaves(n, "", &leaves)
+	return leaves
+}
+
+func collectLeaves(n *yaml.Node, path string, leaves *[]Leaf) {
+	if n == nil {
+		return
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			collectLeaves(c, path, leaves)
+		}
+	case yaml.AliasNode:
+		collectLeaves(n.Alias, path, leaves)
+	case yaml.ScalarNode:
+		*leaves = append(*leaves, Leaf{Path: rootPath(path), Value: n.Value, Node: n})
+	case yaml.MappingNode:
+		if len(n.Content) == 0 {
+			*leaves = append(*leaves, Leaf{Path: rootPath(path), Value: "{}", Node: n})
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			collectLeaves(n.Content[i+1], fmt.Sprintf("%s/%s", path, EscapeToken(n.Content[i].Value)), leaves)
+		}
+	case yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			*leaves = append(*leaves, Leaf{Path: rootPath(path), Value: "[]", Node: n})
+		}
+		for i, c := range n.Content {
+			collectLeaves(c, fmt.Sprintf("%s/%s", path, ElementToken(n, i)), leaves)
+		}
+	}
+}
+
+// name=value for a sequence element with a name no other element shares, otherwise the index
+func ElementToken(sequence *yaml.Node, index int) string {
+	name, named := elementName(sequence.Content[index])
+	if !named {
+		return fmt.Sprintf("%d", index)
+	}
+	for i, c := range sequence.Content {
+		if other, ok := elementName(c); ok && i != index && other == name {
+			return fmt.Sprintf("%d", index)
+		}
+	}
+	return fmt.Sprintf("name=%s", EscapeToken(name))
+}
+
+func elementName(element *yaml.Node) (string, bool) {
+	if element.Kind == yaml.AliasNode {
+		element = element.Alias
+	}
+	if element.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(element.Content); i += 2 {
+			key := element.Content[i]
+			value := element.Content[i+1]
+			if key.Value == "name" && value.Kind == yaml.ScalarNode {
+				return value.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// go-patch pointer escaping
+func EscapeToken(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+func rootPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
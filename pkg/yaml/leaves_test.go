@@ -0,0 +1,86 @@
+package yaml
+
+import (
+	"github.com/google/go-cmp/cmp"
+	y "gopkg.in/yaml.v3"
+	"testing"
+)
+
+func TestLeaves(t *testing.T) {
+
+	t.Run("paths in document order", func(t *testing.T) {
+		input := `---
+foo:
+  a/b: 1
+  empty: {}
+list:
+- name: first
+  value: x
+- bar
+- []
+`
+		node := &y.Node{}
+		yaml := &Yaml{}
+		err := yaml.Unmarshal([]byte(input), node)
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		paths := []string{}
+		values := []string{}
+		for _, leaf := range Leaves(node) {
+			paths = append(paths, leaf.Path)
+			values = append(values, leaf.Value)
+		}
+
+		expectedPaths := []string{"/foo/a~1b", "/foo/empty", "/list/name=first/name", "/list/name=first/value", "/list/1", "/list/2"}
+		expectedValues := []string{"1", "{}", "first", "x", "bar", "[]"}
+		if !cmp.Equal(expectedPaths, paths) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedPaths, paths)
+		}
+		if !cmp.Equal(expectedValues, values) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedValues, values)
+		}
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		input := `---
+list:
+- name: same
+  value: x
+- name: same
+  value: y
+- name: other
+`
+		node := &y.Node{}
+		yaml := &Yaml{}
+		err := yaml.Unmarshal([]byte(input), node)
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		paths := []string{}
+		for _, leaf := range Leaves(node) {
+			paths = append(paths, leaf.Path)
+		}
+
+		expectedPaths := []string{"/list/0/name", "/list/0/value", "/list/1/name", "/list/1/value", "/list/name=other/name"}
+		if !cmp.Equal(expectedPaths, paths) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedPaths, paths)
+		}
+	})
+
+	t.Run("scalar document", func(t *testing.T) {
+		node := &y.Node{}
+		yaml := &Yaml{}
+		err := yaml.Unmarshal([]byte("foo"), node)
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		leaves := Leaves(node)
+		if len(leaves) != 1 || leaves[0].Path != "/" || leaves[0].Value != "foo" {
+			t.Errorf("Unexpected leaves %+v", leaves)
+		}
+	})
+}
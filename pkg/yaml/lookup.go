@@ -0,0 +1,82 @@
+package yaml
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// find the node at a go-patch style pointer (as listed by Leaves).
+// Sequence elements are selected by index or by key=value.
+func Lookup(n *yaml.Node, path string) (*yaml.Node, bool) {
+	n = resolve(n)
+	if path == "/" || path == "" {
+		return n, n != nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		if n == nil {
+			return nil, false
+		}
+		token = unescapeToken(token)
+		switch n.Kind {
+		case yaml.MappingNode:
+			n = mappingValue(n, token)
+		case yaml.SequenceNode:
+			n = sequenceElement(n, token)
+		default:
+			return nil, false
+		}
+	}
+	return n, n != nil
+}
+
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return resolve(n.Content[i+1])
+		}
+	}
+	return nil
+}
+
+func sequenceElement(n *yaml.Node, token string) *yaml.Node {
+	if index, err := strconv.Atoi(token); err == nil {
+		if index < 0 || index >= len(n.Content) {
+			return nil
+		}
+		return resolve(n.Content[index])
+	}
+	kv := strings.SplitN(token, "=", 2)
+	if len(kv) != 2 {
+		return nil
+	}
+	for _, c := range n.Content {
+		element := resolve(c)
+		if element.Kind != yaml.MappingNode {
+			continue
+		}
+		value := mappingValue(element, kv[0])
+		if value != nil && value.Kind == yaml.ScalarNode && value.Value == kv[1] {
+			return element
+		}
+	}
+	return nil
+}
+
+func resolve(n *yaml.Node) *yaml.Node {
+	for n != nil && (n.Kind == yaml.DocumentNode || n.Kind == yaml.AliasNode) {
+		if n.Kind == yaml.AliasNode {
+			n = n.Alias
+		} else if len(n.Content) > 0 {
+			n = n.Content[0]
+		} else {
+			return nil
+		}
+	}
+	return n
+}
+
+func unescapeToken(token string) string {
+	return strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+}
@@ -0,0 +1,62 @@
+package yaml
+
+import (
+	"github.com/google/go-cmp/cmp"
+	y "gopkg.in/yaml.v3"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+
+	input := `---
+foo:
+  a/b: 1
+list:
+- name: first
+  value: x
+- bar
+alias: &anchor
+  c: d
+ref: *anchor
+`
+	node := &y.Node{}
+	yaml := &Yaml{}
+	err := yaml.Unmarshal([]byte(input), node)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	t.Run("found", func(t *testing.T) {
+		paths := []string{"/foo/a~1b", "/list/name=first/value", "/list/1", "/ref/c"}
+		values := []string{}
+		for _, path := range paths {
+			found, ok := Lookup(node, path)
+			if !ok {
+				t.Errorf("Expected to find %s", path)
+				continue
+			}
+			values = append(values, found.Value)
+		}
+
+		expectedValues := []string{"1", "x", "bar", "d"}
+		if !cmp.Equal(expectedValues, values) {
+			t.Errorf("Expected:\n'''%v'''\nActual:\n'''%v'''\n", expectedValues, values)
+		}
+	})
+
+	t.Run("root", func(t *testing.T) {
+		found, ok := Lookup(node, "/")
+		if !ok || found.Kind != y.MappingNode {
+			t.Errorf("Expected root mapping, got %v", found)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		for _, path := range []string{"/missing", "/foo/a~1b/deeper", "/list/2", "/list/-1", "/list/name=second", "/list/nope"} {
+			found, ok := Lookup(node, path)
+			if ok || found != nil {
+				t.Errorf("Expected %s not to be found, got %v", path, found)
+			}
+		}
+	})
+}
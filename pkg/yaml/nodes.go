@@ -0,0 +1,36 @@
+package yaml
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// index of the key node in a mapping's content, or -1
+func KeyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// the anchored node an alias refers to
+func ResolveAlias(n *yaml.Node) *yaml.Node {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		return n.Alias
+	}
+	return n
+}
+
+// patches may be written as json so json styles are dropped to match the template
+func CopyNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Style = c.Style &^ (yaml.FlowStyle | yaml.DoubleQuotedStyle)
+	c.Line = 0
+	c.Column = 0
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = CopyNode(child)
+	}
+	return &c
+}
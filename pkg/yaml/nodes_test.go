@@ -0,0 +1,54 @@
+package yaml
+
+import (
+	"testing"
+
+	y "gopkg.in/yaml.v3"
+)
+
+func TestNodes(t *testing.T) {
+
+	t.Run("copy drops json styles", func(t *testing.T) {
+		node := &y.Node{}
+		yaml := &Yaml{}
+		err := yaml.Unmarshal([]byte(`{"foo": [1, "bar"]}`), node)
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+
+		mapping := CopyNode(node.Content[0])
+		mapping.Content[1].Content[1].Value = "baz"
+
+		bytes, err := yaml.Marshal(mapping)
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		expected := "foo:\n  - 1\n  - baz\n"
+		if string(bytes) != expected {
+			t.Errorf("Expected:\n'''%s'''\nActual:\n'''%s'''\n", expected, string(bytes))
+		}
+		if node.Content[0].Content[1].Content[1].Value != "bar" {
+			t.Errorf("Expected original node to be unchanged")
+		}
+	})
+
+	t.Run("keys and aliases", func(t *testing.T) {
+		node := &y.Node{}
+		yaml := &Yaml{}
+		err := yaml.Unmarshal([]byte("a: &anchor x\nb: *anchor\n"), node)
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		mapping := node.Content[0]
+
+		if KeyIndex(mapping, "b") != 2 || KeyIndex(mapping, "c") != -1 {
+			t.Errorf("Unexpected key indexes %d %d", KeyIndex(mapping, "b"), KeyIndex(mapping, "c"))
+		}
+		if ResolveAlias(mapping.Content[3]) != mapping.Content[1] {
+			t.Errorf("Expected alias to resolve to its anchor")
+		}
+		if ResolveAlias(mapping.Content[1]) != mapping.Content[1] {
+			t.Errorf("Expected node without alias to be unchanged")
+		}
+	})
+}
@@ -104,3 +104,24 @@ type Ancestor struct {
 	Node  *yaml.Node
 	Token string
 }
+
+// converts maps with non-string keys to map[string]interface{} so values can be marshaled as json
+func StringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, child := range v {
+			m[fmt.Sprintf("%v", key)] = StringKeys(child)
+		}
+		return m
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = StringKeys(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = StringKeys(child)
+		}
+	}
+	return value
+}
@@ -0,0 +1,5 @@
+#!/bin/sh
+# echo the request envelope back as the new document
+printf '{"request": '
+cat
+printf '}'